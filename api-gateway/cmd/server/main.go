@@ -46,6 +46,7 @@ func main() {
 		AllowOrigins:     config.AppConfig.Cors.AllowedOrigins,
 		AllowMethods:     config.AppConfig.Cors.AllowedMethods,
 		AllowHeaders:     config.AppConfig.Cors.AllowedHeaders,
+		ExposeHeaders:    config.AppConfig.Cors.ExposedHeaders,
 		AllowCredentials: config.AppConfig.Cors.AllowCredentials,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -72,6 +73,12 @@ func main() {
 		logger.Fatal().Err(err).Msg("Failed to create products service proxy")
 	}
 
+	sessions := middleware.NewSessionVerifier(
+		config.AppConfig.Services.Auth.BaseURL,
+		config.AppConfig.Services.Auth.Timeout*time.Second,
+		config.AppConfig.Auth.SessionCacheTTL,
+	)
+
 	// Routes
 	api := router.Group("/api/v1")
 	{
@@ -84,16 +91,110 @@ func main() {
 
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware(config.AppConfig.Auth.JWTSecret, logger))
+		protected.Use(middleware.AuthMiddleware(config.AppConfig.Auth.JWTSecret, config.AppConfig.Auth.JWTKeys, sessions, logger))
 		{
+			// Account routes
+			account := protected.Group("/auth")
+			{
+				account.GET("/me", authProxy.Handler())
+				account.PUT("/me/password", authProxy.Handler())
+				account.PUT("/me/email", authProxy.Handler())
+				account.GET("/me/export", authProxy.Handler())
+				account.DELETE("/me", authProxy.Handler())
+				account.POST("/impersonation/stop", authProxy.Handler())
+				account.POST("/admin/users/:id/impersonate", authProxy.Handler())
+				account.DELETE("/admin/users/:id", authProxy.Handler())
+			}
+
 			// Product routes
 			products := protected.Group("/products")
 			{
 				products.GET("", productsProxy.Handler())
+				products.GET("/search", productsProxy.Handler())
+				products.GET("/export", productsProxy.Handler())
+				products.GET("/trash", productsProxy.Handler())
+				products.GET("/drafts", productsProxy.Handler())
+				products.GET("/scheduled", productsProxy.Handler())
+				products.POST("/imports", productsProxy.Handler())
+				products.GET("/imports/:id", productsProxy.Handler())
+				products.GET("/imports/:id/errors", productsProxy.Handler())
+				products.GET("/alerts", productsProxy.Handler())
+				products.GET("/alerts/:id", productsProxy.Handler())
+				products.POST("/alerts/:id/acknowledge", productsProxy.Handler())
+				products.GET("/by-slug/:slug", productsProxy.Handler())
 				products.GET("/:id", productsProxy.Handler())
 				products.POST("", productsProxy.Handler())
 				products.PUT("/:id", productsProxy.Handler())
+				products.PATCH("/:id", productsProxy.Handler())
+				products.POST("/:id/restore", productsProxy.Handler())
+				products.PUT("/:id/status", productsProxy.Handler())
 				products.DELETE("/:id", productsProxy.Handler())
+				products.PUT("/:id/options", productsProxy.Handler())
+				products.GET("/:id/variants", productsProxy.Handler())
+				products.GET("/:id/variants/:variantId", productsProxy.Handler())
+				products.POST("/:id/variants", productsProxy.Handler())
+				products.PUT("/:id/variants/:variantId", productsProxy.Handler())
+				products.DELETE("/:id/variants/:variantId", productsProxy.Handler())
+				products.GET("/:id/images", productsProxy.Handler())
+				products.GET("/:id/images/:imageId", productsProxy.Handler())
+				products.POST("/:id/images", productsProxy.Handler())
+				products.PUT("/:id/images/order", productsProxy.Handler())
+				products.PATCH("/:id/images/:imageId", productsProxy.Handler())
+				products.DELETE("/:id/images/:imageId", productsProxy.Handler())
+				products.POST("/:id/stock/adjust", productsProxy.Handler())
+				products.GET("/:id/stock/movements", productsProxy.Handler())
+				products.GET("/:id/prices", productsProxy.Handler())
+				products.GET("/:id/price-schedules", productsProxy.Handler())
+				products.POST("/:id/price-schedules", productsProxy.Handler())
+				products.DELETE("/:id/price-schedules/:scheduleId", productsProxy.Handler())
+			}
+
+			// Checkout reservation routes
+			reservations := protected.Group("/reservations")
+			{
+				reservations.POST("", productsProxy.Handler())
+				reservations.GET("/:id", productsProxy.Handler())
+				reservations.POST("/:id/commit", productsProxy.Handler())
+				reservations.POST("/:id/cancel", productsProxy.Handler())
+			}
+
+			// Pricing and promotion routes
+			pricing := protected.Group("/pricing")
+			{
+				pricing.POST("/quote", productsProxy.Handler())
+				pricing.POST("/coupons/redeem", productsProxy.Handler())
+			}
+
+			promotions := protected.Group("/promotions")
+			{
+				promotions.GET("", productsProxy.Handler())
+				promotions.GET("/:id", productsProxy.Handler())
+				promotions.POST("", productsProxy.Handler())
+				promotions.PUT("/:id", productsProxy.Handler())
+				promotions.DELETE("/:id", productsProxy.Handler())
+			}
+
+			// Category routes
+			categories := protected.Group("/categories")
+			{
+				categories.GET("", productsProxy.Handler())
+				categories.GET("/:id", productsProxy.Handler())
+				categories.POST("", productsProxy.Handler())
+				categories.PUT("/:id", productsProxy.Handler())
+				categories.POST("/:id/move", productsProxy.Handler())
+				categories.DELETE("/:id", productsProxy.Handler())
+				categories.GET("/:id/attributes", productsProxy.Handler())
+				categories.PUT("/:id/attributes", productsProxy.Handler())
+			}
+
+			// Attribute routes
+			attributes := protected.Group("/attributes")
+			{
+				attributes.GET("", productsProxy.Handler())
+				attributes.GET("/:id", productsProxy.Handler())
+				attributes.POST("", productsProxy.Handler())
+				attributes.PUT("/:id", productsProxy.Handler())
+				attributes.DELETE("/:id", productsProxy.Handler())
 			}
 		}
 	}
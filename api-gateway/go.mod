@@ -3,6 +3,7 @@ module github.com/leandrowiemesfilho/api-gateway
 go 1.25.1
 
 require (
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/gin-contrib/cors v1.7.6
 	github.com/gin-gonic/gin v1.11.0
 	github.com/google/uuid v1.6.0
@@ -35,6 +35,7 @@ type CorsConfig struct {
 	AllowedOrigins   []string `mapstructure:"allowed_origins"`
 	AllowedMethods   []string `mapstructure:"allowed_methods"`
 	AllowedHeaders   []string `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string `mapstructure:"exposed_headers"`
 	AllowCredentials bool     `mapstructure:"allow_credentials"`
 }
 
@@ -58,8 +59,12 @@ type RateLimitingConfig struct {
 }
 
 type AuthConfig struct {
-	JWTSecret   string        `mapstructure:"jwt_secret"`
-	TokenExpiry time.Duration `mapstructure:"token_expiry"`
+	JWTSecret   string            `mapstructure:"jwt_secret"`
+	JWTKeys     map[string]string `mapstructure:"jwt_keys"`
+	TokenExpiry time.Duration     `mapstructure:"token_expiry"`
+	// SessionCacheTTL is how long an active session is trusted before it is
+	// verified with the auth service again
+	SessionCacheTTL time.Duration `mapstructure:"session_cache_ttl"`
 }
 
 var AppConfig *Config
@@ -96,11 +101,14 @@ func setDefaults() {
 	viper.SetDefault("logging.output", "stdout")
 
 	viper.SetDefault("cors.allowed_origins", []string{"*"})
-	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "DELETE"})
-	viper.SetDefault("cors.allowed_headers", []string{"Content-Type", "Authorization"})
+	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE"})
+	viper.SetDefault("cors.allowed_headers", []string{"Content-Type", "Authorization", "If-Match", "If-None-Match"})
+	viper.SetDefault("cors.exposed_headers", []string{"ETag", "Link", "Location"})
 	viper.SetDefault("cors.allow_credentials", true)
 
 	viper.SetDefault("rate_limiting.enabled", true)
 	viper.SetDefault("rate_limiting.requests_per_minute", 100)
 	viper.SetDefault("rate_limiting.burst", 20)
+
+	viper.SetDefault("auth.session_cache_ttl", "30s")
 }
@@ -16,6 +16,14 @@ import (
 	"github.com/leandrowiemesfilho/api-gateway/pkg/errors"
 )
 
+// Identity headers set from the validated token. Upstream services trust them, so
+// any client supplied values are dropped before proxying.
+const (
+	HeaderUserID   = "X-User-ID"
+	HeaderUserRole = "X-User-Role"
+	HeaderActorID  = "X-Actor-ID"
+)
+
 type ServiceProxy struct {
 	target  *url.URL
 	proxy   *httputil.ReverseProxy
@@ -102,12 +110,26 @@ func (p *ServiceProxy) Handler() gin.HandlerFunc {
 		// Update request with timeout context
 		c.Request = c.Request.WithContext(ctx)
 
+		// Forward the authenticated identity
+		for header, key := range map[string]string{
+			HeaderUserID:   "user_id",
+			HeaderUserRole: "user_role",
+			HeaderActorID:  "actor_id",
+		} {
+			c.Request.Header.Del(header)
+			if value := c.GetString(key); value != "" {
+				c.Request.Header.Set(header, value)
+			}
+		}
+
 		// Log the request
 		p.logger.LogInfo("Proxying request", map[string]interface{}{
-			"url":    p.target.String(),
-			"method": c.Request.Method,
-			"path":   c.Request.URL.Path,
-			"query":  c.Request.URL.RawQuery,
+			"url":      p.target.String(),
+			"method":   c.Request.Method,
+			"path":     c.Request.URL.Path,
+			"query":    c.Request.URL.RawQuery,
+			"user_id":  c.GetString("user_id"),
+			"actor_id": c.GetString("actor_id"),
 		})
 
 		// Serve the request
@@ -120,6 +142,8 @@ func (p *ServiceProxy) Handler() gin.HandlerFunc {
 			"path":        c.Request.URL.Path,
 			"status_code": c.Writer.Status(),
 			"client_ip":   c.ClientIP(),
+			"user_id":     c.GetString("user_id"),
+			"actor_id":    c.GetString("actor_id"),
 		})
 	}
 }
@@ -1,15 +1,20 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/leandrowiemesfilho/api-gateway/internal/util"
 	"github.com/leandrowiemesfilho/api-gateway/pkg/errors"
 )
 
-func AuthMiddleware(jwtSecret string, logger *util.Logger) gin.HandlerFunc {
+// AuthMiddleware validates tokens signed with jwtSecret, or with one of jwtKeys
+// (key ID to secret) when the token carries a "kid" header after a key rotation.
+// The session of a valid token is then verified with the auth service.
+func AuthMiddleware(jwtSecret string, jwtKeys map[string]string, sessions *SessionVerifier, logger *util.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Skip authentication for public endpoints
 		if isPublicEndpoint(c.Request.URL.Path) {
@@ -40,8 +45,7 @@ func AuthMiddleware(jwtSecret string, logger *util.Logger) gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		// Validate token (simplified - in real implementation, validate with auth service)
-		userID, err := validateToken(tokenString, jwtSecret)
+		claims, err := validateToken(tokenString, jwtSecret, jwtKeys)
 		if err != nil {
 			logger.Warn().Str("path", c.Request.URL.Path).Msg("Invalid token")
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -52,8 +56,32 @@ func AuthMiddleware(jwtSecret string, logger *util.Logger) gin.HandlerFunc {
 			return
 		}
 
-		// Set user ID in context for downstream services
-		c.Set("user_id", userID)
+		active, err := sessions.Verify(c.Request.Context(), claims.Id, tokenString)
+		if err != nil {
+			logger.Error().Err(err).Str("path", c.Request.URL.Path).Msg("Failed to verify session")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Authentication service unavailable",
+				"code":  http.StatusServiceUnavailable,
+			})
+			c.Abort()
+			return
+		}
+		if !active {
+			logger.Warn().Str("path", c.Request.URL.Path).Str("user_id", claims.UserID).Msg("Revoked session")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Session revoked",
+				"code":  http.StatusUnauthorized,
+			})
+			c.Abort()
+			return
+		}
+
+		// Set user and actor in context for logging and downstream services
+		c.Set("user_id", claims.UserID)
+		c.Set("user_role", claims.Role)
+		if claims.Act != nil && claims.Act.Subject != "" {
+			c.Set("actor_id", claims.Act.Subject)
+		}
 		c.Next()
 	}
 }
@@ -73,22 +101,44 @@ func isPublicEndpoint(path string) bool {
 	return false
 }
 
-func validateToken(tokenString, jwtSecret string) (string, error) {
-	// Simplified token validation
-	// In real implementation, this would:
-	// 1. Validate JWT signature
-	// 2. Check expiration
-	// 3. Possibly call auth service for validation
+// tokenClaims mirrors the claims issued by the auth service
+type tokenClaims struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Act    *struct {
+		Subject string `json:"sub"`
+	} `json:"act,omitempty"`
+	jwt.StandardClaims
+}
 
+func validateToken(tokenString, jwtSecret string, jwtKeys map[string]string) (*tokenClaims, error) {
 	if tokenString == "" {
-		return "", errors.NewUnauthorizedError("Empty token")
+		return nil, errors.NewUnauthorizedError("Empty token")
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return []byte(jwtSecret), nil
+		}
+		secret, ok := jwtKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %s", kid)
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, errors.NewUnauthorizedError("Invalid token: " + err.Error())
 	}
 
-	// Mock validation - replace with real JWT validation
-	if len(tokenString) < 10 {
-		return "", errors.NewUnauthorizedError("Invalid token format")
+	claims, ok := token.Claims.(*tokenClaims)
+	if !ok || !token.Valid || claims.UserID == "" || claims.Id == "" {
+		return nil, errors.NewUnauthorizedError("Invalid token claims")
 	}
 
-	// Mock user ID extraction
-	return "user-" + tokenString[:8], nil
+	return claims, nil
 }
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxCachedSessions bounds the session cache; expired entries are dropped when it is full
+const maxCachedSessions = 10000
+
+// SessionVerifier asks the auth service whether the session of a token is still
+// active, so tokens of revoked sessions and disabled users are rejected before
+// they expire. Active sessions are cached for ttl, which is how long a revoked
+// session may keep working at the gateway.
+type SessionVerifier struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	active map[string]time.Time
+}
+
+// NewSessionVerifier creates a SessionVerifier for the auth service at baseURL
+func NewSessionVerifier(baseURL string, timeout, ttl time.Duration) *SessionVerifier {
+	return &SessionVerifier{
+		url:    baseURL + "/session",
+		client: &http.Client{Timeout: timeout},
+		ttl:    ttl,
+		active: make(map[string]time.Time),
+	}
+}
+
+// Verify reports whether the session sessionID of token is active
+func (v *SessionVerifier) Verify(ctx context.Context, sessionID, token string) (bool, error) {
+	now := time.Now()
+	if v.cached(sessionID, now) {
+		return true, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return false, nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		v.store(sessionID, now)
+		return true, nil
+	default:
+		return false, fmt.Errorf("failed to verify session: auth service returned %s", resp.Status)
+	}
+}
+
+func (v *SessionVerifier) cached(sessionID string, now time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	until, ok := v.active[sessionID]
+	return ok && now.Before(until)
+}
+
+func (v *SessionVerifier) store(sessionID string, now time.Time) {
+	if v.ttl <= 0 {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.active) >= maxCachedSessions {
+		for id, until := range v.active {
+			if !now.Before(until) {
+				delete(v.active, id)
+			}
+		}
+		if len(v.active) >= maxCachedSessions {
+			return
+		}
+	}
+	v.active[sessionID] = now.Add(v.ttl)
+}
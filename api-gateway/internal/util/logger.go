@@ -75,6 +75,8 @@ func (l *Logger) GinLogger() gin.HandlerFunc {
 			Str("user_agent", c.Request.UserAgent()).
 			Dur("latency", latency).
 			Str("request_id", c.GetString("request_id")).
+			Str("user_id", c.GetString("user_id")).
+			Str("actor_id", c.GetString("actor_id")).
 			Msg("HTTP request")
 	}
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+func printKeys(a *app, keys []*model.SigningKey) error {
+	rows := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		status := "active"
+		if key.RetiredAt != nil {
+			status = "retired " + key.RetiredAt.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{key.ID, key.CreatedAt.Format(time.RFC3339), status})
+	}
+
+	if keys == nil {
+		keys = []*model.SigningKey{}
+	}
+	return a.out.print(keys, []string{"ID", "CREATED AT", "STATUS"}, rows)
+}
+
+func listKeys(ctx context.Context, a *app, args []string) error {
+	if err := parseFlags(newFlagSet("keys list"), args); err != nil {
+		return err
+	}
+
+	keys, err := a.signingKeyRepo.List(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	return printKeys(a, keys)
+}
+
+// rotatedKey is printed once so the secret can be distributed to token verifiers
+type rotatedKey struct {
+	ID        string    `json:"id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+	Retired   []string  `json:"retired,omitempty"`
+}
+
+func rotateKeys(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("keys rotate")
+	retirePrevious := flags.Bool("retire-previous", false, "retire all previous keys, invalidating tokens they signed")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	previous, err := a.signingKeyRepo.List(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	generated, err := util.GenerateSigningKey()
+	if err != nil {
+		return err
+	}
+
+	key := &model.SigningKey{
+		ID:        generated.ID,
+		Secret:    generated.Secret,
+		CreatedAt: time.Now(),
+	}
+
+	// Make sure the new key signs and verifies before anything depends on it
+	a.jwtUtil.SetSigningKeys([]util.SigningKey{generated})
+	token, err := a.jwtUtil.GenerateToken(util.TokenSubject{UserID: "authctl"}, time.Now().Add(time.Minute))
+	if err != nil {
+		return err
+	}
+	if _, err := a.jwtUtil.ValidateToken(token); err != nil {
+		return err
+	}
+
+	if err := a.signingKeyRepo.Create(ctx, key); err != nil {
+		return err
+	}
+	if err := a.audit(ctx, model.AuditSigningKeyRotated, nil, map[string]interface{}{"key_id": key.ID}); err != nil {
+		return err
+	}
+
+	result := rotatedKey{ID: key.ID, Secret: key.Secret, CreatedAt: key.CreatedAt}
+	if *retirePrevious {
+		for _, old := range previous {
+			if err := a.signingKeyRepo.Retire(ctx, old.ID); err != nil {
+				return err
+			}
+			if err := a.audit(ctx, model.AuditSigningKeyRetired, nil, map[string]interface{}{"key_id": old.ID}); err != nil {
+				return err
+			}
+			result.Retired = append(result.Retired, old.ID)
+		}
+	}
+
+	rows := [][]string{{result.ID, result.Secret, result.CreatedAt.Format(time.RFC3339)}}
+	return a.out.print(result, []string{"ID", "SECRET", "CREATED AT"}, rows)
+}
+
+func retireKey(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("keys retire")
+	id := flags.String("id", "", "signing key ID")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("--id is required")
+	}
+
+	active, err := a.signingKeyRepo.List(ctx, true)
+	if err != nil {
+		return err
+	}
+	if len(active) == 1 && active[0].ID == *id {
+		return errors.New("refusing to retire the only active key, rotate first")
+	}
+
+	if err := a.signingKeyRepo.Retire(ctx, *id); err != nil {
+		return err
+	}
+	if err := a.audit(ctx, model.AuditSigningKeyRetired, nil, map[string]interface{}{"key_id": *id}); err != nil {
+		return err
+	}
+
+	keys, err := a.signingKeyRepo.List(ctx, false)
+	if err != nil {
+		return err
+	}
+	return printKeys(a, keys)
+}
@@ -0,0 +1,182 @@
+// Command authctl performs auth-service administration directly against the database.
+//
+// It reads the same configuration as the server (./config/config.yaml and the
+// DB_* / JWT_SECRET environment variables) and never prompts, so it can be used
+// from scripts:
+//
+//	authctl user create --email admin@example.com --name Admin --role admin --generate-password
+//	authctl -o json user reset-password --email user@example.com --password-stdin < secret.txt
+//	authctl user disable --email user@example.com
+//	authctl keys rotate
+//	authctl sessions list --email user@example.com
+//	authctl user normalize-emails --dry-run
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/leandrowiemesfilho/auth-service/internal/config"
+	"github.com/leandrowiemesfilho/auth-service/internal/database"
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+const usage = `Usage: authctl [-o table|json] <command> [flags]
+
+Commands:
+  user create            --email --name [--role user|admin] (--password|--password-stdin|--generate-password)
+  user show              (--email|--id)
+  user reset-password    (--email|--id) (--password|--password-stdin|--generate-password)
+  user disable           (--email|--id)
+  user enable            (--email|--id)
+  user normalize-emails  [--dry-run]
+  keys list
+  keys rotate            [--retire-previous]
+  keys retire            --id
+  sessions list          (--email|--id) [--all]
+  token issue            (--email|--id) [--ttl 1h]
+
+Run "authctl <command> <subcommand> -h" for the flags of a command.
+`
+
+// errUsage signals invalid arguments, the message has already been printed
+var errUsage = errors.New("usage error")
+
+// app holds the dependencies shared by all commands
+type app struct {
+	cfg            *config.Config
+	out            *printer
+	stdin          io.Reader
+	userRepo       repository.UserRepository
+	auditRepo      repository.AuditRepository
+	sessionRepo    repository.SessionRepository
+	signingKeyRepo repository.SigningKeyRepository
+	passwordUtil   util.PasswordUtil
+	jwtUtil        util.JWTUtil
+}
+
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"user": {
+		"create":           createUser,
+		"show":             showUser,
+		"reset-password":   resetPassword,
+		"disable":          disableUser,
+		"enable":           enableUser,
+		"normalize-emails": normalizeEmails,
+	},
+	"keys": {
+		"list":   listKeys,
+		"rotate": rotateKeys,
+		"retire": retireKey,
+	},
+	"sessions": {
+		"list": listSessions,
+	},
+	"token": {
+		"issue": issueToken,
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("authctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { fmt.Fprint(stderr, usage) }
+	output := global.String("o", "table", "output format: table or json")
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+
+	rest := global.Args()
+	if len(rest) < 2 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	cmd, ok := commands[rest[0]][rest[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", rest[0]+" "+rest[1], usage)
+		return 2
+	}
+
+	out, err := newPrinter(*output, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+
+	// Keep stdout clean for command output
+	if err := util.InitLogger("warn", cfg.Logger.Format); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	util.Logger.SetOutput(stderr)
+
+	db, err := database.NewDatabase(&cfg.Database)
+	if err != nil {
+		fmt.Fprintf(stderr, "database connection failed: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	a := &app{
+		cfg:            cfg,
+		out:            out,
+		stdin:          stdin,
+		userRepo:       repository.NewUserRepository(db.Pool),
+		auditRepo:      repository.NewAuditRepository(db.Pool),
+		sessionRepo:    repository.NewSessionRepository(db.Pool),
+		signingKeyRepo: repository.NewSigningKeyRepository(db.Pool),
+		passwordUtil:   util.NewPasswordUtil(),
+		jwtUtil:        util.NewJWTUtil(cfg.JWT.Secret, cfg.JWT.Issuer),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	if err := cmd(ctx, a, rest[2:]); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "%s %s: %v\n", rest[0], rest[1], err)
+		return 1
+	}
+
+	return 0
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("authctl "+name, flag.ContinueOnError)
+}
+
+// parseFlags parses the flags of a subcommand, reporting invalid arguments as errUsage
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected arguments: %v\n", flags.Args())
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// printer renders command results as an aligned table or as JSON
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	if format != "table" && format != "json" {
+		return nil, fmt.Errorf("invalid output format %q, use table or json", format)
+	}
+	return &printer{format: format, w: w}, nil
+}
+
+// print writes value as JSON, or headers and rows as a table
+func (p *printer) print(value interface{}, headers []string, rows [][]string) error {
+	if p.format == "json" {
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/service"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+// userSelector resolves the user a command operates on from --email or --id
+type userSelector struct {
+	email string
+	id    string
+}
+
+func (s *userSelector) register(flags *flag.FlagSet) {
+	flags.StringVar(&s.email, "email", "", "user email")
+	flags.StringVar(&s.id, "id", "", "user ID")
+}
+
+func (s *userSelector) resolve(ctx context.Context, a *app) (*model.User, error) {
+	switch {
+	case s.email != "" && s.id != "":
+		return nil, errors.New("use either --email or --id, not both")
+	case s.id != "":
+		return a.userRepo.GetUserByID(ctx, s.id)
+	case s.email != "":
+		normalizedEmail, err := util.NormalizeEmail(s.email, a.cfg.Email.NormalizeUnicode)
+		if err != nil {
+			return nil, err
+		}
+		return a.userRepo.GetUserByEmail(ctx, normalizedEmail)
+	default:
+		return nil, errors.New("--email or --id is required")
+	}
+}
+
+// passwordSource reads a password from exactly one of the password flags
+type passwordSource struct {
+	password string
+	stdin    bool
+	generate bool
+}
+
+func (p *passwordSource) register(flags *flag.FlagSet) {
+	flags.StringVar(&p.password, "password", "", "password (visible in the process list, prefer --password-stdin)")
+	flags.BoolVar(&p.stdin, "password-stdin", false, "read the password from the first line of stdin")
+	flags.BoolVar(&p.generate, "generate-password", false, "generate a random password and print it")
+}
+
+// resolve returns the password and whether it was generated
+func (p *passwordSource) resolve(a *app) (string, bool, error) {
+	sources := 0
+	for _, set := range []bool{p.password != "", p.stdin, p.generate} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return "", false, errors.New("exactly one of --password, --password-stdin or --generate-password is required")
+	}
+
+	switch {
+	case p.generate:
+		password, err := a.passwordUtil.GenerateRandomPassword(20)
+		return password, true, err
+	case p.stdin:
+		line, err := bufio.NewReader(a.stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", false, fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), false, nil
+	default:
+		return p.password, false, nil
+	}
+}
+
+type userResult struct {
+	*model.User
+	Password string `json:"password,omitempty"`
+}
+
+func printUser(a *app, user *model.User, password string) error {
+	status := "active"
+	switch {
+	case user.ErasedAt != nil:
+		status = "erased"
+	case user.IsDisabled():
+		status = "disabled"
+	}
+
+	row := []string{user.ID.String(), user.Email, user.Name, user.Role, status, user.CreatedAt.Format(time.RFC3339)}
+	headers := []string{"ID", "EMAIL", "NAME", "ROLE", "STATUS", "CREATED AT"}
+	if password != "" {
+		headers = append(headers, "PASSWORD")
+		row = append(row, password)
+	}
+
+	return a.out.print(userResult{User: user, Password: password}, headers, [][]string{row})
+}
+
+func createUser(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("user create")
+	email := flags.String("email", "", "user email")
+	name := flags.String("name", "", "display name")
+	role := flags.String("role", model.RoleUser, "role: user or admin")
+	var source passwordSource
+	source.register(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *email == "" || *name == "" {
+		return errors.New("--email and --name are required")
+	}
+	if *role != model.RoleUser && *role != model.RoleAdmin {
+		return fmt.Errorf("invalid role %q", *role)
+	}
+
+	normalizedEmail, err := util.NormalizeEmail(*email, a.cfg.Email.NormalizeUnicode)
+	if err != nil {
+		return err
+	}
+
+	password, generated, err := source.resolve(a)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := a.passwordUtil.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user := &model.User{
+		ID:              uuid.New(),
+		Email:           strings.TrimSpace(*email),
+		EmailNormalized: normalizedEmail,
+		PasswordHash:    hashedPassword,
+		Name:            *name,
+		Role:            *role,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	if err := a.userRepo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	if err := a.audit(ctx, model.AuditUserCreated, &user.ID, map[string]interface{}{"role": user.Role}); err != nil {
+		return err
+	}
+
+	if !generated {
+		password = ""
+	}
+	return printUser(a, user, password)
+}
+
+func showUser(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("user show")
+	var selector userSelector
+	selector.register(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	user, err := selector.resolve(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	return printUser(a, user, "")
+}
+
+func resetPassword(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("user reset-password")
+	var selector userSelector
+	var source passwordSource
+	selector.register(flags)
+	source.register(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	user, err := selector.resolve(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	password, generated, err := source.resolve(a)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := a.passwordUtil.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := a.userRepo.UpdatePassword(ctx, user.ID.String(), hashedPassword); err != nil {
+		return err
+	}
+
+	if err := a.audit(ctx, model.AuditPasswordReset, &user.ID, nil); err != nil {
+		return err
+	}
+
+	if !generated {
+		password = ""
+	}
+	return printUser(a, user, password)
+}
+
+func disableUser(ctx context.Context, a *app, args []string) error {
+	return setDisabled(ctx, a, "user disable", args, true)
+}
+
+func enableUser(ctx context.Context, a *app, args []string) error {
+	return setDisabled(ctx, a, "user enable", args, false)
+}
+
+func setDisabled(ctx context.Context, a *app, name string, args []string, disabled bool) error {
+	flags := newFlagSet(name)
+	var selector userSelector
+	selector.register(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	user, err := selector.resolve(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	if err := a.userRepo.SetDisabled(ctx, user.ID.String(), disabled); err != nil {
+		return err
+	}
+
+	action := model.AuditUserEnabled
+	if disabled {
+		action = model.AuditUserDisabled
+	}
+	if err := a.audit(ctx, action, &user.ID, nil); err != nil {
+		return err
+	}
+
+	user, err = a.userRepo.GetUserByID(ctx, user.ID.String())
+	if err != nil {
+		return err
+	}
+	return printUser(a, user, "")
+}
+
+// normalizeEmails brings the normalized emails of existing users in line with the
+// configured normalization and lists the accounts that changed or need a manual fix
+func normalizeEmails(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("user normalize-emails")
+	dryRun := flags.Bool("dry-run", false, "only report the emails that would change")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	results, err := service.NormalizeStoredEmails(ctx, a.userRepo, a.cfg.Email.NormalizeUnicode, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, []string{result.UserID, result.Email, result.Stored, result.Normalized, result.Status})
+	}
+
+	if results == nil {
+		results = []*service.EmailNormalization{}
+	}
+	return a.out.print(results, []string{"ID", "EMAIL", "STORED", "NORMALIZED", "STATUS"}, rows)
+}
+
+// audit records an operator action; authctl has no authenticated actor
+func (a *app) audit(ctx context.Context, action string, userID *uuid.UUID, metadata map[string]interface{}) error {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["source"] = "authctl"
+
+	return a.auditRepo.Record(ctx, &model.AuditEvent{
+		ID:        uuid.New(),
+		Action:    action,
+		UserID:    userID,
+		Metadata:  metadata,
+		CreatedAt: time.Now(),
+	})
+}
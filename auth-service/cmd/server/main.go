@@ -13,6 +13,8 @@ import (
 	"github.com/leandrowiemesfilho/auth-service/internal/config"
 	"github.com/leandrowiemesfilho/auth-service/internal/database"
 	"github.com/leandrowiemesfilho/auth-service/internal/handler"
+	"github.com/leandrowiemesfilho/auth-service/internal/middleware"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
 	"github.com/leandrowiemesfilho/auth-service/internal/repository"
 	"github.com/leandrowiemesfilho/auth-service/internal/service"
 	"github.com/leandrowiemesfilho/auth-service/internal/util"
@@ -30,6 +32,11 @@ func main() {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 
+	// Schema management subcommand
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
 
@@ -44,37 +51,74 @@ func main() {
 	defer db.Close()
 
 	// Run migrations
-	if err := db.Migrate(); err != nil {
-		util.Error("Failed to run migrations", map[string]interface{}{
-			"error": err.Error(),
-		})
-		log.Fatalf("Migrations failed: %v", err)
+	if cfg.Database.AutoMigrate {
+		if err := db.Migrate(); err != nil {
+			util.Error("Failed to run migrations", map[string]interface{}{
+				"error": err.Error(),
+			})
+			log.Fatalf("Migrations failed: %v", err)
+		}
 	}
 
 	// Initialize utilities
 	jwtUtil := util.NewJWTUtil(cfg.JWT.Secret, cfg.JWT.Issuer)
 	passwordUtil := util.NewPasswordUtil()
 
-	// Initialize repository
+	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.Pool)
+	auditRepo := repository.NewAuditRepository(db.Pool)
+	sessionRepo := repository.NewSessionRepository(db.Pool)
+	signingKeyRepo := repository.NewSigningKeyRepository(db.Pool)
+	erasureRepo := repository.NewErasureRepository(db.Pool)
+	outboxRepo := repository.NewOutboxRepository(db.Pool)
+
+	// Load rotated signing keys and keep them in sync
+	if err := service.LoadSigningKeys(context.Background(), signingKeyRepo, jwtUtil); err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if cfg.JWT.KeyRefreshSeconds > 0 {
+		go service.RefreshSigningKeys(backgroundCtx, signingKeyRepo, jwtUtil, time.Duration(cfg.JWT.KeyRefreshSeconds)*time.Second)
+	}
 
 	// Initialize service
 	authService := service.NewAuthService(
 		userRepo,
+		auditRepo,
+		sessionRepo,
 		jwtUtil,
 		passwordUtil,
 		&service.JWTConfig{
-			Secret:          cfg.JWT.Secret,
-			ExpirationHours: cfg.JWT.ExpirationHours,
-			Issuer:          cfg.JWT.Issuer,
+			Secret:           cfg.JWT.Secret,
+			ExpirationHours:  cfg.JWT.ExpirationHours,
+			Issuer:           cfg.JWT.Issuer,
+			ImpersonationTTL: time.Duration(cfg.JWT.ImpersonationTTLMinutes) * time.Minute,
+		},
+		&service.EmailConfig{
+			NormalizeUnicode: cfg.Email.NormalizeUnicode,
 		},
 	)
 
+	gdprService := service.NewGDPRService(userRepo, auditRepo, sessionRepo, erasureRepo, passwordUtil)
+
+	// Deliver outbox events to other services
+	if len(cfg.Events.Subscribers) > 0 {
+		dispatcher := service.NewEventDispatcher(outboxRepo, &service.EventsConfig{
+			Subscribers: cfg.Events.Subscribers,
+			Secret:      cfg.Events.Secret,
+			Interval:    time.Duration(cfg.Events.DispatchIntervalSeconds) * time.Second,
+			BatchSize:   cfg.Events.BatchSize,
+		})
+		go dispatcher.Run(backgroundCtx)
+	}
+
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
+	gdprHandler := handler.NewGDPRHandler(gdprService)
 
 	// Setup router
-	router := setupRouter(authHandler)
+	router := setupRouter(authHandler, gdprHandler, jwtUtil, sessionRepo)
 
 	// Start server
 	srv := &http.Server{
@@ -119,7 +163,7 @@ func main() {
 	util.Info("Server exited properly", nil)
 }
 
-func setupRouter(authHandler *handler.AuthHandler) *gin.Engine {
+func setupRouter(authHandler *handler.AuthHandler, gdprHandler *handler.GDPRHandler, jwtUtil util.JWTUtil, sessionRepo repository.SessionRepository) *gin.Engine {
 	router := gin.New()
 
 	// Global middleware
@@ -131,5 +175,33 @@ func setupRouter(authHandler *handler.AuthHandler) *gin.Engine {
 	router.POST("/register", authHandler.Register)
 	router.POST("/login", authHandler.Login)
 
+	// Authenticated routes
+	me := router.Group("/me")
+	me.Use(middleware.RequireAuth(jwtUtil, sessionRepo))
+	{
+		me.GET("", authHandler.Me)
+		me.PUT("/password", middleware.DenyImpersonation(), authHandler.ChangePassword)
+		me.PUT("/email", middleware.DenyImpersonation(), authHandler.ChangeEmail)
+		me.GET("/export", middleware.DenyImpersonation(), gdprHandler.Export)
+		me.DELETE("", middleware.DenyImpersonation(), gdprHandler.EraseAccount)
+	}
+
+	// Lets the gateway check that the session of a token has not been revoked
+	router.GET("/session", middleware.RequireAuth(jwtUtil, sessionRepo), authHandler.Session)
+
+	router.POST("/impersonation/stop", middleware.RequireAuth(jwtUtil, sessionRepo), authHandler.StopImpersonation)
+
+	// Admin routes
+	admin := router.Group("/admin")
+	admin.Use(
+		middleware.RequireAuth(jwtUtil, sessionRepo),
+		middleware.DenyImpersonation(),
+		middleware.RequireRole(model.RoleAdmin),
+	)
+	{
+		admin.POST("/users/:id/impersonate", authHandler.Impersonate)
+		admin.DELETE("/users/:id", gdprHandler.EraseUser)
+	}
+
 	return router
 }
@@ -14,6 +14,8 @@ type Config struct {
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Logger   LoggerConfig
+	Email    EmailConfig
+	Events   EventsConfig
 }
 
 type ServerConfig struct {
@@ -24,18 +26,33 @@ type ServerConfig struct {
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host        string
+	Port        string
+	User        string
+	Password    string
+	DBName      string
+	SSLMode     string
+	AutoMigrate bool `mapstructure:"auto_migrate"`
 }
 
 type JWTConfig struct {
-	Secret          string
-	ExpirationHours int
-	Issuer          string
+	Secret                  string
+	ExpirationHours         int
+	Issuer                  string
+	ImpersonationTTLMinutes int `mapstructure:"impersonation_ttl_minutes"`
+	KeyRefreshSeconds       int `mapstructure:"key_refresh_seconds"`
+}
+
+type EmailConfig struct {
+	NormalizeUnicode bool `mapstructure:"normalize_unicode"`
+}
+
+// EventsConfig controls delivery of outbox events such as user.erased
+type EventsConfig struct {
+	Subscribers             []string
+	Secret                  string
+	DispatchIntervalSeconds int `mapstructure:"dispatch_interval_seconds"`
+	BatchSize               int `mapstructure:"batch_size"`
 }
 
 type LoggerConfig struct {
@@ -54,9 +71,15 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("server.read_timeout", 30)
 	viper.SetDefault("server.write_timeout", 30)
 	viper.SetDefault("database.sslmode", "disable")
+	viper.SetDefault("database.auto_migrate", true)
 	viper.SetDefault("jwt.expiration_hours", 24)
+	viper.SetDefault("jwt.impersonation_ttl_minutes", 15)
+	viper.SetDefault("jwt.key_refresh_seconds", 60)
 	viper.SetDefault("logger.level", "info")
 	viper.SetDefault("logger.format", "json")
+	viper.SetDefault("email.normalize_unicode", true)
+	viper.SetDefault("events.dispatch_interval_seconds", 5)
+	viper.SetDefault("events.batch_size", 50)
 
 	// Bind environment variables
 	viper.AutomaticEnv()
@@ -66,6 +89,7 @@ func LoadConfig() (*Config, error) {
 	viper.BindEnv("database.password", "DB_PASSWORD")
 	viper.BindEnv("database.dbname", "DB_NAME")
 	viper.BindEnv("jwt.secret", "JWT_SECRET")
+	viper.BindEnv("events.secret", "EVENTS_SECRET")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -97,5 +121,11 @@ func validateConfig(config *Config) error {
 	if config.Database.DBName == "" {
 		return fmt.Errorf("database name is required")
 	}
+	if len(config.Events.Subscribers) > 0 && config.Events.Secret == "" {
+		return fmt.Errorf("events secret is required when event subscribers are configured")
+	}
+	if len(config.Events.Subscribers) > 0 && config.Events.DispatchIntervalSeconds <= 0 {
+		return fmt.Errorf("events dispatch interval must be positive")
+	}
 	return nil
 }
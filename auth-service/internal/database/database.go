@@ -54,30 +54,16 @@ func (db *Database) Close() {
 	}
 }
 
+// Migrate applies all pending versioned migrations
 func (db *Database) Migrate() error {
-	query := `
-        CREATE TABLE IF NOT EXISTS users (
-            id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-            email VARCHAR(255) UNIQUE NOT NULL,
-            password_hash VARCHAR(255) NOT NULL,
-            name VARCHAR(255) NOT NULL,
-            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-        );
-
-        CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
-        CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);
-    `
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	_, err := db.Pool.Exec(ctx, query)
+	migrator, err := NewMigrator(db.Pool)
 	if err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+		return err
 	}
+	defer migrator.Close()
 
-	util.Info("Database migrations completed successfully", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
 
-	return nil
+	return migrator.Up(ctx)
 }
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+	"github.com/leandrowiemesfilho/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the pg_advisory_lock key that serializes migrations across replicas
+const migrationLockID int64 = 7_342_001
+
+// Migrator applies the embedded, versioned SQL migrations over its own
+// database/sql handle, which Close releases
+type Migrator struct {
+	*migrate.Migrator
+	db *sql.DB
+}
+
+func NewMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := migrate.Load(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	db := stdlib.OpenDB(*pool.Config().ConnConfig)
+	return &Migrator{
+		Migrator: migrate.New(db, migrations, migrationLockID, migrationLogger{}),
+		db:       db,
+	}, nil
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// migrationLogger logs through util, turning key/value pairs into fields
+type migrationLogger struct{}
+
+func (migrationLogger) Infow(msg string, keysAndValues ...interface{}) {
+	util.Info(msg, migrationFields(keysAndValues))
+}
+
+func (migrationLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	util.Error(msg, migrationFields(keysAndValues))
+}
+
+func migrationFields(keysAndValues []interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if key, ok := keysAndValues[i].(string); ok {
+			fields[key] = keysAndValues[i+1]
+		}
+	}
+	return fields
+}
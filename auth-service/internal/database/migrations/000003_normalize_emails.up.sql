@@ -0,0 +1,26 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_normalized VARCHAR(255);
+
+UPDATE users SET email_normalized = lower(btrim(email)) WHERE email_normalized IS NULL;
+
+-- Accounts that only differ by case or surrounding whitespace can not be merged
+-- automatically. Report them all at once instead of failing on the first one.
+DO $$
+DECLARE
+    collisions TEXT;
+BEGIN
+    SELECT string_agg(format('%s: %s', email_normalized, user_ids), E'\n')
+    INTO collisions
+    FROM (
+        SELECT email_normalized, string_agg(id::text, ', ' ORDER BY created_at) AS user_ids
+        FROM users
+        GROUP BY email_normalized
+        HAVING COUNT(*) > 1
+    ) duplicates;
+
+    IF collisions IS NOT NULL THEN
+        RAISE EXCEPTION 'normalized email collisions, merge or rename these accounts and migrate again:%', E'\n' || collisions;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_normalized ON users(email_normalized);
+ALTER TABLE users ALTER COLUMN email_normalized SET NOT NULL;
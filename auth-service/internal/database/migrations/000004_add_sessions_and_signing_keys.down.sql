@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS signing_keys;
+DROP TABLE IF EXISTS sessions;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
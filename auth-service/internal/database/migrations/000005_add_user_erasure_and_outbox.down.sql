@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS outbox_events;
+ALTER TABLE users DROP COLUMN IF EXISTS erased_at;
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/auth-service/internal/middleware"
 	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
 	"github.com/leandrowiemesfilho/auth-service/internal/service"
 	"github.com/leandrowiemesfilho/auth-service/internal/util"
 )
@@ -29,7 +32,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	authResponse, err := h.authService.Register(c.Request.Context(), &req)
+	authResponse, err := h.authService.Register(c.Request.Context(), &req, requestMeta(c))
 	if err != nil {
 		util.Error("Registration failed", map[string]interface{}{
 			"error": err.Error(),
@@ -39,10 +42,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		statusCode := http.StatusInternalServerError
 		errorMsg := "Registration failed"
 
-		// You can add more specific error handling here
-		if err.Error() == "email already registered" {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateEmail):
 			statusCode = http.StatusConflict
 			errorMsg = "Email already registered"
+		case errors.Is(err, util.ErrInvalidEmail):
+			statusCode = http.StatusBadRequest
+			errorMsg = "Invalid email address"
 		}
 
 		c.JSON(statusCode, model.ErrorResponse{
@@ -71,13 +77,20 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	authResponse, err := h.authService.Login(c.Request.Context(), &req)
+	authResponse, err := h.authService.Login(c.Request.Context(), &req, requestMeta(c))
 	if err != nil {
 		util.Warn("Login failed", map[string]interface{}{
 			"error": err.Error(),
 			"email": req.Email,
 		})
 
+		if errors.Is(err, service.ErrAccountDisabled) {
+			c.JSON(http.StatusForbidden, model.ErrorResponse{
+				Error: "Account disabled",
+			})
+			return
+		}
+
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
 			Error: "Invalid credentials",
 		})
@@ -92,6 +105,117 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, authResponse)
 }
 
+func (h *AuthHandler) Me(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	user, err := h.authService.GetProfile(c.Request.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: "User not found",
+			})
+			return
+		}
+
+		util.Error("Failed to get profile", map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": claims.UserID,
+		})
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: "Failed to get profile",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req model.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: "Invalid request payload",
+		})
+		return
+	}
+
+	if err := h.authService.ChangePassword(c.Request.Context(), claims.UserID, &req); err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Error: "Invalid credentials",
+			})
+			return
+		}
+
+		util.Error("Password change failed", map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": claims.UserID,
+		})
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: "Password change failed",
+		})
+		return
+	}
+
+	util.Info("Password changed successfully", map[string]interface{}{
+		"user_id": claims.UserID,
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ChangeEmail(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req model.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: "Invalid request payload",
+		})
+		return
+	}
+
+	user, err := h.authService.ChangeEmail(c.Request.Context(), claims.UserID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Error: "Invalid credentials",
+			})
+		case errors.Is(err, repository.ErrDuplicateEmail):
+			c.JSON(http.StatusConflict, model.ErrorResponse{
+				Error: "Email already registered",
+			})
+		case errors.Is(err, util.ErrInvalidEmail):
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: "Invalid email address",
+			})
+		default:
+			util.Error("Email change failed", map[string]interface{}{
+				"error":   err.Error(),
+				"user_id": claims.UserID,
+			})
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: "Email change failed",
+			})
+		}
+		return
+	}
+
+	util.Info("Email changed successfully", map[string]interface{}{
+		"user_id": claims.UserID,
+	})
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Session answers 204 when the token was accepted by RequireAuth
+func (h *AuthHandler) Session(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
 func (h *AuthHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "healthy",
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/auth-service/internal/middleware"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
+	"github.com/leandrowiemesfilho/auth-service/internal/service"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+type GDPRHandler struct {
+	gdprService service.GDPRService
+}
+
+func NewGDPRHandler(gdprService service.GDPRService) *GDPRHandler {
+	return &GDPRHandler{gdprService: gdprService}
+}
+
+func (h *GDPRHandler) Export(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	export, err := h.gdprService.Export(c.Request.Context(), claims.UserID, requestMeta(c))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: "User not found",
+			})
+			return
+		}
+
+		util.Error("Data export failed", map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": claims.UserID,
+		})
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: "Data export failed",
+		})
+		return
+	}
+
+	util.Info("User data exported", map[string]interface{}{
+		"user_id": claims.UserID,
+	})
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s-export.json"`, claims.UserID))
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, export)
+}
+
+func (h *GDPRHandler) EraseAccount(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req model.EraseAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "Invalid request payload",
+			Details: "confirm the erasure with your password",
+		})
+		return
+	}
+
+	response, err := h.gdprService.EraseAccount(c.Request.Context(), claims.UserID, &req, requestMeta(c))
+	h.respondErasure(c, claims.UserID, claims.UserID, response, err)
+}
+
+func (h *GDPRHandler) EraseUser(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	userID := c.Param("id")
+
+	response, err := h.gdprService.EraseUser(c.Request.Context(), claims.UserID, userID, requestMeta(c))
+	h.respondErasure(c, claims.UserID, userID, response, err)
+}
+
+func (h *GDPRHandler) respondErasure(c *gin.Context, actorID, userID string, response *model.ErasureResponse, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Error: "Invalid credentials",
+			})
+		case errors.Is(err, repository.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: "User not found",
+			})
+		default:
+			util.Error("Erasure failed", map[string]interface{}{
+				"error":    err.Error(),
+				"actor_id": actorID,
+				"user_id":  userID,
+			})
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: "Erasure failed",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
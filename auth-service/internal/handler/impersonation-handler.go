@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/auth-service/internal/middleware"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
+	"github.com/leandrowiemesfilho/auth-service/internal/service"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+func (h *AuthHandler) Impersonate(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	targetID := c.Param("id")
+
+	var req model.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "Invalid request payload",
+			Details: "a reason for the impersonation is required",
+		})
+		return
+	}
+
+	response, err := h.authService.Impersonate(c.Request.Context(), claims.UserID, targetID, &req, requestMeta(c))
+	if err != nil {
+		util.Warn("Impersonation failed", map[string]interface{}{
+			"error":    err.Error(),
+			"actor_id": claims.UserID,
+			"user_id":  targetID,
+		})
+
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: "User not found",
+			})
+		case errors.Is(err, service.ErrImpersonationNotAllowed):
+			c.JSON(http.StatusForbidden, model.ErrorResponse{
+				Error:   "Impersonation not allowed",
+				Details: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: "Impersonation failed",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) StopImpersonation(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	if err := h.authService.StopImpersonation(c.Request.Context(), claims, requestMeta(c)); err != nil {
+		if errors.Is(err, service.ErrNotImpersonating) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: "Not impersonating",
+			})
+			return
+		}
+
+		util.Error("Failed to stop impersonation", map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": claims.UserID,
+		})
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: "Failed to stop impersonation",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func requestMeta(c *gin.Context) model.RequestMeta {
+	return model.RequestMeta{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/google/uuid"
+)
+
+const (
+	AuditImpersonationStart = "impersonation.start"
+	AuditImpersonationStop  = "impersonation.stop"
+	AuditUserCreated        = "user.created"
+	AuditPasswordReset      = "user.password_reset"
+	AuditUserDisabled       = "user.disabled"
+	AuditUserEnabled        = "user.enabled"
+	AuditSigningKeyRotated  = "signing_key.rotated"
+	AuditSigningKeyRetired  = "signing_key.retired"
+	AuditDataExported       = "user.data_exported"
+	AuditUserErased         = "user.erased"
+)
+
+// AuditEvent is an append-only record of a security relevant action.
+// ActorID is who performed the action, UserID whose account it affected.
+type AuditEvent struct {
+	ID        uuid.UUID              `json:"id" db:"id"`
+	Action    string                 `json:"action" db:"action"`
+	ActorID   *uuid.UUID             `json:"actor_id,omitempty" db:"actor_id"`
+	UserID    *uuid.UUID             `json:"user_id,omitempty" db:"user_id"`
+	IPAddress string                 `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent string                 `json:"user_agent,omitempty" db:"user_agent"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+}
+
+// RequestMeta carries request details recorded alongside audit events
+type RequestMeta struct {
+	IPAddress string
+	UserAgent string
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/google/uuid"
+)
+
+const (
+	EventUserErased = "user.erased"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change that
+// produced it and delivered to subscribers afterwards
+type OutboxEvent struct {
+	ID          uuid.UUID              `json:"id" db:"id"`
+	Type        string                 `json:"type" db:"event_type"`
+	Payload     map[string]interface{} `json:"data" db:"payload"`
+	Attempts    int                    `json:"-" db:"attempts"`
+	CreatedAt   time.Time              `json:"occurred_at" db:"created_at"`
+	DeliveredAt *time.Time             `json:"-" db:"delivered_at"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+type EraseAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// UserExport is the machine-readable archive returned for a data subject access request
+type UserExport struct {
+	ExportedAt  time.Time     `json:"exported_at"`
+	Profile     *User         `json:"profile"`
+	Sessions    []*Session    `json:"sessions"`
+	AuditEvents []*AuditEvent `json:"audit_events"`
+}
+
+type ErasureResponse struct {
+	UserID        string    `json:"user_id"`
+	ErasedAt      time.Time `json:"erased_at"`
+	AlreadyErased bool      `json:"already_erased"`
+}
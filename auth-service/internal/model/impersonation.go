@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+)
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+	ActorID   string    `json:"actor_id"`
+}
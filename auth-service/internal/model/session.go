@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/google/uuid"
+)
+
+// Session records an issued access token, its ID is the token "jti" claim
+type Session struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty" db:"actor_id"`
+	IPAddress string     `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent string     `json:"user_agent,omitempty" db:"user_agent"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+}
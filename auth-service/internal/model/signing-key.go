@@ -0,0 +1,12 @@
+package model
+
+import (
+	"time"
+)
+
+type SigningKey struct {
+	ID        string     `json:"id" db:"id"`
+	Secret    string     `json:"-" db:"secret"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty" db:"retired_at"`
+}
@@ -6,13 +6,27 @@ import (
 	uuid "github.com/google/uuid"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID           uuid.UUID `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	Name         string    `json:"name" db:"name"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID              uuid.UUID  `json:"id" db:"id"`
+	Email           string     `json:"email" db:"email"`
+	EmailNormalized string     `json:"-" db:"email_normalized"`
+	PasswordHash    string     `json:"-" db:"password_hash"`
+	Name            string     `json:"name" db:"name"`
+	Role            string     `json:"role" db:"role"`
+	DisabledAt      *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	ErasedAt        *time.Time `json:"erased_at,omitempty" db:"erased_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsDisabled reports whether the account was disabled by an operator
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
 }
 
 type RegisterRequest struct {
@@ -26,6 +40,16 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
 type AuthResponse struct {
 	Token string `json:"token"`
 	User  *User  `json:"user"`
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+type AuditRepository interface {
+	Record(ctx context.Context, event *model.AuditEvent) error
+	// ListByUser returns events affecting or performed by userID, oldest first
+	ListByUser(ctx context.Context, userID string) ([]*model.AuditEvent, error)
+}
+
+type auditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Record(ctx context.Context, event *model.AuditEvent) error {
+	query := `
+        INSERT INTO audit_events (id, action, actor_id, user_id, ip_address, user_agent, metadata, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		event.ID,
+		event.Action,
+		event.ActorID,
+		event.UserID,
+		event.IPAddress,
+		event.UserAgent,
+		metadata,
+		event.CreatedAt,
+	)
+
+	if err != nil {
+		util.Error("Failed to record audit event", map[string]interface{}{
+			"error":  err,
+			"action": event.Action,
+		})
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *auditRepository) ListByUser(ctx context.Context, userID string) ([]*model.AuditEvent, error) {
+	query := `
+        SELECT id, action, actor_id, user_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), metadata, created_at
+        FROM audit_events
+        WHERE user_id = $1 OR actor_id = $1
+        ORDER BY created_at
+    `
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		util.Error("Failed to list audit events", map[string]interface{}{
+			"error":   err,
+			"user_id": userID,
+		})
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.AuditEvent
+	for rows.Next() {
+		var event model.AuditEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.Action,
+			&event.ActorID,
+			&event.UserID,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.Metadata,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+// ErasureRepository anonymizes a user and everything that points back to them
+type ErasureRepository interface {
+	// EraseUser anonymizes the user, deletes their sessions and the impersonation
+	// sessions they started, so their tokens stop working, scrubs network details from
+	// their audit trail and writes audit and outbox events, all in one transaction.
+	// Users that are already erased are left untouched and only the audit event is
+	// written; the original erasure time is returned with alreadyErased set.
+	EraseUser(ctx context.Context, id string, audit *model.AuditEvent, event *model.OutboxEvent) (erasedAt time.Time, alreadyErased bool, err error)
+}
+
+type erasureRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewErasureRepository(db *pgxpool.Pool) ErasureRepository {
+	return &erasureRepository{db: db}
+}
+
+// ErasedEmail is the placeholder address of an erased user. It stays unique per user
+// and uses the reserved .invalid TLD so it can never receive mail or be registered.
+func ErasedEmail(id string) string {
+	return "erased+" + id + "@erased.invalid"
+}
+
+func (r *erasureRepository) EraseUser(ctx context.Context, id string, audit *model.AuditEvent, event *model.OutboxEvent) (time.Time, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var erasedAt *time.Time
+	err = tx.QueryRow(ctx, `SELECT erased_at FROM users WHERE id = $1 FOR UPDATE`, id).Scan(&erasedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, false, ErrUserNotFound
+		}
+		return time.Time{}, false, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	alreadyErased := erasedAt != nil
+	if !alreadyErased {
+		now := time.Now()
+		erasedAt = &now
+
+		query := `
+            UPDATE users
+            SET email = $2, email_normalized = $2, name = '', password_hash = '', role = $3,
+                disabled_at = COALESCE(disabled_at, $4), erased_at = $4, updated_at = $4
+            WHERE id = $1
+        `
+		if _, err := tx.Exec(ctx, query, id, ErasedEmail(id), model.RoleUser, now); err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to anonymize user: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM sessions WHERE user_id = $1 OR actor_id = $1`, id); err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to delete sessions: %w", err)
+		}
+
+		query = `
+            UPDATE audit_events
+            SET ip_address = NULL, user_agent = NULL
+            WHERE user_id = $1 OR actor_id = $1
+        `
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to scrub audit events: %w", err)
+		}
+
+		event.Payload["erased_at"] = now.UTC()
+		if err := insertOutboxEvent(ctx, tx, event); err != nil {
+			return time.Time{}, false, err
+		}
+	}
+
+	audit.Metadata["already_erased"] = alreadyErased
+	query := `
+        INSERT INTO audit_events (id, action, actor_id, user_id, ip_address, user_agent, metadata, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+	if _, err := tx.Exec(ctx, query, audit.ID, audit.Action, audit.ActorID, audit.UserID, audit.IPAddress, audit.UserAgent, audit.Metadata, audit.CreatedAt); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		util.Error("Failed to erase user", map[string]interface{}{
+			"error":   err,
+			"user_id": id,
+		})
+		return time.Time{}, false, fmt.Errorf("failed to erase user: %w", err)
+	}
+
+	return *erasedAt, alreadyErased, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+// OutboxRepository reads and settles events written by other repositories in the
+// same transaction as the change that produced them
+type OutboxRepository interface {
+	// ClaimPending locks up to limit due events and passes them to deliver. The
+	// deliveries returned are settled before the locks are released, so several
+	// dispatchers never send the same event concurrently.
+	ClaimPending(ctx context.Context, limit int, deliver func([]*model.OutboxEvent) map[string]error) error
+}
+
+type outboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// insertOutboxEvent writes event as part of tx
+func insertOutboxEvent(ctx context.Context, tx pgx.Tx, event *model.OutboxEvent) error {
+	query := `
+        INSERT INTO outbox_events (id, event_type, payload, created_at, next_attempt_at)
+        VALUES ($1, $2, $3, $4, $4)
+    `
+
+	payload := event.Payload
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+
+	if _, err := tx.Exec(ctx, query, event.ID, event.Type, payload, event.CreatedAt); err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) ClaimPending(ctx context.Context, limit int, deliver func([]*model.OutboxEvent) map[string]error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        SELECT id, event_type, payload, attempts, created_at
+        FROM outbox_events
+        WHERE delivered_at IS NULL AND next_attempt_at <= NOW()
+        ORDER BY created_at
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `
+
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		util.Error("Failed to read outbox", map[string]interface{}{
+			"error": err,
+		})
+		return fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	var events []*model.OutboxEvent
+	for rows.Next() {
+		var event model.OutboxEvent
+		if err := rows.Scan(&event.ID, &event.Type, &event.Payload, &event.Attempts, &event.CreatedAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, &event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	failures := deliver(events)
+
+	for _, event := range events {
+		deliveryErr, failed := failures[event.ID.String()]
+		if !failed {
+			if _, err := tx.Exec(ctx, `UPDATE outbox_events SET delivered_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`, event.ID); err != nil {
+				return fmt.Errorf("failed to mark outbox event delivered: %w", err)
+			}
+			continue
+		}
+
+		retryAt := time.Now().Add(outboxBackoff(event.Attempts + 1))
+		if _, err := tx.Exec(ctx, `UPDATE outbox_events SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`, event.ID, deliveryErr.Error(), retryAt); err != nil {
+			return fmt.Errorf("failed to reschedule outbox event: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// outboxBackoff doubles the retry delay per attempt, capped at one hour
+func outboxBackoff(attempts int) time.Duration {
+	delay := 5 * time.Second
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	ListByUser(ctx context.Context, userID string, activeOnly bool) ([]*model.Session, error)
+	// IsActive reports whether the session exists, has not expired and belongs to
+	// a user that is neither disabled nor erased
+	IsActive(ctx context.Context, id string) (bool, error)
+	// Delete revokes a session; tokens bound to it are rejected from then on
+	Delete(ctx context.Context, id string) error
+}
+
+type sessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	query := `
+        INSERT INTO sessions (id, user_id, actor_id, ip_address, user_agent, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		session.ID,
+		session.UserID,
+		session.ActorID,
+		session.IPAddress,
+		session.UserAgent,
+		session.CreatedAt,
+		session.ExpiresAt,
+	)
+
+	if err != nil {
+		util.Error("Failed to create session", map[string]interface{}{
+			"error":   err,
+			"user_id": session.UserID,
+		})
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) ListByUser(ctx context.Context, userID string, activeOnly bool) ([]*model.Session, error) {
+	query := `
+        SELECT id, user_id, actor_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), created_at, expires_at
+        FROM sessions
+        WHERE user_id = $1 AND (NOT $2 OR expires_at > NOW())
+        ORDER BY created_at DESC
+    `
+
+	rows, err := r.db.Query(ctx, query, userID, activeOnly)
+	if err != nil {
+		util.Error("Failed to list sessions", map[string]interface{}{
+			"error":   err,
+			"user_id": userID,
+		})
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		var session model.Session
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.ActorID,
+			&session.IPAddress,
+			&session.UserAgent,
+			&session.CreatedAt,
+			&session.ExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) IsActive(ctx context.Context, id string) (bool, error) {
+	query := `
+        SELECT EXISTS (
+            SELECT 1 FROM sessions s
+            JOIN users u ON u.id = s.user_id
+            WHERE s.id = $1 AND s.expires_at > NOW() AND u.disabled_at IS NULL AND u.erased_at IS NULL
+        )
+    `
+
+	var active bool
+	if err := r.db.QueryRow(ctx, query, id).Scan(&active); err != nil {
+		util.Error("Failed to check session", map[string]interface{}{
+			"error":      err,
+			"session_id": id,
+		})
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return active, nil
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM sessions WHERE id = $1`, id); err != nil {
+		util.Error("Failed to delete session", map[string]interface{}{
+			"error":      err,
+			"session_id": id,
+		})
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+var ErrSigningKeyNotFound = errors.New("signing key not found")
+
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *model.SigningKey) error
+	List(ctx context.Context, activeOnly bool) ([]*model.SigningKey, error)
+	Retire(ctx context.Context, id string) error
+}
+
+type signingKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSigningKeyRepository(db *pgxpool.Pool) SigningKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+func (r *signingKeyRepository) Create(ctx context.Context, key *model.SigningKey) error {
+	query := `
+        INSERT INTO signing_keys (id, secret, created_at)
+        VALUES ($1, $2, $3)
+    `
+
+	if _, err := r.db.Exec(ctx, query, key.ID, key.Secret, key.CreatedAt); err != nil {
+		util.Error("Failed to create signing key", map[string]interface{}{
+			"error":  err,
+			"key_id": key.ID,
+		})
+		return fmt.Errorf("failed to create signing key: %w", err)
+	}
+
+	return nil
+}
+
+// List returns signing keys ordered from oldest to newest
+func (r *signingKeyRepository) List(ctx context.Context, activeOnly bool) ([]*model.SigningKey, error) {
+	query := `
+        SELECT id, secret, created_at, retired_at
+        FROM signing_keys
+        WHERE NOT $1 OR retired_at IS NULL
+        ORDER BY created_at ASC
+    `
+
+	rows, err := r.db.Query(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*model.SigningKey
+	for rows.Next() {
+		var key model.SigningKey
+		if err := rows.Scan(&key.ID, &key.Secret, &key.CreatedAt, &key.RetiredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan signing key: %w", err)
+		}
+		keys = append(keys, &key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *signingKeyRepository) Retire(ctx context.Context, id string) error {
+	query := `
+        UPDATE signing_keys
+        SET retired_at = COALESCE(retired_at, NOW())
+        WHERE id = $1
+    `
+
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to retire signing key: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrSigningKeyNotFound
+	}
+
+	return nil
+}
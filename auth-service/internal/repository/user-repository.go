@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/leandrowiemesfilho/auth-service/internal/model"
@@ -17,10 +18,25 @@ var (
 	ErrDuplicateEmail    = errors.New("email already registered")
 )
 
+// uniqueViolation is the Postgres SQLSTATE for unique_violation
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *model.User) error
-	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUserByEmail(ctx context.Context, normalizedEmail string) (*model.User, error)
 	GetUserByID(ctx context.Context, id string) (*model.User, error)
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
+	UpdateEmail(ctx context.Context, id string, email string, normalizedEmail string) error
+	SetDisabled(ctx context.Context, id string, disabled bool) error
+	// ListEmails returns the ID, email and normalized email of every user that is not erased
+	ListEmails(ctx context.Context) ([]*model.User, error)
+	// SetNormalizedEmail replaces the normalized email of a user if it is still current
+	SetNormalizedEmail(ctx context.Context, id string, current string, normalizedEmail string) error
 }
 
 type userRepository struct {
@@ -33,8 +49,8 @@ func NewUserRepository(db *pgxpool.Pool) UserRepository {
 
 func (r *userRepository) CreateUser(ctx context.Context, user *model.User) error {
 	query := `
-        INSERT INTO users (id, email, password_hash, name, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO users (id, email, email_normalized, password_hash, name, role, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     `
 
 	_, err := r.db.Exec(
@@ -42,8 +58,10 @@ func (r *userRepository) CreateUser(ctx context.Context, user *model.User) error
 		query,
 		user.ID,
 		user.Email,
+		user.EmailNormalized,
 		user.PasswordHash,
 		user.Name,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -54,8 +72,7 @@ func (r *userRepository) CreateUser(ctx context.Context, user *model.User) error
 			"email": user.Email,
 		})
 
-		// Check for duplicate email error
-		if err.Error() == "pq: duplicate key value violates unique constraint \"users_email_key\"" {
+		if isUniqueViolation(err) {
 			return ErrDuplicateEmail
 		}
 
@@ -69,19 +86,23 @@ func (r *userRepository) CreateUser(ctx context.Context, user *model.User) error
 	return nil
 }
 
-func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+func (r *userRepository) GetUserByEmail(ctx context.Context, normalizedEmail string) (*model.User, error) {
 	query := `
-        SELECT id, email, password_hash, name, created_at, updated_at
+        SELECT id, email, email_normalized, password_hash, name, role, disabled_at, erased_at, created_at, updated_at
         FROM users 
-        WHERE email = $1
+        WHERE email_normalized = $1
     `
 
 	var user model.User
-	err := r.db.QueryRow(ctx, query, email).Scan(
+	err := r.db.QueryRow(ctx, query, normalizedEmail).Scan(
 		&user.ID,
 		&user.Email,
+		&user.EmailNormalized,
 		&user.PasswordHash,
 		&user.Name,
+		&user.Role,
+		&user.DisabledAt,
+		&user.ErasedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -92,7 +113,7 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 		}
 		util.Error("Failed to get user by email", map[string]interface{}{
 			"error": err,
-			"email": email,
+			"email": normalizedEmail,
 		})
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -102,7 +123,7 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 
 func (r *userRepository) GetUserByID(ctx context.Context, id string) (*model.User, error) {
 	query := `
-        SELECT id, email, password_hash, name, created_at, updated_at
+        SELECT id, email, email_normalized, password_hash, name, role, disabled_at, erased_at, created_at, updated_at
         FROM users 
         WHERE id = $1
     `
@@ -111,8 +132,12 @@ func (r *userRepository) GetUserByID(ctx context.Context, id string) (*model.Use
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
+		&user.EmailNormalized,
 		&user.PasswordHash,
 		&user.Name,
+		&user.Role,
+		&user.DisabledAt,
+		&user.ErasedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -131,3 +156,140 @@ func (r *userRepository) GetUserByID(ctx context.Context, id string) (*model.Use
 
 	return &user, nil
 }
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	query := `
+        UPDATE users
+        SET password_hash = $1, updated_at = NOW()
+        WHERE id = $2
+    `
+
+	tag, err := r.db.Exec(ctx, query, passwordHash, id)
+	if err != nil {
+		util.Error("Failed to update password", map[string]interface{}{
+			"error":   err,
+			"user_id": id,
+		})
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) UpdateEmail(ctx context.Context, id string, email string, normalizedEmail string) error {
+	query := `
+        UPDATE users
+        SET email = $1, email_normalized = $2, updated_at = NOW()
+        WHERE id = $3
+    `
+
+	tag, err := r.db.Exec(ctx, query, email, normalizedEmail, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateEmail
+		}
+		util.Error("Failed to update email", map[string]interface{}{
+			"error":   err,
+			"user_id": id,
+		})
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetDisabled disables or enables a user. Disabling revokes the sessions of the
+// user and the impersonation sessions they started, so issued tokens stop working.
+func (r *userRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	query := `
+        WITH revoked AS (
+            DELETE FROM sessions WHERE $1 AND (user_id = $2 OR actor_id = $2)
+        )
+        UPDATE users
+        SET disabled_at = CASE WHEN $1 THEN COALESCE(disabled_at, NOW()) END,
+            updated_at = NOW()
+        WHERE id = $2
+    `
+
+	tag, err := r.db.Exec(ctx, query, disabled, id)
+	if err != nil {
+		util.Error("Failed to update disabled state", map[string]interface{}{
+			"error":   err,
+			"user_id": id,
+		})
+		return fmt.Errorf("failed to update disabled state: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) ListEmails(ctx context.Context) ([]*model.User, error) {
+	query := `
+        SELECT id, email, email_normalized
+        FROM users
+        WHERE erased_at IS NULL
+        ORDER BY created_at
+    `
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		util.Error("Failed to list user emails", map[string]interface{}{
+			"error": err,
+		})
+		return nil, fmt.Errorf("failed to list user emails: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.EmailNormalized); err != nil {
+			return nil, fmt.Errorf("failed to scan user email: %w", err)
+		}
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list user emails: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *userRepository) SetNormalizedEmail(ctx context.Context, id string, current string, normalizedEmail string) error {
+	query := `
+        UPDATE users
+        SET email_normalized = $1, updated_at = NOW()
+        WHERE id = $2 AND email_normalized = $3
+    `
+
+	tag, err := r.db.Exec(ctx, query, normalizedEmail, id, current)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateEmail
+		}
+		util.Error("Failed to update normalized email", map[string]interface{}{
+			"error":   err,
+			"user_id": id,
+		})
+		return fmt.Errorf("failed to update normalized email: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unique violation", &pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email_normalized"}, true},
+		{"wrapped unique violation", fmt.Errorf("failed to create user: %w", &pgconn.PgError{Code: "23505"}), true},
+		{"foreign key violation", &pgconn.PgError{Code: "23503"}, false},
+		{"not null violation", &pgconn.PgError{Code: "23502"}, false},
+		{"other error", errors.New("duplicate key value violates unique constraint"), false},
+		{"no error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUniqueViolation(tt.err); got != tt.want {
+				t.Errorf("isUniqueViolation(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,42 +13,117 @@ import (
 	"github.com/leandrowiemesfilho/auth-service/internal/util"
 )
 
+var (
+	ErrInvalidCredentials      = errors.New("invalid credentials")
+	ErrImpersonationNotAllowed = errors.New("impersonation not allowed")
+	ErrNotImpersonating        = errors.New("token is not an impersonation token")
+	ErrAccountDisabled         = errors.New("account disabled")
+)
+
 type AuthService interface {
-	Register(ctx context.Context, req *model.RegisterRequest) (*model.AuthResponse, error)
-	Login(ctx context.Context, req *model.LoginRequest) (*model.AuthResponse, error)
+	Register(ctx context.Context, req *model.RegisterRequest, meta model.RequestMeta) (*model.AuthResponse, error)
+	Login(ctx context.Context, req *model.LoginRequest, meta model.RequestMeta) (*model.AuthResponse, error)
 	ValidateToken(ctx context.Context, token string) (*model.User, error)
+	GetProfile(ctx context.Context, userID string) (*model.User, error)
+	ChangePassword(ctx context.Context, userID string, req *model.ChangePasswordRequest) error
+	ChangeEmail(ctx context.Context, userID string, req *model.ChangeEmailRequest) (*model.User, error)
+	Impersonate(ctx context.Context, actorID, targetID string, req *model.ImpersonateRequest, meta model.RequestMeta) (*model.ImpersonationResponse, error)
+	StopImpersonation(ctx context.Context, claims *util.Claims, meta model.RequestMeta) error
 }
 
 type authService struct {
 	userRepo     repository.UserRepository
+	auditRepo    repository.AuditRepository
+	sessionRepo  repository.SessionRepository
 	jwtUtil      util.JWTUtil
 	passwordUtil util.PasswordUtil
 	config       *JWTConfig
+	emailConfig  *EmailConfig
 }
 
 type JWTConfig struct {
-	Secret          string
-	ExpirationHours int
-	Issuer          string
+	Secret           string
+	ExpirationHours  int
+	Issuer           string
+	ImpersonationTTL time.Duration
+}
+
+type EmailConfig struct {
+	// NormalizeUnicode enables NFC normalization and IDN (punycode) domains
+	NormalizeUnicode bool
 }
 
 func NewAuthService(
 	userRepo repository.UserRepository,
+	auditRepo repository.AuditRepository,
+	sessionRepo repository.SessionRepository,
 	jwtUtil util.JWTUtil,
 	passwordUtil util.PasswordUtil,
 	config *JWTConfig,
+	emailConfig *EmailConfig,
 ) AuthService {
 	return &authService{
 		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		sessionRepo:  sessionRepo,
 		jwtUtil:      jwtUtil,
 		passwordUtil: passwordUtil,
 		config:       config,
+		emailConfig:  emailConfig,
+	}
+}
+
+func (s *authService) normalizeEmail(email string) (string, error) {
+	return util.NormalizeEmail(email, s.emailConfig.NormalizeUnicode)
+}
+
+// issueToken records a session for user and returns a token bound to it
+func (s *authService) issueToken(ctx context.Context, user *model.User, actorID *uuid.UUID, ttl time.Duration, meta model.RequestMeta) (string, time.Time, error) {
+	now := time.Now()
+	session := &model.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		ActorID:   actorID,
+		IPAddress: meta.IPAddress,
+		UserAgent: meta.UserAgent,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return "", time.Time{}, err
+	}
+
+	subject := util.TokenSubject{
+		UserID:    user.ID.String(),
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: session.ID.String(),
 	}
+	if actorID != nil {
+		subject.ActorID = actorID.String()
+	}
+
+	token, err := s.jwtUtil.GenerateToken(subject, session.ExpiresAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return token, session.ExpiresAt, nil
+}
+
+func (s *authService) tokenTTL() time.Duration {
+	return time.Duration(s.config.ExpirationHours) * time.Hour
 }
 
-func (s *authService) Register(ctx context.Context, req *model.RegisterRequest) (*model.AuthResponse, error) {
+func (s *authService) Register(ctx context.Context, req *model.RegisterRequest, meta model.RequestMeta) (*model.AuthResponse, error) {
+	normalizedEmail, err := s.normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if user already exists
-	existingUser, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	existingUser, err := s.userRepo.GetUserByEmail(ctx, normalizedEmail)
 	if existingUser != nil {
 		return nil, fmt.Errorf("email already registered: %w", repository.ErrDuplicateEmail)
 	}
@@ -63,12 +139,14 @@ func (s *authService) Register(ctx context.Context, req *model.RegisterRequest)
 
 	// Create user
 	user := &model.User{
-		ID:           uuid.New(),
-		Email:        req.Email,
-		PasswordHash: hashedPassword,
-		Name:         req.Name,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:              uuid.New(),
+		Email:           strings.TrimSpace(req.Email),
+		EmailNormalized: normalizedEmail,
+		PasswordHash:    hashedPassword,
+		Name:            req.Name,
+		Role:            model.RoleUser,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
@@ -76,9 +154,9 @@ func (s *authService) Register(ctx context.Context, req *model.RegisterRequest)
 	}
 
 	// Generate JWT token
-	token, err := s.jwtUtil.GenerateToken(user.ID.String(), user.Email, s.config.ExpirationHours)
+	token, _, err := s.issueToken(ctx, user, nil, s.tokenTTL(), meta)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
 	// Clear password hash for response
@@ -90,25 +168,34 @@ func (s *authService) Register(ctx context.Context, req *model.RegisterRequest)
 	}, nil
 }
 
-func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (*model.AuthResponse, error) {
+func (s *authService) Login(ctx context.Context, req *model.LoginRequest, meta model.RequestMeta) (*model.AuthResponse, error) {
+	normalizedEmail, err := s.normalizeEmail(req.Email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
 	// Get user by email
-	user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	user, err := s.userRepo.GetUserByEmail(ctx, normalizedEmail)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
-			return nil, fmt.Errorf("invalid credentials")
+			return nil, ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Verify password
 	if !s.passwordUtil.VerifyPassword(req.Password, user.PasswordHash) {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
 	}
 
 	// Generate JWT token
-	token, err := s.jwtUtil.GenerateToken(user.ID.String(), user.Email, s.config.ExpirationHours)
+	token, _, err := s.issueToken(ctx, user, nil, s.tokenTTL(), meta)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
 	// Clear password hash for response
@@ -133,3 +220,162 @@ func (s *authService) ValidateToken(ctx context.Context, token string) (*model.U
 
 	return user, nil
 }
+
+func (s *authService) GetProfile(ctx context.Context, userID string) (*model.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.PasswordHash = ""
+	return user, nil
+}
+
+func (s *authService) ChangePassword(ctx context.Context, userID string, req *model.ChangePasswordRequest) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !s.passwordUtil.VerifyPassword(req.CurrentPassword, user.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+
+	hashedPassword, err := s.passwordUtil.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return s.userRepo.UpdatePassword(ctx, userID, hashedPassword)
+}
+
+func (s *authService) ChangeEmail(ctx context.Context, userID string, req *model.ChangeEmailRequest) (*model.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.passwordUtil.VerifyPassword(req.Password, user.PasswordHash) {
+		return nil, ErrInvalidCredentials
+	}
+
+	normalizedEmail, err := s.normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	existingUser, err := s.userRepo.GetUserByEmail(ctx, normalizedEmail)
+	if existingUser != nil && existingUser.ID != user.ID {
+		return nil, repository.ErrDuplicateEmail
+	}
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to check existing user: %w", err)
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if err := s.userRepo.UpdateEmail(ctx, userID, email, normalizedEmail); err != nil {
+		return nil, err
+	}
+
+	user.Email = email
+	user.EmailNormalized = normalizedEmail
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// Impersonate issues a short-lived token for the target user on behalf of an admin.
+// Admin accounts can not be impersonated so the token never grants elevated access.
+func (s *authService) Impersonate(ctx context.Context, actorID, targetID string, req *model.ImpersonateRequest, meta model.RequestMeta) (*model.ImpersonationResponse, error) {
+	if actorID == targetID {
+		return nil, fmt.Errorf("%w: can not impersonate yourself", ErrImpersonationNotAllowed)
+	}
+
+	actor, err := s.userRepo.GetUserByID(ctx, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get actor: %w", err)
+	}
+
+	target, err := s.userRepo.GetUserByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Role == model.RoleAdmin {
+		return nil, fmt.Errorf("%w: can not impersonate an admin", ErrImpersonationNotAllowed)
+	}
+
+	token, expiresAt, err := s.issueToken(ctx, target, &actor.ID, s.config.ImpersonationTTL, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	event := newAuditEvent(model.AuditImpersonationStart, &actor.ID, &target.ID, meta)
+	event.Metadata = map[string]interface{}{
+		"reason":     req.Reason,
+		"expires_at": expiresAt.UTC(),
+	}
+	if err := s.auditRepo.Record(ctx, event); err != nil {
+		// Never hand out an impersonation token that is missing from the audit trail
+		return nil, err
+	}
+
+	util.Info("Impersonation started", map[string]interface{}{
+		"actor_id": actor.ID,
+		"user_id":  target.ID,
+	})
+
+	target.PasswordHash = ""
+
+	return &model.ImpersonationResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      target,
+		ActorID:   actor.ID.String(),
+	}, nil
+}
+
+// StopImpersonation revokes the session of the impersonation token and records
+// the end of the impersonation
+func (s *authService) StopImpersonation(ctx context.Context, claims *util.Claims, meta model.RequestMeta) error {
+	if !claims.IsImpersonated() {
+		return ErrNotImpersonating
+	}
+
+	actorID, err := uuid.Parse(claims.Act.Subject)
+	if err != nil {
+		return fmt.Errorf("invalid actor claim: %w", err)
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid subject claim: %w", err)
+	}
+
+	if err := s.sessionRepo.Delete(ctx, claims.Id); err != nil {
+		return err
+	}
+
+	event := newAuditEvent(model.AuditImpersonationStop, &actorID, &userID, meta)
+	if err := s.auditRepo.Record(ctx, event); err != nil {
+		return err
+	}
+
+	util.Info("Impersonation stopped", map[string]interface{}{
+		"actor_id":   actorID,
+		"user_id":    userID,
+		"session_id": claims.Id,
+	})
+
+	return nil
+}
+
+func newAuditEvent(action string, actorID, userID *uuid.UUID, meta model.RequestMeta) *model.AuditEvent {
+	return &model.AuditEvent{
+		ID:        uuid.New(),
+		Action:    action,
+		ActorID:   actorID,
+		UserID:    userID,
+		IPAddress: meta.IPAddress,
+		UserAgent: meta.UserAgent,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+// Outcomes of normalizing a stored email
+const (
+	EmailNormalizationUpdated   = "updated"
+	EmailNormalizationPending   = "pending"
+	EmailNormalizationCollision = "collision"
+	EmailNormalizationInvalid   = "invalid"
+)
+
+// EmailNormalization is a user whose stored normalized email differs from the
+// one NormalizeEmail returns for their address
+type EmailNormalization struct {
+	UserID     string `json:"user_id"`
+	Email      string `json:"email"`
+	Stored     string `json:"stored"`
+	Normalized string `json:"normalized,omitempty"`
+	Status     string `json:"status"`
+}
+
+// NormalizeStoredEmails recomputes the normalized email of every user. The SQL
+// migration that introduced normalized emails could only trim and lowercase, so
+// non-NFC addresses and internationalized domains were stored in a form login
+// never looks up. Mismatches are updated unless dryRun is set, in which case they
+// are reported as pending. Addresses that are invalid or collide with another
+// account are only reported; those accounts have to be fixed by hand. It is run
+// once after that migration, with authctl user normalize-emails.
+func NormalizeStoredEmails(ctx context.Context, userRepo repository.UserRepository, unicode bool, dryRun bool) ([]*EmailNormalization, error) {
+	users, err := userRepo.ListEmails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*EmailNormalization
+	for _, user := range users {
+		result := &EmailNormalization{
+			UserID: user.ID.String(),
+			Email:  user.Email,
+			Stored: user.EmailNormalized,
+		}
+
+		normalized, err := util.NormalizeEmail(user.Email, unicode)
+		if err != nil {
+			result.Status = EmailNormalizationInvalid
+			results = append(results, result)
+			continue
+		}
+		if normalized == user.EmailNormalized {
+			continue
+		}
+		result.Normalized = normalized
+
+		owner, err := userRepo.GetUserByEmail(ctx, normalized)
+		if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+			return results, err
+		}
+
+		switch {
+		case owner != nil && owner.ID != user.ID:
+			result.Status = EmailNormalizationCollision
+		case dryRun:
+			result.Status = EmailNormalizationPending
+		default:
+			result.Status = EmailNormalizationUpdated
+			err := userRepo.SetNormalizedEmail(ctx, user.ID.String(), user.EmailNormalized, normalized)
+			switch {
+			case errors.Is(err, repository.ErrDuplicateEmail):
+				result.Status = EmailNormalizationCollision
+			case errors.Is(err, repository.ErrUserNotFound):
+				// Changed or erased since it was listed
+				continue
+			case err != nil:
+				return results, err
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
+)
+
+// stubUserRepository keeps users in memory for the calls the backfill makes;
+// any other call panics
+type stubUserRepository struct {
+	repository.UserRepository
+	users []*model.User
+	// setErrors fails SetNormalizedEmail for a user ID
+	setErrors map[string]error
+}
+
+func (r *stubUserRepository) ListEmails(ctx context.Context) ([]*model.User, error) {
+	return r.users, nil
+}
+
+func (r *stubUserRepository) GetUserByEmail(ctx context.Context, normalizedEmail string) (*model.User, error) {
+	for _, user := range r.users {
+		if user.EmailNormalized == normalizedEmail {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *stubUserRepository) SetNormalizedEmail(ctx context.Context, id string, current string, normalizedEmail string) error {
+	if err := r.setErrors[id]; err != nil {
+		return err
+	}
+	for _, user := range r.users {
+		if user.ID.String() == id && user.EmailNormalized == current {
+			user.EmailNormalized = normalizedEmail
+			return nil
+		}
+	}
+	return repository.ErrUserNotFound
+}
+
+func TestNormalizeStoredEmails(t *testing.T) {
+	var (
+		normalized = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+		unicode    = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+		taken      = uuid.MustParse("00000000-0000-0000-0000-000000000003")
+		owner      = uuid.MustParse("00000000-0000-0000-0000-000000000004")
+		invalid    = uuid.MustParse("00000000-0000-0000-0000-000000000005")
+		raced      = uuid.MustParse("00000000-0000-0000-0000-000000000006")
+		vanished   = uuid.MustParse("00000000-0000-0000-0000-000000000007")
+	)
+	users := func() []*model.User {
+		return []*model.User{
+			{ID: normalized, Email: "Same@Example.com", EmailNormalized: "same@example.com"},
+			{ID: unicode, Email: "Foo@Bücher.example", EmailNormalized: "foo@bücher.example"},
+			{ID: taken, Email: "Bar@Bücher.example", EmailNormalized: "bar@bücher.example"},
+			{ID: owner, Email: "bar@xn--bcher-kva.example", EmailNormalized: "bar@xn--bcher-kva.example"},
+			{ID: invalid, Email: "not an email", EmailNormalized: "not an email"},
+			{ID: raced, Email: "Baz@Bücher.example", EmailNormalized: "baz@bücher.example"},
+			{ID: vanished, Email: "Qux@Bücher.example", EmailNormalized: "qux@bücher.example"},
+		}
+	}
+	setErrors := map[string]error{
+		raced.String():    repository.ErrDuplicateEmail,
+		vanished.String(): repository.ErrUserNotFound,
+	}
+
+	tests := []struct {
+		name              string
+		unicode           bool
+		dryRun            bool
+		want              map[string]string
+		wantUnicodeStored string
+	}{
+		{
+			name:    "without unicode only invalid addresses are reported",
+			unicode: false,
+			want:    map[string]string{invalid.String(): EmailNormalizationInvalid},
+		},
+		{
+			name:    "dry run reports mismatches as pending",
+			unicode: true,
+			dryRun:  true,
+			want: map[string]string{
+				unicode.String():  EmailNormalizationPending,
+				taken.String():    EmailNormalizationCollision,
+				invalid.String():  EmailNormalizationInvalid,
+				raced.String():    EmailNormalizationPending,
+				vanished.String(): EmailNormalizationPending,
+			},
+			wantUnicodeStored: "foo@bücher.example",
+		},
+		{
+			name:    "updates mismatches",
+			unicode: true,
+			want: map[string]string{
+				unicode.String(): EmailNormalizationUpdated,
+				taken.String():   EmailNormalizationCollision,
+				invalid.String(): EmailNormalizationInvalid,
+				raced.String():   EmailNormalizationCollision,
+			},
+			wantUnicodeStored: "foo@xn--bcher-kva.example",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubUserRepository{users: users(), setErrors: setErrors}
+
+			results, err := NormalizeStoredEmails(context.Background(), repo, tt.unicode, tt.dryRun)
+			if err != nil {
+				t.Fatalf("NormalizeStoredEmails() error = %v", err)
+			}
+
+			got := make(map[string]string)
+			for _, result := range results {
+				got[result.UserID] = result.Status
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeStoredEmails() statuses = %v, want %v", got, tt.want)
+			}
+			if tt.wantUnicodeStored != "" && repo.users[1].EmailNormalized != tt.wantUnicodeStored {
+				t.Errorf("stored normalized email = %q, want %q", repo.users[1].EmailNormalized, tt.wantUnicodeStored)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+// EventSignatureHeader carries the hex HMAC-SHA256 of the request body, keyed with
+// the shared events secret, so subscribers can reject forged events
+const EventSignatureHeader = "X-Event-Signature"
+
+type EventsConfig struct {
+	Subscribers []string
+	Secret      string
+	Interval    time.Duration
+	BatchSize   int
+}
+
+// EventDispatcher delivers outbox events to every subscriber with at-least-once
+// semantics. An event stays pending until all subscribers accept it, so
+// subscribers must handle duplicates.
+type EventDispatcher struct {
+	outboxRepo repository.OutboxRepository
+	config     *EventsConfig
+	client     *http.Client
+}
+
+func NewEventDispatcher(outboxRepo repository.OutboxRepository, config *EventsConfig) *EventDispatcher {
+	return &EventDispatcher{
+		outboxRepo: outboxRepo,
+		config:     config,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Run dispatches pending events every interval until ctx is done
+func (d *EventDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := d.outboxRepo.ClaimPending(ctx, d.config.BatchSize, func(events []*model.OutboxEvent) map[string]error {
+				return d.deliverAll(ctx, events)
+			})
+			if err != nil {
+				util.Error("Failed to dispatch events", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
+
+func (d *EventDispatcher) deliverAll(ctx context.Context, events []*model.OutboxEvent) map[string]error {
+	failures := make(map[string]error)
+	for _, event := range events {
+		for _, subscriber := range d.config.Subscribers {
+			if err := d.deliver(ctx, subscriber, event); err != nil {
+				util.Warn("Event delivery failed", map[string]interface{}{
+					"error":      err.Error(),
+					"event_id":   event.ID,
+					"event_type": event.Type,
+					"subscriber": subscriber,
+					"attempt":    event.Attempts + 1,
+				})
+				failures[event.ID.String()] = err
+				break
+			}
+		}
+
+		if _, failed := failures[event.ID.String()]; !failed {
+			util.Info("Event delivered", map[string]interface{}{
+				"event_id":   event.ID,
+				"event_type": event.Type,
+			})
+		}
+	}
+	return failures
+}
+
+func (d *EventDispatcher) deliver(ctx context.Context, url string, event *model.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventSignatureHeader, SignEvent(d.config.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// SignEvent returns the signature subscribers expect in EventSignatureHeader
+func SignEvent(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
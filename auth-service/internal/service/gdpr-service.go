@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/auth-service/internal/model"
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+// GDPRService implements data subject access (export) and erasure requests
+type GDPRService interface {
+	Export(ctx context.Context, userID string, meta model.RequestMeta) (*model.UserExport, error)
+	// EraseAccount erases the caller's own account after confirming their password
+	EraseAccount(ctx context.Context, userID string, req *model.EraseAccountRequest, meta model.RequestMeta) (*model.ErasureResponse, error)
+	// EraseUser erases userID on behalf of an admin
+	EraseUser(ctx context.Context, actorID, userID string, meta model.RequestMeta) (*model.ErasureResponse, error)
+}
+
+type gdprService struct {
+	userRepo     repository.UserRepository
+	auditRepo    repository.AuditRepository
+	sessionRepo  repository.SessionRepository
+	erasureRepo  repository.ErasureRepository
+	passwordUtil util.PasswordUtil
+}
+
+func NewGDPRService(
+	userRepo repository.UserRepository,
+	auditRepo repository.AuditRepository,
+	sessionRepo repository.SessionRepository,
+	erasureRepo repository.ErasureRepository,
+	passwordUtil util.PasswordUtil,
+) GDPRService {
+	return &gdprService{
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		sessionRepo:  sessionRepo,
+		erasureRepo:  erasureRepo,
+		passwordUtil: passwordUtil,
+	}
+}
+
+func (s *gdprService) Export(ctx context.Context, userID string, meta model.RequestMeta) (*model.UserExport, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = ""
+
+	sessions, err := s.sessionRepo.ListByUser(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.auditRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditRepo.Record(ctx, newAuditEvent(model.AuditDataExported, &user.ID, &user.ID, meta)); err != nil {
+		return nil, err
+	}
+
+	if sessions == nil {
+		sessions = []*model.Session{}
+	}
+	if events == nil {
+		events = []*model.AuditEvent{}
+	}
+
+	return &model.UserExport{
+		ExportedAt:  time.Now().UTC(),
+		Profile:     user,
+		Sessions:    sessions,
+		AuditEvents: events,
+	}, nil
+}
+
+func (s *gdprService) EraseAccount(ctx context.Context, userID string, req *model.EraseAccountRequest, meta model.RequestMeta) (*model.ErasureResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Erased accounts have no password left, repeating the request just reports the erasure
+	if user.ErasedAt == nil && !s.passwordUtil.VerifyPassword(req.Password, user.PasswordHash) {
+		return nil, ErrInvalidCredentials
+	}
+
+	// The request itself would otherwise put the network details straight back
+	return s.erase(ctx, user.ID, user.ID, model.RequestMeta{})
+}
+
+func (s *gdprService) EraseUser(ctx context.Context, actorID, userID string, meta model.RequestMeta) (*model.ErasureResponse, error) {
+	actor, err := uuid.Parse(actorID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, repository.ErrUserNotFound
+	}
+
+	return s.erase(ctx, actor, target, meta)
+}
+
+func (s *gdprService) erase(ctx context.Context, actorID, userID uuid.UUID, meta model.RequestMeta) (*model.ErasureResponse, error) {
+	audit := newAuditEvent(model.AuditUserErased, &actorID, &userID, meta)
+	audit.Metadata = map[string]interface{}{}
+
+	event := &model.OutboxEvent{
+		ID:        uuid.New(),
+		Type:      model.EventUserErased,
+		Payload:   map[string]interface{}{"user_id": userID.String()},
+		CreatedAt: time.Now(),
+	}
+
+	erasedAt, alreadyErased, err := s.erasureRepo.EraseUser(ctx, userID.String(), audit, event)
+	if err != nil {
+		return nil, err
+	}
+
+	util.Info("User erased", map[string]interface{}{
+		"actor_id":       actorID,
+		"user_id":        userID,
+		"already_erased": alreadyErased,
+	})
+
+	return &model.ErasureResponse{
+		UserID:        userID.String(),
+		ErasedAt:      erasedAt,
+		AlreadyErased: alreadyErased,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/leandrowiemesfilho/auth-service/internal/repository"
+	"github.com/leandrowiemesfilho/auth-service/internal/util"
+)
+
+// LoadSigningKeys installs the active signing keys from the database into jwtUtil.
+// Without any stored keys jwtUtil keeps signing with the configured secret.
+func LoadSigningKeys(ctx context.Context, repo repository.SigningKeyRepository, jwtUtil util.JWTUtil) error {
+	keys, err := repo.List(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	signingKeys := make([]util.SigningKey, 0, len(keys))
+	for _, key := range keys {
+		signingKeys = append(signingKeys, util.SigningKey{ID: key.ID, Secret: key.Secret})
+	}
+
+	jwtUtil.SetSigningKeys(signingKeys)
+	return nil
+}
+
+// RefreshSigningKeys reloads the signing keys every interval until ctx is done,
+// so keys rotated with authctl are picked up without a restart.
+func RefreshSigningKeys(ctx context.Context, repo repository.SigningKeyRepository, jwtUtil util.JWTUtil, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := LoadSigningKeys(ctx, repo, jwtUtil); err != nil {
+				util.Error("Failed to refresh signing keys", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// NormalizeEmail returns the canonical form of an email address used for identity lookups.
+// The address is trimmed and lowercased. With unicode enabled the address is also NFC
+// normalized and an internationalized domain is converted to its ASCII (punycode) form,
+// so "Foo@Bücher.example" and "foo@xn--bcher-kva.example" resolve to the same account.
+func NormalizeEmail(email string, unicode bool) (string, error) {
+	email = strings.TrimSpace(email)
+	if unicode {
+		email = norm.NFC.String(email)
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return "", ErrInvalidEmail
+	}
+
+	local := strings.ToLower(email[:at])
+	domain := strings.ToLower(strings.TrimSuffix(email[at+1:], "."))
+
+	if unicode {
+		asciiDomain, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			return "", ErrInvalidEmail
+		}
+		domain = asciiDomain
+	}
+
+	if domain == "" {
+		return "", ErrInvalidEmail
+	}
+
+	return local + "@" + domain, nil
+}
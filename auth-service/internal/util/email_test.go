@@ -0,0 +1,51 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		unicode bool
+		want    string
+		wantErr bool
+	}{
+		{"lowercases", "Foo.Bar@Example.COM", false, "foo.bar@example.com", false},
+		{"trims", "  foo@example.com\t", false, "foo@example.com", false},
+		{"drops the root dot", "foo@example.com.", false, "foo@example.com", false},
+		{"folds unicode case", "ÉMILE@Example.com", false, "émile@example.com", false},
+		{"last at splits", "\"a@b\"@Example.com", false, "\"a@b\"@example.com", false},
+		{"keeps decomposed form without unicode", "e\u0301@example.com", false, "e\u0301@example.com", false},
+		{"composes with unicode", "E\u0301@example.com", true, "\u00e9@example.com", false},
+		{"keeps unicode domain without unicode", "Foo@Bücher.example", false, "foo@bücher.example", false},
+		{"converts unicode domain to punycode", "Foo@Bücher.example", true, "foo@xn--bcher-kva.example", false},
+		{"punycode domain is unchanged", "foo@XN--BCHER-KVA.example", true, "foo@xn--bcher-kva.example", false},
+		{"empty", "", false, "", true},
+		{"no at", "foo.example.com", false, "", true},
+		{"no local part", "@example.com", false, "", true},
+		{"no domain", "foo@", false, "", true},
+		{"only the root dot", "foo@.", false, "", true},
+		{"invalid domain with unicode", "foo@exa mple.com", true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeEmail(tt.email, tt.unicode)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEmail) {
+					t.Fatalf("NormalizeEmail(%q) = %q, %v, want ErrInvalidEmail", tt.email, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeEmail(%q) error = %v", tt.email, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+			}
+		})
+	}
+}
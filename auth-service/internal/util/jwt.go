@@ -1,28 +1,65 @@
 package util
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
 type JWTUtil interface {
-	GenerateToken(userID, email string, expirationHours int) (string, error)
+	GenerateToken(subject TokenSubject, expiresAt time.Time) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
+	SetSigningKeys(keys []SigningKey)
+}
+
+// TokenSubject describes who a token is issued for
+type TokenSubject struct {
+	UserID    string
+	Email     string
+	Role      string
+	SessionID string
+	// ActorID is set when an admin acts on behalf of UserID
+	ActorID string
+}
+
+// SigningKey is an HMAC secret identified by the "kid" token header
+type SigningKey struct {
+	ID     string
+	Secret string
 }
 
 type jwtUtil struct {
 	secret string
 	issuer string
+
+	mu   sync.RWMutex
+	keys []SigningKey
+}
+
+// ActorClaim identifies who is acting on behalf of the subject (RFC 8693 "act" claim)
+type ActorClaim struct {
+	Subject string `json:"sub"`
 }
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID string      `json:"user_id"`
+	Email  string      `json:"email"`
+	Role   string      `json:"role,omitempty"`
+	Act    *ActorClaim `json:"act,omitempty"`
 	jwt.StandardClaims
 }
 
+// IsImpersonated reports whether the token was issued to an actor impersonating the subject
+func (c *Claims) IsImpersonated() bool {
+	return c.Act != nil && c.Act.Subject != ""
+}
+
+// NewJWTUtil creates a JWTUtil signing with secret until signing keys are set
 func NewJWTUtil(secret, issuer string) JWTUtil {
 	return &jwtUtil{
 		secret: secret,
@@ -30,21 +67,61 @@ func NewJWTUtil(secret, issuer string) JWTUtil {
 	}
 }
 
-func (j *jwtUtil) GenerateToken(userID, email string, expirationHours int) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(expirationHours) * time.Hour)
+// GenerateSigningKey creates a new random signing key. The ID is lowercase so it
+// survives case-insensitive config loaders used by token verifiers.
+func GenerateSigningKey() (SigningKey, error) {
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return SigningKey{}, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return SigningKey{}, err
+	}
+
+	return SigningKey{
+		ID:     time.Now().UTC().Format("20060102") + "-" + hex.EncodeToString(id),
+		Secret: base64.RawURLEncoding.EncodeToString(secret),
+	}, nil
+}
+
+// SetSigningKeys replaces the key ring. The last key signs new tokens, every key
+// verifies. Tokens without a "kid" header keep being verified with the configured secret.
+func (j *jwtUtil) SetSigningKeys(keys []SigningKey) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.keys = append([]SigningKey(nil), keys...)
+}
 
+func (j *jwtUtil) GenerateToken(subject TokenSubject, expiresAt time.Time) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID: subject.UserID,
+		Email:  subject.Email,
+		Role:   subject.Role,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Id:        subject.SessionID,
+			Subject:   subject.UserID,
+			ExpiresAt: expiresAt.Unix(),
 			Issuer:    j.issuer,
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
+	if subject.ActorID != "" {
+		claims.Act = &ActorClaim{Subject: subject.ActorID}
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secret))
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if len(j.keys) == 0 {
+		return token.SignedString([]byte(j.secret))
+	}
+
+	key := j.keys[len(j.keys)-1]
+	token.Header["kid"] = key.ID
+	return token.SignedString([]byte(key.Secret))
 }
 
 func (j *jwtUtil) ValidateToken(tokenString string) (*Claims, error) {
@@ -52,7 +129,7 @@ func (j *jwtUtil) ValidateToken(tokenString string) (*Claims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(j.secret), nil
+		return j.verificationKey(token)
 	})
 
 	if err != nil {
@@ -65,3 +142,21 @@ func (j *jwtUtil) ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, fmt.Errorf("invalid token")
 }
+
+func (j *jwtUtil) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return []byte(j.secret), nil
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	for _, key := range j.keys {
+		if key.ID == kid {
+			return []byte(key.Secret), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key: %s", kid)
+}
@@ -0,0 +1,3 @@
+module github.com/leandrowiemesfilho/migrate
+
+go 1.25.1
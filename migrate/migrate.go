@@ -0,0 +1,380 @@
+// Package migrate applies versioned SQL migrations to PostgreSQL. It is shared by
+// the services, which embed their own migrations and run them through a Migrator.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	ErrNoMigration      = errors.New("no migration to roll back")
+)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Version          int64      `json:"version"`
+	Name             string     `json:"name"`
+	Applied          bool       `json:"applied"`
+	AppliedAt        *time.Time `json:"applied_at,omitempty"`
+	ChecksumMismatch bool       `json:"checksum_mismatch,omitempty"`
+	Missing          bool       `json:"missing,omitempty"`
+}
+
+type appliedMigration struct {
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Logger receives progress messages with alternating keys and values
+type Logger interface {
+	Infow(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// Migrator applies versioned SQL migrations
+type Migrator struct {
+	db         *sql.DB
+	logger     Logger
+	lockID     int64
+	migrations []Migration
+}
+
+// New creates a Migrator for migrations. lockID is the pg_advisory_lock key that
+// serializes migrations across replicas and must differ between services sharing
+// a database server.
+func New(db *sql.DB, migrations []Migration, lockID int64, logger Logger) *Migrator {
+	return &Migrator{db: db, logger: logger, lockID: lockID, migrations: migrations}
+}
+
+// Load reads the migrations in dir of files, named NNNNNN_name.up.sql and
+// NNNNNN_name.down.sql, in version order
+func Load(files fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		// 1_a.up.sql and 001_a.up.sql are the same version
+		file := fmt.Sprintf("%d.%s", version, match[3])
+		if seen[file] {
+			return nil, fmt.Errorf("migration version %d has more than one %s file", version, match[3])
+		}
+		seen[file] = true
+
+		if match[3] == "up" {
+			sum := sha256.Sum256(content)
+			migration.Up = string(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(unlockCtx, "SELECT pg_advisory_unlock($1)", m.lockID); err != nil {
+			m.logger.Errorw("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	query := `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version BIGINT PRIMARY KEY,
+        name VARCHAR(255) NOT NULL,
+        checksum VARCHAR(64) NOT NULL,
+        applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+    )
+    `
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var migration appliedMigration
+		if err := rows.Scan(&version, &migration.Name, &migration.Checksum, &migration.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = migration
+	}
+
+	return applied, rows.Err()
+}
+
+// verifyChecksums refuses to continue when an applied migration was edited afterwards
+func (m *Migrator) verifyChecksums(applied map[int64]appliedMigration) error {
+	for _, migration := range m.migrations {
+		record, ok := applied[migration.Version]
+		if ok && record.Checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s was modified after it was applied", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// Up applies every pending migration in version order
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verifyChecksums(applied); err != nil {
+			return err
+		}
+
+		count := 0
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+
+		m.logger.Infow("Database migrations completed successfully", "applied", count)
+		return nil
+	})
+}
+
+// Down rolls back the given number of most recently applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		return m.down(ctx, conn, steps)
+	})
+}
+
+// Redo rolls back the latest migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		latest, ok := m.latestApplied(applied)
+		if !ok {
+			return ErrNoMigration
+		}
+
+		if err := m.down(ctx, conn, 1); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, latest)
+	})
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		known := map[int64]bool{}
+		for _, migration := range m.migrations {
+			known[migration.Version] = true
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if record, ok := applied[migration.Version]; ok {
+				appliedAt := record.AppliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+				status.ChecksumMismatch = record.Checksum != migration.Checksum
+			}
+			statuses = append(statuses, status)
+		}
+
+		// Applied in the database but unknown to this binary, e.g. after a downgrade
+		for version, record := range applied {
+			if known[version] {
+				continue
+			}
+			appliedAt := record.AppliedAt
+			statuses = append(statuses, Status{
+				Version:   version,
+				Name:      record.Name,
+				Applied:   true,
+				AppliedAt: &appliedAt,
+				Missing:   true,
+			})
+		}
+
+		sort.Slice(statuses, func(i, j int) bool {
+			return statuses[i].Version < statuses[j].Version
+		})
+		return nil
+	})
+
+	return statuses, err
+}
+
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn, steps int) error {
+	for i := 0; i < steps; i++ {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		migration, ok := m.latestApplied(applied)
+		if !ok {
+			if i == 0 {
+				return ErrNoMigration
+			}
+			return nil
+		}
+		if err := m.revert(ctx, conn, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) latestApplied(applied map[int64]appliedMigration) (Migration, bool) {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.migrations[i], true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	err := m.inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+			migration.Version, migration.Name, migration.Checksum,
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	m.logger.Infow("Applied migration", "version", migration.Version, "name", migration.Name)
+	return nil
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+	}
+
+	err := m.inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	m.logger.Infow("Reverted migration", "version", migration.Version, "name", migration.Name)
+	return nil
+}
+
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
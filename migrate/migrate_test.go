@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoad(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/000010_add_index.up.sql":      file("CREATE INDEX i ON t(c);"),
+		"migrations/000002_add_column.up.sql":     file("ALTER TABLE t ADD COLUMN c TEXT;"),
+		"migrations/000002_add_column.down.sql":   file("ALTER TABLE t DROP COLUMN c;"),
+		"migrations/000001_create_table.down.sql": file("DROP TABLE t;"),
+		"migrations/000001_create_table.up.sql":   file("CREATE TABLE t (id INT);"),
+	}
+
+	migrations, err := Load(files, "migrations")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "create_table", Up: "CREATE TABLE t (id INT);", Down: "DROP TABLE t;", Checksum: checksum("CREATE TABLE t (id INT);")},
+		{Version: 2, Name: "add_column", Up: "ALTER TABLE t ADD COLUMN c TEXT;", Down: "ALTER TABLE t DROP COLUMN c;", Checksum: checksum("ALTER TABLE t ADD COLUMN c TEXT;")},
+		{Version: 10, Name: "add_index", Up: "CREATE INDEX i ON t(c);", Checksum: checksum("CREATE INDEX i ON t(c);")},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("Load() returned %d migrations, want %d", len(migrations), len(want))
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr string
+	}{
+		{
+			name:    "no directory",
+			files:   fstest.MapFS{},
+			wantErr: "failed to read migrations",
+		},
+		{
+			name:    "invalid file name",
+			files:   fstest.MapFS{"migrations/create_table.sql": file("")},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name: "conflicting names",
+			files: fstest.MapFS{
+				"migrations/000001_create_table.up.sql": file("CREATE TABLE t (id INT);"),
+				"migrations/000001_create_other.up.sql": file("CREATE TABLE o (id INT);"),
+			},
+			wantErr: "conflicting names",
+		},
+		{
+			name: "duplicate up",
+			files: fstest.MapFS{
+				"migrations/000001_create_table.up.sql": file("CREATE TABLE t (id INT);"),
+				"migrations/1_create_table.up.sql":      file("CREATE TABLE t (id BIGINT);"),
+			},
+			wantErr: "more than one up file",
+		},
+		{
+			name: "duplicate down",
+			files: fstest.MapFS{
+				"migrations/000001_create_table.up.sql":   file("CREATE TABLE t (id INT);"),
+				"migrations/000001_create_table.down.sql": file("DROP TABLE t;"),
+				"migrations/01_create_table.down.sql":     file("DROP TABLE IF EXISTS t;"),
+			},
+			wantErr: "more than one down file",
+		},
+		{
+			name:    "only a down file",
+			files:   fstest.MapFS{"migrations/000001_create_table.down.sql": file("DROP TABLE t;")},
+			wantErr: "has no up file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.files, "migrations")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	m := New(nil, []Migration{
+		{Version: 1, Name: "create_table", Checksum: checksum("one")},
+		{Version: 2, Name: "add_column", Checksum: checksum("two")},
+	}, 1, nil)
+
+	tests := []struct {
+		name    string
+		applied map[int64]appliedMigration
+		wantErr error
+	}{
+		{"nothing applied", map[int64]appliedMigration{}, nil},
+		{"unchanged", map[int64]appliedMigration{1: {Checksum: checksum("one")}, 2: {Checksum: checksum("two")}}, nil},
+		{"pending are not checked", map[int64]appliedMigration{1: {Checksum: checksum("one")}}, nil},
+		{"unknown versions are not checked", map[int64]appliedMigration{3: {Checksum: "anything"}}, nil},
+		{"edited after it was applied", map[int64]appliedMigration{1: {Checksum: checksum("one")}, 2: {Checksum: checksum("old two")}}, ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.verifyChecksums(tt.applied); !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifyChecksums() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLatestApplied(t *testing.T) {
+	m := New(nil, []Migration{{Version: 1}, {Version: 2}, {Version: 10}}, 1, nil)
+
+	tests := []struct {
+		name        string
+		applied     map[int64]appliedMigration
+		wantVersion int64
+		wantOK      bool
+	}{
+		{"nothing applied", map[int64]appliedMigration{}, 0, false},
+		{"highest applied", map[int64]appliedMigration{1: {}, 2: {}}, 2, true},
+		{"all applied", map[int64]appliedMigration{1: {}, 2: {}, 10: {}}, 10, true},
+		{"unknown versions are skipped", map[int64]appliedMigration{1: {}, 11: {}}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migration, ok := m.latestApplied(tt.applied)
+			if ok != tt.wantOK || migration.Version != tt.wantVersion {
+				t.Errorf("latestApplied() = %d, %v, want %d, %v", migration.Version, ok, tt.wantVersion, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/leandrowiemesfilho/product-service/internal/config"
 	"github.com/leandrowiemesfilho/product-service/internal/database"
 	"github.com/leandrowiemesfilho/product-service/internal/handler"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
 	"github.com/leandrowiemesfilho/product-service/internal/repository"
 	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"github.com/leandrowiemesfilho/product-service/internal/storage"
 	"github.com/leandrowiemesfilho/product-service/pkg/logger"
 )
 
@@ -26,6 +31,11 @@ func main() {
 	}
 	defer appLogger.Sync()
 
+	// Schema management subcommand
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, appLogger, os.Args[2:]))
+	}
+
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
 
@@ -37,19 +47,223 @@ func main() {
 	defer db.Close()
 
 	// Initialize schema
-	if err := db.InitSchema(appLogger.SugaredLogger); err != nil {
-		appLogger.Fatalw("Failed to initialize database schema", "error", err)
+	if cfg.Database.AutoMigrate {
+		if err := db.InitSchema(appLogger.SugaredLogger); err != nil {
+			appLogger.Fatalw("Failed to initialize database schema", "error", err)
+		}
 	}
 
 	// Initialize repository, service, and handlers
 	productRepo := repository.NewProductRepository(db.DB, appLogger.SugaredLogger)
-	productService := service.NewProductService(productRepo, appLogger.SugaredLogger)
+	attributeRepo := repository.NewAttributeRepository(db.DB, appLogger.SugaredLogger)
+	rates, err := service.NewExchangeRates(cfg.Catalog.Currency, cfg.Catalog.ExchangeRates)
+	if err != nil {
+		appLogger.Fatalw("Invalid exchange rates", "error", err)
+	}
+	currency := rates.Base()
+	priceRanges := make([]model.PriceRange, 0, len(cfg.Catalog.PriceRanges))
+	for _, priceRange := range cfg.Catalog.PriceRanges {
+		from, err := catalogPrice(priceRange.From, currency)
+		if err != nil {
+			appLogger.Fatalw("Invalid price range", "error", err)
+		}
+		to, err := catalogPrice(priceRange.To, currency)
+		if err != nil {
+			appLogger.Fatalw("Invalid price range", "error", err)
+		}
+		priceRanges = append(priceRanges, model.PriceRange{From: from, To: to})
+	}
+	catalogConfig := &service.CatalogConfig{
+		Currency:       currency,
+		PriceRanges:    priceRanges,
+		Rates:          rates,
+		RequireIfMatch: cfg.Catalog.RequireIfMatch,
+	}
+	productService := service.NewProductService(productRepo, attributeRepo, catalogConfig, appLogger.SugaredLogger)
 	productHandler := handler.NewProductHandler(productService, appLogger.SugaredLogger)
+	exportHandler := handler.NewExportHandler(productService, &handler.FeedConfig{
+		Title:       cfg.Feed.Title,
+		Link:        cfg.Feed.Link,
+		Description: cfg.Feed.Description,
+		ProductURL:  cfg.Feed.ProductURL,
+	}, appLogger.SugaredLogger)
+
+	// Purge products kept in the trash past the retention period
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if cfg.Trash.Retention > 0 && cfg.Trash.PurgeInterval > 0 && cfg.Trash.PurgeBatchSize > 0 {
+		purger := service.NewTrashPurger(productRepo, &service.PurgeConfig{
+			Retention: cfg.Trash.Retention,
+			Interval:  cfg.Trash.PurgeInterval,
+			BatchSize: cfg.Trash.PurgeBatchSize,
+		}, appLogger.SugaredLogger)
+		go purger.Run(backgroundCtx)
+	}
+
+	// Publish scheduled products and unpublish expired ones
+	if cfg.Publishing.ScheduleInterval <= 0 || cfg.Publishing.ScheduleBatchSize <= 0 {
+		appLogger.Fatalw("Invalid publishing configuration", "schedule_interval", cfg.Publishing.ScheduleInterval,
+			"schedule_batch_size", cfg.Publishing.ScheduleBatchSize)
+	}
+	publishScheduler := service.NewPublishScheduler(productRepo, &service.PublishSchedulerConfig{
+		Interval:  cfg.Publishing.ScheduleInterval,
+		BatchSize: cfg.Publishing.ScheduleBatchSize,
+	}, appLogger.SugaredLogger)
+	go publishScheduler.Run(backgroundCtx)
+
+	// Process queued product imports
+	importRepo := repository.NewImportRepository(db.DB, appLogger.SugaredLogger)
+	importConfig := &service.ImportConfig{
+		Currency:  currency,
+		BatchSize: cfg.Imports.BatchSize,
+		MaxErrors: cfg.Imports.MaxErrors,
+	}
+	if cfg.Imports.BatchSize <= 0 || cfg.Imports.PollInterval <= 0 {
+		appLogger.Fatalw("Invalid imports configuration", "batch_size", cfg.Imports.BatchSize, "poll_interval", cfg.Imports.PollInterval)
+	}
+	importWorker := service.NewImportWorker(importRepo, importConfig, &service.ImportWorkerConfig{
+		Interval:   cfg.Imports.PollInterval,
+		StaleAfter: cfg.Imports.StaleAfter,
+	}, appLogger.SugaredLogger)
+	go importWorker.Run(backgroundCtx)
+	importService := service.NewImportService(importRepo, importConfig, appLogger.SugaredLogger)
+	importHandler := handler.NewImportHandler(importService, cfg.Imports.MaxUploadSize, appLogger.SugaredLogger)
+
+	variantRepo := repository.NewVariantRepository(db.DB, appLogger.SugaredLogger)
+	variantService := service.NewVariantService(productRepo, variantRepo, appLogger.SugaredLogger)
+	variantHandler := handler.NewVariantHandler(variantService, appLogger.SugaredLogger)
+
+	// Store product images and remove the files of deleted ones
+	var blobStore storage.BlobStore
+	switch cfg.Images.Storage {
+	case "local":
+		blobStore, err = storage.NewLocalStore(cfg.Images.Local.Dir, cfg.Images.BaseURL)
+	case "s3":
+		blobStore, err = storage.NewS3Store(&storage.S3Config{
+			Endpoint:  cfg.Images.S3.Endpoint,
+			Region:    cfg.Images.S3.Region,
+			Bucket:    cfg.Images.S3.Bucket,
+			AccessKey: cfg.Images.S3.AccessKey,
+			SecretKey: cfg.Images.S3.SecretKey,
+			PathStyle: cfg.Images.S3.PathStyle,
+			Timeout:   cfg.Images.S3.Timeout,
+		}, cfg.Images.BaseURL)
+	default:
+		appLogger.Fatalw("Unknown image storage", "storage", cfg.Images.Storage)
+	}
+	if err != nil {
+		appLogger.Fatalw("Failed to initialize image storage", "error", err)
+	}
+	if cfg.Images.MaxSize <= 0 || cfg.Images.MaxPixels <= 0 || cfg.Images.MaxPerProduct <= 0 ||
+		cfg.Images.JPEGQuality < 1 || cfg.Images.JPEGQuality > 100 {
+		appLogger.Fatalw("Invalid images configuration", "max_size", cfg.Images.MaxSize, "max_pixels", cfg.Images.MaxPixels,
+			"max_per_product", cfg.Images.MaxPerProduct, "jpeg_quality", cfg.Images.JPEGQuality)
+	}
+	imageRepo := repository.NewImageRepository(db.DB, appLogger.SugaredLogger)
+	if cfg.Images.CleanInterval > 0 && cfg.Images.CleanBatchSize > 0 && cfg.Images.CleanMaxAttempts > 0 {
+		cleaner := service.NewBlobCleaner(imageRepo, blobStore, &service.BlobCleanerConfig{
+			Interval:    cfg.Images.CleanInterval,
+			BatchSize:   cfg.Images.CleanBatchSize,
+			MaxAttempts: cfg.Images.CleanMaxAttempts,
+		}, appLogger.SugaredLogger)
+		go cleaner.Run(backgroundCtx)
+	}
+	imageService := service.NewImageService(productRepo, imageRepo, blobStore, &service.ImageConfig{
+		MaxSize:        cfg.Images.MaxSize,
+		MaxPixels:      cfg.Images.MaxPixels,
+		MaxPerProduct:  cfg.Images.MaxPerProduct,
+		ThumbnailSizes: cfg.Images.ThumbnailSizes,
+		JPEGQuality:    cfg.Images.JPEGQuality,
+	}, appLogger.SugaredLogger)
+	imageHandler := handler.NewImageHandler(imageService, cfg.Images.MaxSize, appLogger.SugaredLogger)
+
+	stockRepo := repository.NewStockRepository(db.DB, appLogger.SugaredLogger)
+	stockService := service.NewStockService(stockRepo, appLogger.SugaredLogger)
+	stockHandler := handler.NewStockHandler(stockService, appLogger.SugaredLogger)
+
+	// Release the stock of reservations left to expire
+	reservationRepo := repository.NewReservationRepository(db.DB, appLogger.SugaredLogger)
+	if cfg.Reservations.DefaultTTL <= 0 || cfg.Reservations.MaxTTL < cfg.Reservations.DefaultTTL ||
+		cfg.Reservations.SweepInterval <= 0 || cfg.Reservations.SweepBatchSize <= 0 {
+		appLogger.Fatalw("Invalid reservations configuration", "default_ttl", cfg.Reservations.DefaultTTL,
+			"max_ttl", cfg.Reservations.MaxTTL, "sweep_interval", cfg.Reservations.SweepInterval,
+			"sweep_batch_size", cfg.Reservations.SweepBatchSize)
+	}
+	sweeper := service.NewReservationSweeper(reservationRepo, &service.SweepConfig{
+		Interval:  cfg.Reservations.SweepInterval,
+		BatchSize: cfg.Reservations.SweepBatchSize,
+	}, appLogger.SugaredLogger)
+	go sweeper.Run(backgroundCtx)
+	reservationService := service.NewReservationService(reservationRepo, &service.ReservationConfig{
+		DefaultTTL: cfg.Reservations.DefaultTTL,
+		MaxTTL:     cfg.Reservations.MaxTTL,
+	}, appLogger.SugaredLogger)
+	reservationHandler := handler.NewReservationHandler(reservationService, appLogger.SugaredLogger)
+
+	// Raise low-stock alerts and deliver them through the configured notifiers
+	alertRepo := repository.NewAlertRepository(db.DB, appLogger.SugaredLogger)
+	notifiers, err := service.NewNotifiers(&service.NotifierConfig{
+		Channels:       cfg.Alerts.Notifiers,
+		WebhookURL:     cfg.Alerts.Webhook.URL,
+		WebhookSecret:  cfg.Alerts.Webhook.Secret,
+		WebhookTimeout: cfg.Alerts.Webhook.Timeout,
+		EmailFrom:      cfg.Alerts.Email.From,
+		EmailTo:        cfg.Alerts.Email.To,
+	}, appLogger.SugaredLogger)
+	if err != nil {
+		appLogger.Fatalw("Invalid alert notifiers", "error", err)
+	}
+	if cfg.Alerts.EvaluateInterval > 0 && cfg.Alerts.BatchSize > 0 && cfg.Alerts.MaxAttempts > 0 {
+		evaluator := service.NewAlertEvaluator(alertRepo, notifiers, &service.AlertConfig{
+			Interval:    cfg.Alerts.EvaluateInterval,
+			Cooldown:    cfg.Alerts.Cooldown,
+			BatchSize:   cfg.Alerts.BatchSize,
+			MaxAttempts: cfg.Alerts.MaxAttempts,
+		}, appLogger.SugaredLogger)
+		go evaluator.Run(backgroundCtx)
+	}
+	alertService := service.NewAlertService(alertRepo, appLogger.SugaredLogger)
+	alertHandler := handler.NewAlertHandler(alertService, appLogger.SugaredLogger)
+
+	// Start and end scheduled prices
+	priceRepo := repository.NewPriceRepository(db.DB, appLogger.SugaredLogger)
+	if cfg.Pricing.ScheduleInterval <= 0 || cfg.Pricing.ScheduleBatchSize <= 0 {
+		appLogger.Fatalw("Invalid pricing configuration", "schedule_interval", cfg.Pricing.ScheduleInterval,
+			"schedule_batch_size", cfg.Pricing.ScheduleBatchSize)
+	}
+	priceScheduler := service.NewPriceScheduler(priceRepo, &service.PriceSchedulerConfig{
+		Interval:  cfg.Pricing.ScheduleInterval,
+		BatchSize: cfg.Pricing.ScheduleBatchSize,
+	}, appLogger.SugaredLogger)
+	go priceScheduler.Run(backgroundCtx)
+	priceService := service.NewPriceService(priceRepo, productRepo, appLogger.SugaredLogger)
+	priceHandler := handler.NewPriceHandler(priceService, appLogger.SugaredLogger)
+
+	promotionRepo := repository.NewPromotionRepository(db.DB, appLogger.SugaredLogger)
+	promotionService := service.NewPromotionService(promotionRepo, appLogger.SugaredLogger)
+	promotionHandler := handler.NewPromotionHandler(promotionService, appLogger.SugaredLogger)
+	pricingService := service.NewPricingService(promotionRepo, productRepo, variantRepo, catalogConfig, appLogger.SugaredLogger)
+	pricingHandler := handler.NewPricingHandler(pricingService, appLogger.SugaredLogger)
+
+	categoryRepo := repository.NewCategoryRepository(db.DB, appLogger.SugaredLogger)
+	categoryService := service.NewCategoryService(categoryRepo, appLogger.SugaredLogger)
+	categoryHandler := handler.NewCategoryHandler(categoryService, appLogger.SugaredLogger)
+	attributeService := service.NewAttributeService(attributeRepo, appLogger.SugaredLogger)
+	attributeHandler := handler.NewAttributeHandler(attributeService, appLogger.SugaredLogger)
+	eventHandler := handler.NewEventHandler(productService, cfg.Events.Secret, appLogger.SugaredLogger)
 
 	// Setup router
 	router := gin.New()
 	router.Use(gin.Recovery())
 
+	// Locally stored images, usually fetched through a CDN
+	if cfg.Images.Storage == "local" {
+		media := router.Group("/media", func(c *gin.Context) {
+			c.Header("Cache-Control", storage.ImmutableCacheControl)
+		})
+		media.Static("/", cfg.Images.Local.Dir)
+	}
+
 	// Routes
 	api := router.Group("/api/v1")
 	{
@@ -60,11 +274,91 @@ func main() {
 		products := api.Group("/products")
 		{
 			products.GET("", productHandler.GetAllProducts)
+			products.GET("/search", productHandler.SearchProducts)
+			products.GET("/export", exportHandler.ExportProducts)
+			products.GET("/trash", handler.RequireAdmin(), productHandler.GetTrash)
+			products.GET("/drafts", handler.RequireAdmin(), productHandler.GetDrafts)
+			products.GET("/scheduled", handler.RequireAdmin(), productHandler.GetScheduled)
+			products.POST("/imports", handler.RequireAdmin(), importHandler.CreateImport)
+			products.GET("/imports/:id", handler.RequireAdmin(), importHandler.GetImport)
+			products.GET("/imports/:id/errors", handler.RequireAdmin(), importHandler.GetErrorReport)
+			products.GET("/alerts", handler.RequireAdmin(), alertHandler.GetAlerts)
+			products.GET("/alerts/:id", handler.RequireAdmin(), alertHandler.GetAlert)
+			products.POST("/alerts/:id/acknowledge", handler.RequireAdmin(), alertHandler.AcknowledgeAlert)
+			products.GET("/by-slug/:slug", productHandler.GetProductBySlug)
 			products.GET("/:id", productHandler.GetProduct)
 			products.POST("", productHandler.CreateProduct)
 			products.PUT("/:id", productHandler.UpdateProduct)
+			products.PATCH("/:id", productHandler.PatchProduct)
+			products.POST("/:id/restore", handler.RequireAdmin(), productHandler.RestoreProduct)
+			products.PUT("/:id/status", handler.RequireAdmin(), productHandler.SetStatus)
 			products.DELETE("/:id", productHandler.DeleteProduct)
+			products.PUT("/:id/options", handler.RequireAdmin(), variantHandler.SetVariantOptions)
+			products.GET("/:id/variants", variantHandler.GetVariants)
+			products.GET("/:id/variants/:variantId", variantHandler.GetVariant)
+			products.POST("/:id/variants", handler.RequireAdmin(), variantHandler.CreateVariant)
+			products.PUT("/:id/variants/:variantId", handler.RequireAdmin(), variantHandler.UpdateVariant)
+			products.DELETE("/:id/variants/:variantId", handler.RequireAdmin(), variantHandler.DeleteVariant)
+			products.GET("/:id/images", imageHandler.GetImages)
+			products.GET("/:id/images/:imageId", imageHandler.GetImage)
+			products.POST("/:id/images", handler.RequireAdmin(), imageHandler.UploadImage)
+			products.PUT("/:id/images/order", handler.RequireAdmin(), imageHandler.ReorderImages)
+			products.PATCH("/:id/images/:imageId", handler.RequireAdmin(), imageHandler.UpdateImage)
+			products.DELETE("/:id/images/:imageId", handler.RequireAdmin(), imageHandler.DeleteImage)
+			products.POST("/:id/stock/adjust", handler.RequireAdmin(), stockHandler.AdjustStock)
+			products.GET("/:id/stock/movements", handler.RequireAdmin(), stockHandler.GetStockHistory)
+			products.GET("/:id/prices", priceHandler.GetPriceHistory)
+			products.GET("/:id/price-schedules", priceHandler.GetPriceSchedules)
+			products.POST("/:id/price-schedules", handler.RequireAdmin(), priceHandler.SchedulePrice)
+			products.DELETE("/:id/price-schedules/:scheduleId", handler.RequireAdmin(), priceHandler.CancelPriceSchedule)
 		}
+
+		reservations := api.Group("/reservations")
+		{
+			reservations.POST("", reservationHandler.CreateReservation)
+			reservations.GET("/:id", reservationHandler.GetReservation)
+			reservations.POST("/:id/commit", reservationHandler.CommitReservation)
+			reservations.POST("/:id/cancel", reservationHandler.CancelReservation)
+		}
+
+		pricing := api.Group("/pricing")
+		{
+			pricing.POST("/quote", pricingHandler.Quote)
+			pricing.POST("/coupons/redeem", promotionHandler.RedeemCoupon)
+		}
+
+		promotions := api.Group("/promotions", handler.RequireAdmin())
+		{
+			promotions.GET("", promotionHandler.GetPromotions)
+			promotions.GET("/:id", promotionHandler.GetPromotion)
+			promotions.POST("", promotionHandler.CreatePromotion)
+			promotions.PUT("/:id", promotionHandler.UpdatePromotion)
+			promotions.DELETE("/:id", promotionHandler.DeletePromotion)
+		}
+
+		categories := api.Group("/categories")
+		{
+			categories.GET("", categoryHandler.GetAllCategories)
+			categories.GET("/:id", categoryHandler.GetCategory)
+			categories.POST("", handler.RequireAdmin(), categoryHandler.CreateCategory)
+			categories.PUT("/:id", handler.RequireAdmin(), categoryHandler.UpdateCategory)
+			categories.POST("/:id/move", handler.RequireAdmin(), categoryHandler.MoveCategory)
+			categories.DELETE("/:id", handler.RequireAdmin(), categoryHandler.DeleteCategory)
+			categories.GET("/:id/attributes", attributeHandler.GetCategoryAttributes)
+			categories.PUT("/:id/attributes", handler.RequireAdmin(), attributeHandler.SetCategoryAttributes)
+		}
+
+		attributes := api.Group("/attributes")
+		{
+			attributes.GET("", attributeHandler.GetAllAttributes)
+			attributes.GET("/:id", attributeHandler.GetAttribute)
+			attributes.POST("", handler.RequireAdmin(), attributeHandler.CreateAttribute)
+			attributes.PUT("/:id", handler.RequireAdmin(), attributeHandler.UpdateAttribute)
+			attributes.DELETE("/:id", handler.RequireAdmin(), attributeHandler.DeleteAttribute)
+		}
+
+		// Service to service events, not exposed through the gateway
+		api.POST("/internal/events", eventHandler.HandleEvent)
 	}
 
 	appLogger.Infow("Starting server", "port", cfg.Server.Port)
@@ -72,3 +366,15 @@ func main() {
 		appLogger.Fatalw("Failed to start server", "error", err)
 	}
 }
+
+// catalogPrice converts a configured price bound to money in the catalog currency
+func catalogPrice(bound *float64, currency string) (*model.Money, error) {
+	if bound == nil {
+		return nil, nil
+	}
+	price, err := model.ParseMoney(strconv.FormatFloat(*bound, 'f', -1, 64), currency)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
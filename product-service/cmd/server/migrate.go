@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leandrowiemesfilho/migrate"
+	"github.com/leandrowiemesfilho/product-service/internal/config"
+	"github.com/leandrowiemesfilho/product-service/internal/database"
+	"github.com/leandrowiemesfilho/product-service/pkg/logger"
+)
+
+const migrateUsage = `Usage: server migrate <command> [flags]
+
+Commands:
+  status          Show applied and pending migrations
+  up              Apply all pending migrations
+  down [-steps N] Roll back the latest N migrations (default 1)
+  redo            Roll back and re-apply the latest migration
+`
+
+// runMigrate implements the "migrate" subcommand and returns the process exit code
+func runMigrate(cfg *config.Config, appLogger *logger.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "number of migrations to roll back")
+	asJSON := flags.Bool("json", false, "print status as JSON")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	db, err := database.NewDB(&cfg.Database, appLogger.SugaredLogger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "database connection failed: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db.DB, appLogger.SugaredLogger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load migrations: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch command {
+	case "status":
+		err = printMigrationStatus(ctx, migrator, *asJSON)
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		if *steps < 1 {
+			err = errors.New("steps must be at least 1")
+			break
+		}
+		err = migrator.Down(ctx, *steps)
+	case "redo":
+		err = migrator.Redo(ctx)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate %s failed: %v\n", command, err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator, asJSON bool) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state := "pending"
+		appliedAt := "-"
+		switch {
+		case status.Missing:
+			state = "applied (missing file)"
+		case status.ChecksumMismatch:
+			state = "applied (checksum mismatch)"
+		case status.Applied:
+			state = "applied"
+		}
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
@@ -8,9 +8,19 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	Logging      LoggingConfig      `mapstructure:"logging"`
+	Events       EventsConfig       `mapstructure:"events"`
+	Catalog      CatalogConfig      `mapstructure:"catalog"`
+	Trash        TrashConfig        `mapstructure:"trash"`
+	Imports      ImportsConfig      `mapstructure:"imports"`
+	Feed         FeedConfig         `mapstructure:"feed"`
+	Reservations ReservationsConfig `mapstructure:"reservations"`
+	Alerts       AlertsConfig       `mapstructure:"alerts"`
+	Pricing      PricingConfig      `mapstructure:"pricing"`
+	Images       ImagesConfig       `mapstructure:"images"`
+	Publishing   PublishingConfig   `mapstructure:"publishing"`
 }
 
 type ServerConfig struct {
@@ -28,6 +38,131 @@ type DatabaseConfig struct {
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	AutoMigrate     bool          `mapstructure:"auto_migrate"`
+}
+
+// EventsConfig holds the secret shared with services publishing events
+type EventsConfig struct {
+	Secret string `mapstructure:"secret"`
+}
+
+// CatalogConfig holds catalog wide settings. RequireIfMatch makes product writes
+// conditional on an If-Match header.
+type CatalogConfig struct {
+	Currency       string             `mapstructure:"currency"`
+	ExchangeRates  map[string]float64 `mapstructure:"exchange_rates"`
+	PriceRanges    []PriceRange       `mapstructure:"price_ranges"`
+	RequireIfMatch bool               `mapstructure:"require_if_match"`
+}
+
+// PriceRange is a price facet bucket in the catalog currency from From (inclusive)
+// to To (exclusive); an omitted bound is open
+type PriceRange struct {
+	From *float64 `mapstructure:"from"`
+	To   *float64 `mapstructure:"to"`
+}
+
+// TrashConfig controls how long deleted products are kept before being purged
+type TrashConfig struct {
+	Retention      time.Duration `mapstructure:"retention"`
+	PurgeInterval  time.Duration `mapstructure:"purge_interval"`
+	PurgeBatchSize int           `mapstructure:"purge_batch_size"`
+}
+
+// ImportsConfig controls bulk product imports. Workers poll for queued jobs every
+// PollInterval and take over running jobs without progress for StaleAfter.
+type ImportsConfig struct {
+	MaxUploadSize int64         `mapstructure:"max_upload_size"`
+	BatchSize     int           `mapstructure:"batch_size"`
+	MaxErrors     int           `mapstructure:"max_errors"`
+	PollInterval  time.Duration `mapstructure:"poll_interval"`
+	StaleAfter    time.Duration `mapstructure:"stale_after"`
+}
+
+// FeedConfig describes the store in product feeds. ProductURL is the storefront
+// address of a product with {id} and {slug} in place of the product ID and slug.
+type FeedConfig struct {
+	Title       string `mapstructure:"title"`
+	Link        string `mapstructure:"link"`
+	Description string `mapstructure:"description"`
+	ProductURL  string `mapstructure:"product_url"`
+}
+
+// ReservationsConfig controls how long checkout reservations hold stock and how
+// often expired ones are released
+type ReservationsConfig struct {
+	DefaultTTL     time.Duration `mapstructure:"default_ttl"`
+	MaxTTL         time.Duration `mapstructure:"max_ttl"`
+	SweepInterval  time.Duration `mapstructure:"sweep_interval"`
+	SweepBatchSize int           `mapstructure:"sweep_batch_size"`
+}
+
+// AlertsConfig controls low-stock alerts. Notifiers lists the channels alerts
+// are delivered through: log, webhook or email.
+type AlertsConfig struct {
+	EvaluateInterval time.Duration       `mapstructure:"evaluate_interval"`
+	Cooldown         time.Duration       `mapstructure:"cooldown"`
+	BatchSize        int                 `mapstructure:"batch_size"`
+	MaxAttempts      int                 `mapstructure:"max_attempts"`
+	Notifiers        []string            `mapstructure:"notifiers"`
+	Webhook          AlertsWebhookConfig `mapstructure:"webhook"`
+	Email            AlertsEmailConfig   `mapstructure:"email"`
+}
+
+type AlertsWebhookConfig struct {
+	URL     string        `mapstructure:"url"`
+	Secret  string        `mapstructure:"secret"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+type AlertsEmailConfig struct {
+	From string   `mapstructure:"from"`
+	To   []string `mapstructure:"to"`
+}
+
+// PricingConfig controls how often scheduled prices are started and ended
+type PricingConfig struct {
+	ScheduleInterval  time.Duration `mapstructure:"schedule_interval"`
+	ScheduleBatchSize int           `mapstructure:"schedule_batch_size"`
+}
+
+// PublishingConfig controls how often scheduled products are published and
+// active ones past their unpublish time archived
+type PublishingConfig struct {
+	ScheduleInterval  time.Duration `mapstructure:"schedule_interval"`
+	ScheduleBatchSize int           `mapstructure:"schedule_batch_size"`
+}
+
+// ImagesConfig controls product image uploads. Storage is local or s3 and files
+// are served from BaseURL, typically a CDN in front of the storage. Files of
+// deleted images are removed every CleanInterval.
+type ImagesConfig struct {
+	Storage          string            `mapstructure:"storage"`
+	BaseURL          string            `mapstructure:"base_url"`
+	MaxSize          int64             `mapstructure:"max_size"`
+	MaxPixels        int               `mapstructure:"max_pixels"`
+	MaxPerProduct    int               `mapstructure:"max_per_product"`
+	ThumbnailSizes   []int             `mapstructure:"thumbnail_sizes"`
+	JPEGQuality      int               `mapstructure:"jpeg_quality"`
+	CleanInterval    time.Duration     `mapstructure:"clean_interval"`
+	CleanBatchSize   int               `mapstructure:"clean_batch_size"`
+	CleanMaxAttempts int               `mapstructure:"clean_max_attempts"`
+	Local            ImagesLocalConfig `mapstructure:"local"`
+	S3               ImagesS3Config    `mapstructure:"s3"`
+}
+
+type ImagesLocalConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+type ImagesS3Config struct {
+	Endpoint  string        `mapstructure:"endpoint"`
+	Region    string        `mapstructure:"region"`
+	Bucket    string        `mapstructure:"bucket"`
+	AccessKey string        `mapstructure:"access_key"`
+	SecretKey string        `mapstructure:"secret_key"`
+	PathStyle bool          `mapstructure:"path_style"`
+	Timeout   time.Duration `mapstructure:"timeout"`
 }
 
 type LoggingConfig struct {
@@ -47,11 +182,60 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("database.max_open_conns", 25)
 	viper.SetDefault("database.max_idle_conns", 5)
 	viper.SetDefault("database.conn_max_lifetime", "5m")
+	viper.SetDefault("database.auto_migrate", true)
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "json")
+	viper.SetDefault("trash.retention", "720h")
+	viper.SetDefault("trash.purge_interval", "1h")
+	viper.SetDefault("trash.purge_batch_size", 500)
+	viper.SetDefault("imports.max_upload_size", 32<<20)
+	viper.SetDefault("imports.batch_size", 500)
+	viper.SetDefault("imports.max_errors", 1000)
+	viper.SetDefault("imports.poll_interval", "5s")
+	viper.SetDefault("imports.stale_after", "5m")
+	viper.SetDefault("reservations.default_ttl", "15m")
+	viper.SetDefault("reservations.max_ttl", "2h")
+	viper.SetDefault("reservations.sweep_interval", "30s")
+	viper.SetDefault("reservations.sweep_batch_size", 100)
+	viper.SetDefault("alerts.evaluate_interval", "1m")
+	viper.SetDefault("alerts.cooldown", "1h")
+	viper.SetDefault("alerts.batch_size", 100)
+	viper.SetDefault("alerts.max_attempts", 5)
+	viper.SetDefault("alerts.notifiers", []string{"log"})
+	viper.SetDefault("alerts.webhook.timeout", "10s")
+	viper.SetDefault("pricing.schedule_interval", "30s")
+	viper.SetDefault("pricing.schedule_batch_size", 100)
+	viper.SetDefault("publishing.schedule_interval", "30s")
+	viper.SetDefault("publishing.schedule_batch_size", 100)
+	viper.SetDefault("images.storage", "local")
+	viper.SetDefault("images.base_url", "http://localhost:8082/media")
+	viper.SetDefault("images.max_size", 10<<20)
+	viper.SetDefault("images.max_pixels", 40_000_000)
+	viper.SetDefault("images.max_per_product", 20)
+	viper.SetDefault("images.thumbnail_sizes", []int{160, 480, 1024})
+	viper.SetDefault("images.jpeg_quality", 85)
+	viper.SetDefault("images.clean_interval", "1m")
+	viper.SetDefault("images.clean_batch_size", 100)
+	viper.SetDefault("images.clean_max_attempts", 10)
+	viper.SetDefault("images.local.dir", "./data/media")
+	viper.SetDefault("images.s3.region", "us-east-1")
+	viper.SetDefault("images.s3.timeout", "30s")
+	viper.SetDefault("catalog.currency", "USD")
+	viper.SetDefault("catalog.require_if_match", true)
+	viper.SetDefault("catalog.price_ranges", []map[string]interface{}{
+		{"to": 25},
+		{"from": 25, "to": 50},
+		{"from": 50, "to": 100},
+		{"from": 100, "to": 250},
+		{"from": 250},
+	})
 
 	// Environment variables
 	viper.AutomaticEnv()
+	viper.BindEnv("events.secret", "EVENTS_SECRET")
+	viper.BindEnv("alerts.webhook.secret", "ALERTS_WEBHOOK_SECRET")
+	viper.BindEnv("images.s3.access_key", "IMAGES_S3_ACCESS_KEY")
+	viper.BindEnv("images.s3.secret_key", "IMAGES_S3_SECRET_KEY")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+
+	"github.com/leandrowiemesfilho/migrate"
+	"go.uber.org/zap"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the pg_advisory_lock key that serializes migrations across replicas
+const migrationLockID int64 = 7_342_002
+
+// NewMigrator returns a migrator for the embedded, versioned SQL migrations
+func NewMigrator(db *sql.DB, logger *zap.SugaredLogger) (*migrate.Migrator, error) {
+	migrations, err := migrate.Load(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.New(db, migrations, migrationLockID, logger), nil
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_products_created_by;
+ALTER TABLE products DROP COLUMN IF EXISTS created_by;
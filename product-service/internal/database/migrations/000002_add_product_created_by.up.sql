@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS created_by VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_products_created_by ON products(created_by);
@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS idx_products_created_at ON products(created_at);
+
+DROP INDEX IF EXISTS idx_products_stock_id;
+DROP INDEX IF EXISTS idx_products_name_id;
+DROP INDEX IF EXISTS idx_products_price_id;
+DROP INDEX IF EXISTS idx_products_updated_at_id;
+DROP INDEX IF EXISTS idx_products_created_at_id;
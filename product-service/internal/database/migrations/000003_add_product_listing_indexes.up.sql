@@ -0,0 +1,8 @@
+-- Keyset pagination orders by (sort column, id)
+CREATE INDEX IF NOT EXISTS idx_products_created_at_id ON products(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_products_updated_at_id ON products(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_products_price_id ON products(price, id);
+CREATE INDEX IF NOT EXISTS idx_products_name_id ON products(name, id);
+CREATE INDEX IF NOT EXISTS idx_products_stock_id ON products(stock, id);
+
+DROP INDEX IF EXISTS idx_products_created_at;
@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS idx_products_category ON products(category);
+
+DROP INDEX IF EXISTS idx_products_category_id;
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,45 @@
+-- gen_random_uuid() is only built in from PostgreSQL 13
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE IF NOT EXISTS categories (
+    id VARCHAR(255) PRIMARY KEY,
+    parent_id VARCHAR(255) REFERENCES categories(id) ON DELETE RESTRICT,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(120) NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT categories_not_own_parent CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories(slug);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_parent_name ON categories(COALESCE(parent_id, ''), lower(name));
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+-- products.category stays as the denormalized category name for full-text search
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id VARCHAR(255) REFERENCES categories(id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
+
+-- Fold free-text categories that only differ in case, spacing or punctuation
+-- ("Shoes", "shoes ", "T-Shirts"/"t shirts") into one root category per slug
+CREATE TEMPORARY TABLE legacy_categories ON COMMIT DROP AS
+SELECT btrim(category) AS name,
+       btrim(regexp_replace(lower(btrim(category)), '[^a-z0-9]+', '-', 'g'), '-') AS slug
+FROM products
+WHERE category IS NOT NULL AND btrim(category) <> '';
+
+INSERT INTO categories (id, name, slug)
+SELECT gen_random_uuid()::text, min(name), slug
+FROM legacy_categories
+WHERE slug <> ''
+GROUP BY slug;
+
+UPDATE products p
+SET category_id = c.id, category = c.name
+FROM categories c
+WHERE c.slug = btrim(regexp_replace(lower(btrim(p.category)), '[^a-z0-9]+', '-', 'g'), '-');
+
+-- Categories without a usable slug can not be mapped and are cleared
+UPDATE products SET category = NULL WHERE category_id IS NULL;
+
+DROP INDEX IF EXISTS idx_products_category;
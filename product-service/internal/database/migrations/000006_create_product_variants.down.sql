@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_variants;
+ALTER TABLE products DROP COLUMN IF EXISTS variant_options;
@@ -0,0 +1,19 @@
+-- Option definitions, e.g. [{"name": "size", "values": ["S", "M", "L"]}]
+ALTER TABLE products ADD COLUMN IF NOT EXISTS variant_options JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id VARCHAR(255) PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku VARCHAR(64) NOT NULL,
+    options JSONB NOT NULL DEFAULT '{}',
+    price DECIMAL(10,2),
+    stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    barcode VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku ON product_variants(sku);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_barcode ON product_variants(barcode) WHERE barcode IS NOT NULL;
+-- jsonb equality ignores key order, so each option combination exists once per product
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_options ON product_variants(product_id, options);
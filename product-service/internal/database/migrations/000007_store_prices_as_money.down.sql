@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS product_prices;
+
+ALTER TABLE product_variants DROP CONSTRAINT IF EXISTS product_variants_price_positive;
+ALTER TABLE product_variants ALTER COLUMN price TYPE DECIMAL(10,2) USING price / 100.0;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_price_positive;
+ALTER TABLE products ALTER COLUMN price TYPE DECIMAL(10,2) USING price / 100.0;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,17 @@
+-- Prices become integer minor units of an ISO 4217 currency. Existing prices were
+-- entered without a currency and are taken to be US dollars.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE products ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE products ALTER COLUMN price TYPE BIGINT USING round(price * 100);
+ALTER TABLE products ADD CONSTRAINT products_price_positive CHECK (price > 0);
+
+ALTER TABLE product_variants ALTER COLUMN price TYPE BIGINT USING round(price * 100);
+ALTER TABLE product_variants ADD CONSTRAINT product_variants_price_positive CHECK (price > 0);
+
+-- Fixed prices in other currencies, shown instead of a converted price
+CREATE TABLE IF NOT EXISTS product_prices (
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (product_id, currency)
+);
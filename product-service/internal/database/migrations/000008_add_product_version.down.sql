@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS products_increment_version ON products;
+DROP FUNCTION IF EXISTS products_increment_version();
+ALTER TABLE products DROP COLUMN IF EXISTS version;
@@ -0,0 +1,14 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+-- Every write to a product moves it to a new version, whichever query made it
+CREATE OR REPLACE FUNCTION products_increment_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_increment_version ON products;
+CREATE TRIGGER products_increment_version
+    BEFORE UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION products_increment_version();
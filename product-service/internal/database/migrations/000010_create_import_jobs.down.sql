@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS import_jobs;
+DROP INDEX IF EXISTS idx_products_external_id;
+DROP INDEX IF EXISTS idx_products_sku;
+ALTER TABLE products DROP COLUMN IF EXISTS external_id;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
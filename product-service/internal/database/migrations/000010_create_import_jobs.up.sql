@@ -0,0 +1,29 @@
+-- Keys imports match existing products on
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products(sku) WHERE sku IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_external_id ON products(external_id) WHERE external_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id VARCHAR(255) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    match_key VARCHAR(20) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    -- The uploaded file, cleared once the job has finished
+    payload BYTEA,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    updated_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_unfinished ON import_jobs(created_at) WHERE status IN ('pending', 'running');
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS products_record_stock_movement ON products;
+DROP FUNCTION IF EXISTS products_record_stock_movement();
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_stock_non_negative;
+DROP TABLE IF EXISTS stock_movements;
+DROP FUNCTION IF EXISTS stock_movements_append_only();
@@ -0,0 +1,86 @@
+-- Append-only ledger of stock changes. products.stock is the running balance of
+-- its movements; movements of a variant's stock carry the variant ID.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id VARCHAR(255),
+    delta INTEGER NOT NULL CHECK (delta <> 0),
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('initial', 'receipt', 'sale', 'return', 'correction')),
+    reference_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    -- Stock of the product after the movement
+    stock_after INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements(product_id, id);
+
+-- Opening balances of the stock held before the ledger existed
+INSERT INTO stock_movements (product_id, delta, reason, stock_after)
+SELECT id, stock, 'initial', stock FROM products
+WHERE stock <> 0 AND NOT EXISTS (SELECT 1 FROM stock_movements);
+
+-- Existing rows are not checked, only new writes
+DO $$
+BEGIN
+    ALTER TABLE products ADD CONSTRAINT products_stock_non_negative CHECK (stock >= 0) NOT VALID;
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+-- Every change of products.stock is recorded, whichever query made it. Writers
+-- describe the change with the transaction settings stock.reason,
+-- stock.reference_id, stock.actor_id and stock.variant_id; without them a new
+-- product's stock is recorded as initial and any other change as a correction.
+CREATE OR REPLACE FUNCTION products_record_stock_movement() RETURNS trigger AS $$
+DECLARE
+    previous INTEGER := 0;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        previous := OLD.stock;
+    END IF;
+    IF NEW.stock = previous THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO stock_movements (product_id, variant_id, delta, reason, reference_id, actor_id, stock_after)
+    VALUES (
+        NEW.id,
+        NULLIF(current_setting('stock.variant_id', true), ''),
+        NEW.stock - previous,
+        COALESCE(NULLIF(current_setting('stock.reason', true), ''),
+                 CASE WHEN TG_OP = 'INSERT' THEN 'initial' ELSE 'correction' END),
+        NULLIF(current_setting('stock.reference_id', true), ''),
+        NULLIF(current_setting('stock.actor_id', true), ''),
+        NEW.stock);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_record_stock_movement ON products;
+CREATE TRIGGER products_record_stock_movement
+    AFTER INSERT OR UPDATE OF stock ON products
+    FOR EACH ROW EXECUTE FUNCTION products_record_stock_movement();
+
+-- Movements are never changed, except that the actor of a movement is erased
+-- with the user, and only go away with their product
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF EXISTS (SELECT 1 FROM products WHERE id = OLD.product_id) THEN
+            RAISE EXCEPTION 'stock movements can not be deleted';
+        END IF;
+        RETURN OLD;
+    END IF;
+
+    IF NEW.actor_id IS NULL AND (NEW.id, NEW.product_id, NEW.variant_id, NEW.delta, NEW.reason, NEW.reference_id, NEW.stock_after, NEW.created_at)
+        IS NOT DISTINCT FROM (OLD.id, OLD.product_id, OLD.variant_id, OLD.delta, OLD.reason, OLD.reference_id, OLD.stock_after, OLD.created_at) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'stock movements can not be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements;
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS reservation_items;
+DROP TABLE IF EXISTS reservations;
+ALTER TABLE product_variants DROP CONSTRAINT IF EXISTS product_variants_reserved_within_stock;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_reserved_within_stock;
+ALTER TABLE product_variants DROP COLUMN IF EXISTS reserved;
+ALTER TABLE products DROP COLUMN IF EXISTS reserved;
@@ -0,0 +1,40 @@
+-- Units held by active reservations. Available to sell is stock - reserved; the
+-- reserved units of a product sold in variants are the total of its variants.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reserved INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS reserved INTEGER NOT NULL DEFAULT 0;
+
+DO $$
+BEGIN
+    ALTER TABLE products ADD CONSTRAINT products_reserved_within_stock CHECK (reserved >= 0 AND reserved <= stock) NOT VALID;
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+DO $$
+BEGIN
+    ALTER TABLE product_variants ADD CONSTRAINT product_variants_reserved_within_stock CHECK (reserved >= 0 AND reserved <= stock);
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id VARCHAR(255) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'committed', 'cancelled', 'expired')),
+    reference_id VARCHAR(255),
+    created_by VARCHAR(255),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS reservation_items (
+    id BIGSERIAL PRIMARY KEY,
+    reservation_id VARCHAR(255) NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id VARCHAR(255),
+    quantity INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservation_items_reservation_id ON reservation_items(reservation_id);
+CREATE INDEX IF NOT EXISTS idx_reservation_items_product_id ON reservation_items(product_id);
+-- The sweeper releases active reservations in expiry order
+CREATE INDEX IF NOT EXISTS idx_reservations_active_expires_at ON reservations(expires_at) WHERE status = 'active';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_alerts;
+DROP INDEX IF EXISTS idx_products_reorder_threshold;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_threshold;
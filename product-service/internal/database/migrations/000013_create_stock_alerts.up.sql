@@ -0,0 +1,25 @@
+-- Stock at or below the reorder threshold raises a low-stock alert; NULL disables alerts
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_threshold INTEGER CHECK (reorder_threshold >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_products_reorder_threshold ON products(id) WHERE reorder_threshold IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    threshold INTEGER NOT NULL,
+    stock INTEGER NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    notified_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by VARCHAR(255),
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A product has at most one alert that is not resolved
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_alerts_unresolved ON stock_alerts(product_id) WHERE status <> 'resolved';
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_product_id ON stock_alerts(product_id, id);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_undelivered ON stock_alerts(id) WHERE notified_at IS NULL AND status <> 'resolved';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS price_schedules;
+DROP TRIGGER IF EXISTS products_record_price_change ON products;
+DROP FUNCTION IF EXISTS products_record_price_change();
+DROP TABLE IF EXISTS price_history;
@@ -0,0 +1,72 @@
+-- Every change of a product's price, written by a trigger so no code path can
+-- change a price without a record. Writers describe the change with the
+-- price.source, price.schedule_id and price.actor_id settings of their transaction.
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGSERIAL PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    previous_price BIGINT,
+    previous_currency CHAR(3),
+    source VARCHAR(20) NOT NULL CHECK (source IN ('initial', 'manual', 'import', 'schedule_start', 'schedule_end')),
+    schedule_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product_id ON price_history(product_id, id);
+
+-- Current prices of existing products open their history
+INSERT INTO price_history (product_id, price, currency, source, changed_at)
+SELECT p.id, p.price, p.currency, 'initial', COALESCE(p.created_at, CURRENT_TIMESTAMP)
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM price_history h WHERE h.product_id = p.id);
+
+CREATE OR REPLACE FUNCTION products_record_price_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.price, NEW.currency) IS NOT DISTINCT FROM (OLD.price, OLD.currency) THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO price_history (product_id, price, currency, previous_price, previous_currency, source, schedule_id, actor_id)
+    VALUES (
+        NEW.id,
+        NEW.price,
+        NEW.currency,
+        CASE WHEN TG_OP = 'UPDATE' THEN OLD.price END,
+        CASE WHEN TG_OP = 'UPDATE' THEN OLD.currency END,
+        COALESCE(NULLIF(current_setting('price.source', true), ''),
+                 CASE WHEN TG_OP = 'INSERT' THEN 'initial' ELSE 'manual' END),
+        NULLIF(current_setting('price.schedule_id', true), ''),
+        NULLIF(current_setting('price.actor_id', true), ''));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_record_price_change ON products;
+CREATE TRIGGER products_record_price_change
+    AFTER INSERT OR UPDATE OF price, currency ON products
+    FOR EACH ROW EXECUTE FUNCTION products_record_price_change();
+
+-- Future prices. A scheduled price replaces the product price from starts_at and
+-- the price it replaced is restored at ends_at; without ends_at it stays.
+CREATE TABLE IF NOT EXISTS price_schedules (
+    id VARCHAR(255) PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price BIGINT NOT NULL CHECK (price > 0),
+    currency CHAR(3) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE CHECK (ends_at > starts_at),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('scheduled', 'active', 'completed', 'cancelled')),
+    previous_price BIGINT,
+    note TEXT,
+    created_by VARCHAR(255),
+    activated_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_schedules_product_id ON price_schedules(product_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_due_start ON price_schedules(starts_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_price_schedules_due_end ON price_schedules(ends_at) WHERE status = 'active';
@@ -0,0 +1,46 @@
+-- Discount rules. A promotion applies to one product, to a category and its
+-- subcategories, or to every product; with a coupon code only to quotes that
+-- present the code.
+CREATE TABLE IF NOT EXISTS promotions (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    type VARCHAR(20) NOT NULL CHECK (type IN ('percentage', 'fixed', 'buy_x_get_y')),
+    percent INTEGER CHECK (percent BETWEEN 1 AND 100),
+    amount BIGINT CHECK (amount > 0),
+    currency CHAR(3),
+    buy_quantity INTEGER CHECK (buy_quantity > 0),
+    get_quantity INTEGER CHECK (get_quantity > 0),
+    product_id VARCHAR(255) REFERENCES products(id) ON DELETE CASCADE,
+    category_id VARCHAR(255) REFERENCES categories(id) ON DELETE CASCADE,
+    coupon_code VARCHAR(64),
+    usage_limit INTEGER CHECK (usage_limit > 0),
+    usage_count INTEGER NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT promotions_single_target CHECK (product_id IS NULL OR category_id IS NULL),
+    CONSTRAINT promotions_window CHECK (starts_at IS NULL OR ends_at IS NULL OR ends_at > starts_at),
+    CONSTRAINT promotions_usage_within_limit CHECK (usage_limit IS NULL OR usage_count <= usage_limit),
+    CONSTRAINT promotions_rule CHECK (
+        (type = 'percentage' AND percent IS NOT NULL) OR
+        (type = 'fixed' AND amount IS NOT NULL AND currency IS NOT NULL) OR
+        (type = 'buy_x_get_y' AND buy_quantity IS NOT NULL AND get_quantity IS NOT NULL))
+);
+
+-- Codes are stored upper case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotions_coupon_code ON promotions(coupon_code) WHERE coupon_code IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_promotions_automatic ON promotions(created_at) WHERE coupon_code IS NULL AND active;
+
+-- A coupon is redeemed once per order, so redeeming again for the same
+-- reference does not use it up further
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    promotion_id VARCHAR(255) NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    reference_id VARCHAR(255) NOT NULL,
+    redeemed_by VARCHAR(255),
+    redeemed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (promotion_id, reference_id)
+);
@@ -0,0 +1,46 @@
+-- Images of a product in display order. Files live in blob storage under
+-- storage_key; variants holds the thumbnails with their keys and URLs.
+CREATE TABLE IF NOT EXISTS product_images (
+    id VARCHAR(255) PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    alt_text VARCHAR(500) NOT NULL DEFAULT '',
+    storage_key VARCHAR(1024) NOT NULL,
+    url TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    width INTEGER NOT NULL CHECK (width > 0),
+    height INTEGER NOT NULL CHECK (height > 0),
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    checksum CHAR(64) NOT NULL,
+    variants JSONB NOT NULL DEFAULT '[]',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    -- Deferred so reordering can swap positions within a transaction
+    CONSTRAINT product_images_position UNIQUE (product_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+-- Blobs of deleted images, including those of purged products, waiting to be
+-- removed from storage
+CREATE TABLE IF NOT EXISTS blob_deletions (
+    id BIGSERIAL PRIMARY KEY,
+    storage_key VARCHAR(1024) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION product_images_queue_blobs() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO blob_deletions (storage_key)
+    SELECT OLD.storage_key
+    UNION ALL
+    SELECT v->>'key' FROM jsonb_array_elements(OLD.variants) v;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS product_images_queue_blobs ON product_images;
+CREATE TRIGGER product_images_queue_blobs
+    AFTER DELETE ON product_images
+    FOR EACH ROW EXECUTE FUNCTION product_images_queue_blobs();
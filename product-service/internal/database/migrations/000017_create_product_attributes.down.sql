@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_products_attributes;
+ALTER TABLE products DROP COLUMN IF EXISTS attributes;
+DROP TABLE IF EXISTS category_attributes;
+DROP TABLE IF EXISTS attribute_definitions;
@@ -0,0 +1,32 @@
+-- Typed attributes products can be described with. Code is the key of the
+-- attribute in product attributes and filters; enum attributes take one of
+-- enum_values.
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+    id VARCHAR(255) PRIMARY KEY,
+    code VARCHAR(63) NOT NULL CHECK (code ~ '^[a-z][a-z0-9_]*$'),
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('text', 'number', 'boolean', 'enum')),
+    unit VARCHAR(20) NOT NULL DEFAULT '',
+    enum_values TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT attribute_definitions_enum_values CHECK ((type = 'enum') = (cardinality(enum_values) > 0))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attribute_definitions_code ON attribute_definitions(code);
+
+-- Attributes that apply to the products of a category and its subcategories
+CREATE TABLE IF NOT EXISTS category_attributes (
+    category_id VARCHAR(255) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    attribute_id VARCHAR(255) NOT NULL REFERENCES attribute_definitions(id) ON DELETE CASCADE,
+    required BOOLEAN NOT NULL DEFAULT false,
+    PRIMARY KEY (category_id, attribute_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_attributes_attribute_id ON category_attributes(attribute_id);
+
+-- Attribute values by code, validated against their definitions when written
+ALTER TABLE products ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(attributes) = 'object');
+
+-- Serves the containment (@>) and key existence (?) tests of attribute filters
+CREATE INDEX IF NOT EXISTS idx_products_attributes ON products USING GIN (attributes);
@@ -0,0 +1,25 @@
+-- Lifecycle status of products. Only active products are listed publicly.
+-- Scheduled products go live at publish_at and active ones are archived at
+-- unpublish_at; publish_at stays set once a product is live.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('draft', 'scheduled', 'active', 'archived'));
+ALTER TABLE products ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMP WITH TIME ZONE;
+
+-- Existing products stay live, new ones start as drafts
+ALTER TABLE products ALTER COLUMN status SET DEFAULT 'draft';
+
+DO $$
+BEGIN
+    ALTER TABLE products ADD CONSTRAINT products_schedule CHECK (
+        (status <> 'scheduled' OR publish_at IS NOT NULL)
+        AND (publish_at IS NULL OR unpublish_at IS NULL OR unpublish_at > publish_at)
+    );
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_products_status ON products(status);
+
+-- Serve the scheduler's scans for due products
+CREATE INDEX IF NOT EXISTS idx_products_publish_at ON products(publish_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_products_unpublish_at ON products(unpublish_at) WHERE status = 'active' AND unpublish_at IS NOT NULL;
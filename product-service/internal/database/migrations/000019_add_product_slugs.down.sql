@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS product_slug_redirects;
+DROP INDEX IF EXISTS idx_products_slug;
+ALTER TABLE products DROP COLUMN IF EXISTS canonical_url;
+ALTER TABLE products DROP COLUMN IF EXISTS meta_description;
+ALTER TABLE products DROP COLUMN IF EXISTS meta_title;
+ALTER TABLE products DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,42 @@
+-- Human readable addresses and search engine metadata of products
+ALTER TABLE products ADD COLUMN IF NOT EXISTS slug VARCHAR(120);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS meta_title VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS meta_description VARCHAR(500) NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS canonical_url VARCHAR(2048) NOT NULL DEFAULT '';
+
+-- Existing products get slugs from their names the way categories did, without
+-- transliteration. Oldest first, a product takes the plain slug of its name, else
+-- the lowest numbered one from -2 on that is still free, as new products do.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products(slug);
+
+DO $$
+DECLARE
+    product RECORD;
+    base TEXT;
+    candidate TEXT;
+    n INTEGER;
+BEGIN
+    FOR product IN SELECT id, name FROM products WHERE slug IS NULL ORDER BY created_at, id LOOP
+        base := COALESCE(NULLIF(btrim(left(regexp_replace(lower(btrim(product.name)), '[^a-z0-9]+', '-', 'g'), 112), '-'), ''), 'product');
+        candidate := base;
+        n := 2;
+        WHILE EXISTS (SELECT 1 FROM products WHERE slug = candidate) LOOP
+            candidate := base || '-' || n;
+            n := n + 1;
+        END LOOP;
+        UPDATE products SET slug = candidate WHERE id = product.id;
+    END LOOP;
+END
+$$;
+
+ALTER TABLE products ALTER COLUMN slug SET NOT NULL;
+
+-- Former slugs of products, so old addresses redirect to the current one. A
+-- slug is used by at most one product or redirect.
+CREATE TABLE IF NOT EXISTS product_slug_redirects (
+    slug VARCHAR(120) PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_slug_redirects_product_id ON product_slug_redirects(product_id);
@@ -1,10 +1,13 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/leandrowiemesfilho/product-service/internal/config"
+	_ "github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -40,27 +43,18 @@ func (db *DB) Close() error {
 	return db.DB.Close()
 }
 
-// InitSchema creates the necessary tables
+// InitSchema applies all pending versioned migrations
 func (db *DB) InitSchema(logger *zap.SugaredLogger) error {
-	query := `
-    CREATE TABLE IF NOT EXISTS products (
-        id VARCHAR(255) PRIMARY KEY,
-        name VARCHAR(255) NOT NULL,
-        description TEXT,
-        price DECIMAL(10,2) NOT NULL,
-        category VARCHAR(100),
-        stock INTEGER NOT NULL DEFAULT 0,
-        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-    );
+	migrator, err := NewMigrator(db.DB, logger)
+	if err != nil {
+		return err
+	}
 
-    CREATE INDEX IF NOT EXISTS idx_products_category ON products(category);
-    CREATE INDEX IF NOT EXISTS idx_products_created_at ON products(created_at);
-    `
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
 
-	_, err := db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create schema: %w", err)
+	if err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
 	}
 
 	logger.Info("Database schema initialized successfully")
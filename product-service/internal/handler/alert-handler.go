@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type AlertHandler struct {
+	service service.AlertService
+	logger  *zap.SugaredLogger
+}
+
+func NewAlertHandler(service service.AlertService, logger *zap.SugaredLogger) *AlertHandler {
+	return &AlertHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetAlerts lists low-stock alerts, newest first, optionally by status and
+// product. Pages continue with before set to the next_before of the previous page.
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	query := &model.StockAlertQuery{Status: c.Query("status"), ProductID: c.Query("product_id")}
+	limit, err := queryInt(c, "limit")
+	if err == nil && c.Query("before") != "" {
+		if query.Before, err = strconv.ParseInt(c.Query("before"), 10, 64); err != nil {
+			err = fmt.Errorf("%w: before must be an alert ID", model.ErrInvalidQuery)
+		}
+	}
+	query.Limit = limit
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.StockAlertsResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	alerts, nextBefore, err := h.service.ListAlerts(query)
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to get stock alerts")
+		c.JSON(status, model.StockAlertsResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.StockAlertsResponse{
+		Success:    true,
+		Data:       alerts,
+		NextBefore: nextBefore,
+	})
+}
+
+func (h *AlertHandler) GetAlert(c *gin.Context) {
+	id, err := alertID(c)
+	if err == nil {
+		var alert *model.StockAlert
+		if alert, err = h.service.GetAlert(id); err == nil {
+			c.JSON(http.StatusOK, model.StockAlertResponse{
+				Success: true,
+				Data:    alert,
+			})
+			return
+		}
+	}
+
+	status, message := h.errorStatus(c, err, "Failed to get stock alert")
+	c.JSON(status, model.StockAlertResponse{
+		Success: false,
+		Error:   message,
+	})
+}
+
+// AcknowledgeAlert records that someone is dealing with a low-stock alert
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	id, err := alertID(c)
+	if err == nil {
+		var alert *model.StockAlert
+		if alert, err = h.service.AcknowledgeAlert(id, c.GetHeader(HeaderUserID)); err == nil {
+			c.JSON(http.StatusOK, model.StockAlertResponse{
+				Success: true,
+				Data:    alert,
+			})
+			return
+		}
+	}
+
+	status, message := h.errorStatus(c, err, "Failed to acknowledge stock alert")
+	c.JSON(status, model.StockAlertResponse{
+		Success: false,
+		Error:   message,
+	})
+}
+
+func alertID(c *gin.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, model.ErrInvalidAlertID
+	}
+	return id, nil
+}
+
+// errorStatus maps a service error to a status and message, logging unexpected errors
+func (h *AlertHandler) errorStatus(c *gin.Context, err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, model.ErrAlertNotFound):
+		return http.StatusNotFound, "Stock alert not found"
+	case errors.Is(err, model.ErrAlertResolved):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrInvalidQuery), errors.Is(err, model.ErrInvalidAlertID):
+		return http.StatusBadRequest, err.Error()
+	default:
+		h.logger.Errorw(fallback, "error", err, "alert_id", c.Param("id"))
+		return http.StatusInternalServerError, fallback
+	}
+}
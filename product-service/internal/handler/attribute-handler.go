@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type AttributeHandler struct {
+	service service.AttributeService
+	logger  *zap.SugaredLogger
+}
+
+func NewAttributeHandler(service service.AttributeService, logger *zap.SugaredLogger) *AttributeHandler {
+	return &AttributeHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *AttributeHandler) CreateAttribute(c *gin.Context) {
+	var req model.AttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.AttributeResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	attribute, err := h.service.CreateAttribute(&req)
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to create attribute")
+		c.JSON(status, model.AttributeResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.AttributeResponse{
+		Success: true,
+		Data:    attribute,
+	})
+}
+
+func (h *AttributeHandler) GetAttribute(c *gin.Context) {
+	attribute, err := h.service.GetAttribute(c.Param("id"))
+	h.respond(c, attribute, err, "Failed to get attribute")
+}
+
+func (h *AttributeHandler) GetAllAttributes(c *gin.Context) {
+	attributes, err := h.service.GetAllAttributes()
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to get attributes")
+		c.JSON(status, model.AttributesResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AttributesResponse{
+		Success: true,
+		Data:    attributes,
+	})
+}
+
+// UpdateAttribute replaces the name, unit and enum values of an attribute. The
+// request repeats its code and type, which can not change.
+func (h *AttributeHandler) UpdateAttribute(c *gin.Context) {
+	var req model.AttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.AttributeResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	attribute, err := h.service.UpdateAttribute(c.Param("id"), &req)
+	h.respond(c, attribute, err, "Failed to update attribute")
+}
+
+func (h *AttributeHandler) DeleteAttribute(c *gin.Context) {
+	if err := h.service.DeleteAttribute(c.Param("id")); err != nil {
+		status, message := h.errorStatus(c, err, "Failed to delete attribute")
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attribute deleted successfully"})
+}
+
+// GetCategoryAttributes lists the attributes that apply to the products of a
+// category, including those inherited from its ancestors
+func (h *AttributeHandler) GetCategoryAttributes(c *gin.Context) {
+	attributes, err := h.service.GetCategoryAttributes(c.Param("id"))
+	h.respondCategory(c, attributes, err, "Failed to get category attributes")
+}
+
+// SetCategoryAttributes replaces the attributes assigned to a category
+func (h *AttributeHandler) SetCategoryAttributes(c *gin.Context) {
+	var req model.SetCategoryAttributesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CategoryAttributesResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	attributes, err := h.service.SetCategoryAttributes(c.Param("id"), &req)
+	h.respondCategory(c, attributes, err, "Failed to set category attributes")
+}
+
+func (h *AttributeHandler) respond(c *gin.Context, attribute *model.AttributeDefinition, err error, message string) {
+	if err != nil {
+		status, message := h.errorStatus(c, err, message)
+		c.JSON(status, model.AttributeResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AttributeResponse{
+		Success: true,
+		Data:    attribute,
+	})
+}
+
+func (h *AttributeHandler) respondCategory(c *gin.Context, attributes []*model.CategoryAttribute, err error, message string) {
+	if err != nil {
+		status, message := h.errorStatus(c, err, message)
+		c.JSON(status, model.CategoryAttributesResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryAttributesResponse{
+		Success: true,
+		Data:    attributes,
+	})
+}
+
+func (h *AttributeHandler) errorStatus(c *gin.Context, err error, message string) (int, string) {
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, model.ErrAttributeNotFound):
+		return http.StatusNotFound, "Attribute not found"
+	case errors.Is(err, model.ErrCategoryNotFound):
+		return http.StatusNotFound, "Category not found"
+	case errors.Is(err, model.ErrDuplicateAttribute), errors.Is(err, model.ErrAttributeInUse):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrInvalidAttribute), errors.As(err, &validationErrors):
+		return http.StatusBadRequest, err.Error()
+	}
+
+	h.logger.Errorw(message, "error", err, "id", c.Param("id"))
+	return http.StatusInternalServerError, message
+}
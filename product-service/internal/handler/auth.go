@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Identity headers forwarded by the API gateway from the validated token
+const (
+	HeaderUserID   = "X-User-ID"
+	HeaderUserRole = "X-User-Role"
+	HeaderActorID  = "X-Actor-ID"
+)
+
+const RoleAdmin = "admin"
+
+// isAdmin reports whether the gateway authenticated the request as an admin
+func isAdmin(c *gin.Context) bool {
+	return c.GetHeader(HeaderUserRole) == RoleAdmin
+}
+
+// actorID returns who is acting: the admin impersonating the user, else the user
+func actorID(c *gin.Context) string {
+	if actor := c.GetHeader(HeaderActorID); actor != "" {
+		return actor
+	}
+	return c.GetHeader(HeaderUserID)
+}
+
+// RequireAdmin rejects requests the gateway did not authenticate as an admin
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin role required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type CategoryHandler struct {
+	service service.CategoryService
+	logger  *zap.SugaredLogger
+}
+
+func NewCategoryHandler(service service.CategoryService, logger *zap.SugaredLogger) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req model.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CategoryResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	category, err := h.service.CreateCategory(&req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.CategoryResponse{
+		Success: true,
+		Data:    category,
+	})
+}
+
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	category, err := h.service.GetCategory(c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get category")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryResponse{
+		Success: true,
+		Data:    category,
+	})
+}
+
+// GetAllCategories returns the category tree, or a flat list with flat=true
+func (h *CategoryHandler) GetAllCategories(c *gin.Context) {
+	flat, _ := strconv.ParseBool(c.Query("flat"))
+
+	var categories []*model.Category
+	var err error
+	if flat {
+		categories, err = h.service.GetAllCategories()
+	} else {
+		categories, err = h.service.GetCategoryTree()
+	}
+	if err != nil {
+		h.logger.Errorw("Failed to get categories", "error", err)
+		c.JSON(http.StatusInternalServerError, model.CategoriesResponse{
+			Success: false,
+			Error:   "Failed to get categories",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoriesResponse{
+		Success: true,
+		Data:    categories,
+	})
+}
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	var req model.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CategoryResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	category, err := h.service.UpdateCategory(c.Param("id"), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update category")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryResponse{
+		Success: true,
+		Data:    category,
+	})
+}
+
+func (h *CategoryHandler) MoveCategory(c *gin.Context) {
+	var req model.MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CategoryResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	category, err := h.service.MoveCategory(c.Param("id"), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to move category")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryResponse{
+		Success: true,
+		Data:    category,
+	})
+}
+
+// DeleteCategory deletes an empty category. With reassign_to set to a category ID,
+// or to "parent", its products and subcategories are moved there first.
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteCategory(id, c.Query("reassign_to")); err != nil {
+		h.respondError(c, err, "Failed to delete category")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
+
+func (h *CategoryHandler) respondError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, model.ErrCategoryNotFound):
+		status, message = http.StatusNotFound, "Category not found"
+	case errors.Is(err, model.ErrCategoryExists), errors.Is(err, model.ErrCategoryNotEmpty),
+		errors.Is(err, model.ErrInvalidAttribute):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrCategoryCycle), errors.Is(err, model.ErrInvalidSlug), errors.As(err, &validationErrors):
+		status, message = http.StatusBadRequest, err.Error()
+	default:
+		h.logger.Errorw(message, "error", err, "category_id", c.Param("id"))
+	}
+
+	c.JSON(status, model.CategoryResponse{
+		Success: false,
+		Error:   message,
+	})
+}
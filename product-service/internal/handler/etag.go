@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+// productETag is the strong entity tag of a product version. The display price
+// is part of the representation, so its currency is part of the tag: "3-EUR".
+func productETag(product *model.Product) string {
+	tag := strconv.FormatInt(product.Version, 10)
+	if product.DisplayPrice != nil {
+		tag += "-" + product.DisplayPrice.Currency
+	}
+	return `"` + tag + `"`
+}
+
+// parseIfMatch reads the If-Match header into a precondition, or nil when absent.
+// If-Match uses strong comparison (RFC 9110), so weak tags never match. Writes
+// replace every representation of a version, so only the version is compared.
+func parseIfMatch(c *gin.Context) (*model.Precondition, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return nil, nil
+	}
+	if header == "*" {
+		return &model.Precondition{Any: true}, nil
+	}
+
+	precondition := &model.Precondition{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		value, _, _ := strings.Cut(strings.Trim(tag, `"`), "-")
+		version, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			return nil, errors.New("malformed If-Match header")
+		}
+		precondition.Versions = append(precondition.Versions, version)
+	}
+
+	return precondition, nil
+}
+
+// notModified reports whether the If-None-Match header matches etag, using weak comparison
+func notModified(c *gin.Context, etag string) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    *model.Precondition
+		wantErr bool
+	}{
+		{"absent", "", nil, false},
+		{"any", "*", &model.Precondition{Any: true}, false},
+		{"version", `"3"`, &model.Precondition{Versions: []int64{3}}, false},
+		{"version with currency", `"3-EUR"`, &model.Precondition{Versions: []int64{3}}, false},
+		{"several", `"3-EUR", "4"`, &model.Precondition{Versions: []int64{3, 4}}, false},
+		{"weak tags never match", `W/"3"`, &model.Precondition{}, false},
+		{"unquoted", `3`, nil, true},
+		{"not a version", `"abc"`, nil, true},
+		{"only a currency", `"-EUR"`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("If-Match", tt.header)
+			}
+
+			got, err := parseIfMatch(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIfMatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIfMatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// EventSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body
+const EventSignatureHeader = "X-Event-Signature"
+
+// maxEventSize bounds the body read before the signature is checked
+const maxEventSize = 1 << 20
+
+// EventHandler receives events published by other services. Delivery is at least
+// once, so every handled event type must be idempotent.
+type EventHandler struct {
+	service service.ProductService
+	secret  string
+	logger  *zap.SugaredLogger
+}
+
+func NewEventHandler(service service.ProductService, secret string, logger *zap.SugaredLogger) *EventHandler {
+	return &EventHandler{
+		service: service,
+		secret:  secret,
+		logger:  logger,
+	}
+}
+
+func (h *EventHandler) HandleEvent(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxEventSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if !h.validSignature(c.GetHeader(EventSignatureHeader), body) {
+		h.logger.Warnw("Rejected event with invalid signature", "client_ip", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid event signature"})
+		return
+	}
+
+	var event model.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event: " + err.Error()})
+		return
+	}
+
+	switch event.Type {
+	case model.EventUserErased:
+		userID, _ := event.Data["user_id"].(string)
+		if err := h.service.EraseUserData(userID); err != nil {
+			h.logger.Errorw("Failed to handle event", "error", err, "event_id", event.ID, "event_type", event.Type)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle event"})
+			return
+		}
+	default:
+		// Accept unknown events so the publisher does not keep retrying them
+		h.logger.Infow("Ignoring unhandled event", "event_id", event.ID, "event_type", event.Type)
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	h.logger.Infow("Event handled", "event_id", event.ID, "event_type", event.Type)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *EventHandler) validSignature(signature string, body []byte) bool {
+	// Without a shared secret nothing can be verified, so nothing is accepted
+	if h.secret == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
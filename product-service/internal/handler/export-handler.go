@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// exportContentTypes maps export formats to their content type and file extension
+var exportContentTypes = map[string][2]string{
+	model.ExportFormatCSV:         {"text/csv; charset=utf-8", "csv"},
+	model.ExportFormatNDJSON:      {"application/x-ndjson", "ndjson"},
+	model.ExportFormatMerchantXML: {"application/xml; charset=utf-8", "xml"},
+	model.ExportFormatMerchantTSV: {"text/tab-separated-values; charset=utf-8", "tsv"},
+}
+
+type ExportHandler struct {
+	service service.ProductService
+	feed    *FeedConfig
+	logger  *zap.SugaredLogger
+}
+
+func NewExportHandler(service service.ProductService, feed *FeedConfig, logger *zap.SugaredLogger) *ExportHandler {
+	return &ExportHandler{
+		service: service,
+		feed:    feed,
+		logger:  logger,
+	}
+}
+
+// ExportProducts streams the products matching the listing filters as a download
+// in the format parameter: csv (default), ndjson, merchant_xml or merchant_tsv.
+// The response is gzip compressed when the client accepts it.
+func (h *ExportHandler) ExportProducts(c *gin.Context) {
+	filter, err := parseProductFilter(c, h.service.DefaultCurrency())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductsResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	query := &model.ExportQuery{
+		Filter:   filter,
+		Format:   strings.ToLower(c.Query("format")),
+		Currency: displayCurrency(c),
+	}
+
+	// The response starts with the first product, so a failing query can still be answered with an error
+	var writer exportWriter
+	var compressor *gzip.Writer
+	started := false
+	begin := func() error {
+		started = true
+		var out io.Writer = c.Writer
+		contentType := exportContentTypes[query.Format]
+		c.Header("Content-Type", contentType[0])
+		c.Header("Content-Disposition", `attachment; filename="products.`+contentType[1]+`"`)
+		c.Header("Vary", "Accept-Encoding")
+		if acceptsGzip(c) {
+			c.Header("Content-Encoding", "gzip")
+			compressor = gzip.NewWriter(c.Writer)
+			out = compressor
+		}
+		c.Status(http.StatusOK)
+
+		var err error
+		writer, err = newExportWriter(out, query.Format, h.feed)
+		return err
+	}
+
+	err = h.service.ExportProducts(query, func(product *model.Product) error {
+		if writer == nil {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(product)
+	})
+	if err == nil && !started {
+		err = begin()
+	}
+	if err != nil {
+		if started {
+			// The download has started; leaving it unterminated lets clients detect the failure
+			h.logger.Errorw("Product export aborted", "error", err, "format", query.Format)
+			return
+		}
+		if errors.Is(err, model.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, model.ProductsResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		h.logger.Errorw("Failed to export products", "error", err)
+		c.JSON(http.StatusInternalServerError, model.ProductsResponse{
+			Success: false,
+			Error:   "Failed to export products",
+		})
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		h.logger.Warnw("Failed to complete product export", "error", err)
+		return
+	}
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			h.logger.Warnw("Failed to complete product export", "error", err)
+		}
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip
+func acceptsGzip(c *gin.Context) bool {
+	for _, encoding := range strings.Split(c.GetHeader("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+// FeedConfig describes the store in product feeds. ProductURL is the storefront
+// address of a product, with {id} and {slug} replaced by the product ID and slug.
+// The canonical URL of a product takes precedence.
+type FeedConfig struct {
+	Title       string
+	Link        string
+	Description string
+	ProductURL  string
+}
+
+// exportWriter writes products in an export format. The preamble is written on
+// creation and Close completes the document.
+type exportWriter interface {
+	Write(product *model.Product) error
+	Close() error
+}
+
+func newExportWriter(w io.Writer, format string, feed *FeedConfig) (exportWriter, error) {
+	switch format {
+	case model.ExportFormatNDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return &ndjsonExportWriter{encoder: encoder}, nil
+	case model.ExportFormatMerchantXML:
+		return newMerchantXMLWriter(w, feed)
+	case model.ExportFormatMerchantTSV:
+		return newMerchantTSVWriter(w, feed)
+	default:
+		return newCSVExportWriter(w)
+	}
+}
+
+// exportColumns are the CSV export columns. New columns are only ever appended
+// so consumers and diffs of earlier exports keep working.
+var exportColumns = []string{
+	"id", "sku", "external_id", "name", "description", "price", "currency", "prices",
+	"category_id", "category", "stock", "version", "created_at", "updated_at",
+	"display_price", "display_currency", "available",
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer) (*csvExportWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{writer: writer}, nil
+}
+
+func (e *csvExportWriter) Write(p *model.Product) error {
+	prices := make([]string, len(p.Prices))
+	for i, price := range p.Prices {
+		prices[i] = price.String()
+	}
+	var displayPrice, displayCurrency string
+	if p.DisplayPrice != nil {
+		displayPrice, displayCurrency = p.DisplayPrice.Decimal(), p.DisplayPrice.Currency
+	}
+
+	return e.writer.Write([]string{
+		p.ID, deref(p.SKU), deref(p.ExternalID), p.Name, p.Description, p.Price.Decimal(), p.Price.Currency,
+		strings.Join(prices, ";"), deref(p.CategoryID), p.Category, strconv.Itoa(p.Stock),
+		strconv.FormatInt(p.Version, 10), p.CreatedAt.UTC().Format(time.RFC3339), p.UpdatedAt.UTC().Format(time.RFC3339),
+		displayPrice, displayCurrency, strconv.Itoa(p.Available),
+	})
+}
+
+func (e *csvExportWriter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExportWriter) Write(p *model.Product) error {
+	return e.encoder.Encode(p)
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// merchantItem is a product in a Google Merchant Center feed. Without GTINs or
+// brands products are declared as having no identifiers.
+type merchantItem struct {
+	XMLName          xml.Name `xml:"item"`
+	ID               string   `xml:"g:id"`
+	Title            string   `xml:"g:title"`
+	Description      string   `xml:"g:description"`
+	Link             string   `xml:"g:link,omitempty"`
+	ImageLink        string   `xml:"g:image_link,omitempty"`
+	Price            string   `xml:"g:price"`
+	Availability     string   `xml:"g:availability"`
+	Condition        string   `xml:"g:condition"`
+	ProductType      string   `xml:"g:product_type,omitempty"`
+	IdentifierExists string   `xml:"g:identifier_exists"`
+}
+
+// merchantColumns are the merchantItem attributes in feed column order
+var merchantColumns = []string{
+	"id", "title", "description", "link", "image_link", "price", "availability", "condition", "product_type", "identifier_exists",
+}
+
+func newMerchantItem(p *model.Product, feed *FeedConfig) *merchantItem {
+	price := p.Price
+	if p.DisplayPrice != nil {
+		price = p.DisplayPrice.Money
+	}
+	availability := "out_of_stock"
+	if p.Available > 0 {
+		availability = "in_stock"
+	}
+	link := p.SEO.CanonicalURL
+	if link == "" && feed.ProductURL != "" {
+		link = strings.NewReplacer("{id}", p.ID, "{slug}", p.Slug).Replace(feed.ProductURL)
+	}
+	var imageLink string
+	if len(p.Images) > 0 {
+		imageLink = p.Images[0].URL
+	}
+
+	return &merchantItem{
+		ID:               p.ID,
+		Title:            p.Name,
+		Description:      p.Description,
+		Link:             link,
+		ImageLink:        imageLink,
+		Price:            price.String(),
+		Availability:     availability,
+		Condition:        "new",
+		ProductType:      p.Category,
+		IdentifierExists: "no",
+	}
+}
+
+func (i *merchantItem) columns() []string {
+	return []string{
+		i.ID, i.Title, i.Description, i.Link, i.ImageLink, i.Price, i.Availability, i.Condition, i.ProductType, i.IdentifierExists,
+	}
+}
+
+// merchantXMLWriter writes an RSS 2.0 feed with the Google product namespace
+type merchantXMLWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	feed    *FeedConfig
+}
+
+func newMerchantXMLWriter(w io.Writer, feed *FeedConfig) (*merchantXMLWriter, error) {
+	var header strings.Builder
+	header.WriteString(xml.Header)
+	header.WriteString(`<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0">` + "\n<channel>\n")
+	for _, element := range [][2]string{{"title", feed.Title}, {"link", feed.Link}, {"description", feed.Description}} {
+		header.WriteString("<" + element[0] + ">")
+		xml.EscapeText(&header, []byte(element[1]))
+		header.WriteString("</" + element[0] + ">\n")
+	}
+	if _, err := io.WriteString(w, header.String()); err != nil {
+		return nil, err
+	}
+
+	return &merchantXMLWriter{w: w, encoder: xml.NewEncoder(w), feed: feed}, nil
+}
+
+func (e *merchantXMLWriter) Write(p *model.Product) error {
+	if err := e.encoder.Encode(newMerchantItem(p, e.feed)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
+
+func (e *merchantXMLWriter) Close() error {
+	_, err := io.WriteString(e.w, "</channel>\n</rss>\n")
+	return err
+}
+
+// merchantTSVWriter writes a tab separated feed. Feed values can not be quoted,
+// so tabs and line breaks in them are replaced by spaces.
+type merchantTSVWriter struct {
+	w    io.Writer
+	feed *FeedConfig
+}
+
+var tsvReplacer = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+func newMerchantTSVWriter(w io.Writer, feed *FeedConfig) (*merchantTSVWriter, error) {
+	e := &merchantTSVWriter{w: w, feed: feed}
+	if err := e.writeLine(merchantColumns); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *merchantTSVWriter) Write(p *model.Product) error {
+	return e.writeLine(newMerchantItem(p, e.feed).columns())
+}
+
+func (e *merchantTSVWriter) writeLine(values []string) error {
+	line := make([]string, len(values))
+	for i, value := range values {
+		line[i] = tsvReplacer.Replace(value)
+	}
+	_, err := io.WriteString(e.w, strings.Join(line, "\t")+"\n")
+	return err
+}
+
+func (e *merchantTSVWriter) Close() error {
+	return nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// multipartOverhead is allowed on top of the largest image for the form
+// boundaries, headers and the other fields of an upload
+const multipartOverhead = 64 << 10
+
+type ImageHandler struct {
+	service service.ImageService
+	maxSize int64
+	logger  *zap.SugaredLogger
+}
+
+func NewImageHandler(service service.ImageService, maxSize int64, logger *zap.SugaredLogger) *ImageHandler {
+	return &ImageHandler{
+		service: service,
+		maxSize: maxSize,
+		logger:  logger,
+	}
+}
+
+// UploadImage adds the image uploaded as the "file" field of a multipart form
+// to a product. The optional alt_text field describes it and position places it
+// before the image at that position instead of after the last one.
+func (h *ImageHandler) UploadImage(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+
+	upload, err := h.readUpload(c)
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) || errors.Is(err, model.ErrImageTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, model.ProductImageResponse{
+			Success: false,
+			Error:   "Invalid upload: " + err.Error(),
+		})
+		return
+	}
+
+	image, err := h.service.UploadImage(c.Request.Context(), upload)
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to upload image")
+		c.JSON(status, model.ProductImageResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.Header("Location", "/api/v1/products/"+image.ProductID+"/images/"+image.ID)
+	c.JSON(http.StatusCreated, model.ProductImageResponse{
+		Success: true,
+		Data:    image,
+	})
+}
+
+func (h *ImageHandler) readUpload(c *gin.Context) (*model.ImageUpload, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != "multipart/form-data" {
+		return nil, errors.New("expected a multipart/form-data request")
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	if header.Size > h.maxSize {
+		return nil, model.ErrImageTooLarge
+	}
+
+	upload := &model.ImageUpload{
+		ProductID: c.Param("id"),
+		AltText:   c.PostForm("alt_text"),
+		CreatedBy: c.GetHeader(HeaderUserID),
+	}
+	if value := c.PostForm("position"); value != "" {
+		position, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.New("position must be an integer")
+		}
+		upload.Position = &position
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if upload.Data, err = io.ReadAll(file); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (h *ImageHandler) GetImages(c *gin.Context) {
+	images, err := h.service.GetImages(c.Param("id"))
+	h.respondList(c, images, err, "Failed to get images")
+}
+
+func (h *ImageHandler) GetImage(c *gin.Context) {
+	image, err := h.service.GetImage(c.Param("id"), c.Param("imageId"))
+	h.respond(c, image, err, "Failed to get image")
+}
+
+// UpdateImage changes the alt text of an image
+func (h *ImageHandler) UpdateImage(c *gin.Context) {
+	var req model.UpdateImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductImageResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	image, err := h.service.UpdateImage(c.Param("id"), c.Param("imageId"), &req)
+	h.respond(c, image, err, "Failed to update image")
+}
+
+// ReorderImages puts the images of a product in the order of image_ids, which
+// must list all of them
+func (h *ImageHandler) ReorderImages(c *gin.Context) {
+	var req model.ReorderImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductImagesResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	images, err := h.service.ReorderImages(c.Param("id"), &req)
+	h.respondList(c, images, err, "Failed to reorder images")
+}
+
+func (h *ImageHandler) DeleteImage(c *gin.Context) {
+	if err := h.service.DeleteImage(c.Param("id"), c.Param("imageId")); err != nil {
+		status, message := h.errorStatus(c, err, "Failed to delete image")
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+func (h *ImageHandler) respond(c *gin.Context, image *model.ProductImage, err error, message string) {
+	if err != nil {
+		status, message := h.errorStatus(c, err, message)
+		c.JSON(status, model.ProductImageResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ProductImageResponse{
+		Success: true,
+		Data:    image,
+	})
+}
+
+func (h *ImageHandler) respondList(c *gin.Context, images []*model.ProductImage, err error, message string) {
+	if err != nil {
+		status, message := h.errorStatus(c, err, message)
+		c.JSON(status, model.ProductImagesResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ProductImagesResponse{
+		Success: true,
+		Data:    images,
+	})
+}
+
+func (h *ImageHandler) errorStatus(c *gin.Context, err error, message string) (int, string) {
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, model.ErrProductNotFound):
+		return http.StatusNotFound, "Product not found"
+	case errors.Is(err, model.ErrImageNotFound):
+		return http.StatusNotFound, "Image not found"
+	case errors.Is(err, model.ErrTooManyImages):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge, err.Error()
+	case errors.Is(err, model.ErrUnsupportedImage):
+		return http.StatusUnsupportedMediaType, err.Error()
+	case errors.Is(err, model.ErrInvalidImage), errors.As(err, &validationErrors):
+		return http.StatusBadRequest, err.Error()
+	}
+
+	h.logger.Errorw(message, "error", err, "product_id", c.Param("id"), "image_id", c.Param("imageId"))
+	return http.StatusInternalServerError, message
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+const importsPath = "/api/v1/products/imports/"
+
+type ImportHandler struct {
+	service       service.ImportService
+	maxUploadSize int64
+	logger        *zap.SugaredLogger
+}
+
+func NewImportHandler(service service.ImportService, maxUploadSize int64, logger *zap.SugaredLogger) *ImportHandler {
+	return &ImportHandler{
+		service:       service,
+		maxUploadSize: maxUploadSize,
+		logger:        logger,
+	}
+}
+
+// CreateImport queues an import of the CSV or NDJSON file uploaded as the "file"
+// field of a multipart form or as the request body. The format is taken from the
+// format parameter, the file extension or the content type; rows are matched on
+// the key parameter, sku by default. With dry_run=true nothing is saved.
+func (h *ImportHandler) CreateImport(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadSize)
+
+	payload, format, err := h.readUpload(c)
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, model.ImportJobResponse{
+			Success: false,
+			Error:   "Invalid upload: " + err.Error(),
+		})
+		return
+	}
+
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, model.ImportJobResponse{
+				Success: false,
+				Error:   "dry_run must be true or false",
+			})
+			return
+		}
+	}
+
+	job, err := h.service.Submit(&model.ImportRequest{
+		Format:    format,
+		Key:       c.DefaultQuery("key", model.ImportKeySKU),
+		DryRun:    dryRun,
+		CreatedBy: c.GetHeader(HeaderUserID),
+	}, payload)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidImport) {
+			c.JSON(http.StatusBadRequest, model.ImportJobResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		h.logger.Errorw("Failed to create import", "error", err)
+		c.JSON(http.StatusInternalServerError, model.ImportJobResponse{
+			Success: false,
+			Error:   "Failed to create import",
+		})
+		return
+	}
+
+	c.Header("Location", importsPath+job.ID)
+	c.JSON(http.StatusAccepted, model.ImportJobResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// readUpload returns the uploaded file and its format
+func (h *ImportHandler) readUpload(c *gin.Context) ([]byte, string, error) {
+	format := strings.ToLower(c.Query("format"))
+
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		if format == "" {
+			format = formatOf(strings.ToLower(filepath.Ext(header.Filename)), header.Header.Get("Content-Type"))
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+
+		payload, err := io.ReadAll(file)
+		return payload, format, err
+	}
+
+	if format == "" {
+		format = formatOf("", mediaType)
+	}
+	payload, err := io.ReadAll(c.Request.Body)
+	return payload, format, err
+}
+
+// formatOf infers an import format from a file extension or content type
+func formatOf(extension, contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case extension == ".csv" || mediaType == "text/csv":
+		return model.ImportFormatCSV
+	case extension == ".ndjson" || extension == ".jsonl" ||
+		mediaType == "application/x-ndjson" || mediaType == "application/ndjson":
+		return model.ImportFormatNDJSON
+	default:
+		return ""
+	}
+}
+
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	job, ok := h.getImport(c)
+	if !ok {
+		return
+	}
+
+	if job.Failed > 0 {
+		job.ErrorReport = importsPath + job.ID + "/errors"
+	}
+	c.JSON(http.StatusOK, model.ImportJobResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// GetErrorReport downloads the row errors of an import as CSV
+func (h *ImportHandler) GetErrorReport(c *gin.Context) {
+	job, ok := h.getImport(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%s-errors.csv"`, job.ID))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"row", "field", "message"})
+	for _, rowError := range job.Errors {
+		writer.Write([]string{strconv.Itoa(rowError.Row), rowError.Field, rowError.Message})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		h.logger.Warnw("Failed to write import error report", "error", err, "import_id", job.ID)
+	}
+}
+
+func (h *ImportHandler) getImport(c *gin.Context) (*model.ImportJob, bool) {
+	job, err := h.service.GetImport(c.Param("id"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to get import"
+		switch {
+		case errors.Is(err, model.ErrImportNotFound):
+			status, message = http.StatusNotFound, "Import not found"
+		case errors.Is(err, model.ErrInvalidID):
+			status, message = http.StatusBadRequest, "Import ID is required"
+		default:
+			h.logger.Errorw("Failed to get import", "error", err, "import_id", c.Param("id"))
+		}
+
+		c.JSON(status, model.ImportJobResponse{
+			Success: false,
+			Error:   message,
+		})
+		return nil, false
+	}
+	return job, true
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+// parseProductFilter reads the filter parameters shared by listing and search:
+// category (comma separated IDs or slugs), include_descendants, min_price, max_price, in_stock, created_after and
+// created_before (RFC 3339 or YYYY-MM-DD). Prices are decimals in the currency parameter, else in defaultCurrency.
+// Parameters prefixed with attr. filter on attribute values, e.g. attr.screen_size>=50 or attr.material=cotton,linen.
+// Admins may also filter on status (comma separated lifecycle statuses); everyone else only sees active products.
+func parseProductFilter(c *gin.Context, defaultCurrency string) (model.ProductFilter, error) {
+	filter := model.ProductFilter{Currency: defaultCurrency}
+	var err error
+
+	if currency := displayCurrency(c); currency != "" {
+		filter.Currency = currency
+	}
+
+	if category := c.Query("category"); category != "" {
+		for _, value := range strings.Split(category, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				filter.Categories = append(filter.Categories, value)
+			}
+		}
+	}
+
+	if value := c.Query("include_descendants"); value != "" {
+		if filter.IncludeDescendants, err = strconv.ParseBool(value); err != nil {
+			return filter, fmt.Errorf("%w: include_descendants must be true or false", model.ErrInvalidQuery)
+		}
+	}
+
+	if filter.MinPrice, err = queryAmount(c, "min_price", filter.Currency); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = queryAmount(c, "max_price", filter.Currency); err != nil {
+		return filter, err
+	}
+
+	if value := c.Query("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("%w: in_stock must be true or false", model.ErrInvalidQuery)
+		}
+		filter.InStock = &inStock
+	}
+
+	if filter.CreatedAfter, err = queryTime(c, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = queryTime(c, "created_before"); err != nil {
+		return filter, err
+	}
+
+	if status := c.Query("status"); status != "" {
+		if !isAdmin(c) {
+			return filter, fmt.Errorf("%w: only admins can filter by status", model.ErrInvalidQuery)
+		}
+		for _, value := range strings.Split(status, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				filter.Statuses = append(filter.Statuses, value)
+			}
+		}
+	}
+
+	if filter.Attributes, err = queryAttributeFilters(c); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// attributeFilterPrefix marks the query parameters that filter on attributes
+const attributeFilterPrefix = "attr."
+
+// queryAttributeFilters reads the attribute filters of the query string. The
+// query string splits an expression at its first "=", so attr.size>=50 arrives
+// as the key attr.size> with the value 50 and attr.size>50 as a key without a
+// value; the expression is put back together before it is parsed.
+func queryAttributeFilters(c *gin.Context) ([]model.AttributeFilter, error) {
+	values := c.Request.URL.Query()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, attributeFilterPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var filters []model.AttributeFilter
+	for _, key := range keys {
+		for _, value := range values[key] {
+			expression := strings.TrimPrefix(key, attributeFilterPrefix)
+			if value != "" || !strings.ContainsAny(expression, "<>") {
+				expression += "=" + value
+			}
+
+			filter, err := model.ParseAttributeFilter(expression)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+		}
+	}
+	return filters, nil
+}
+
+// parseListQuery reads the filter, sort, order, page, limit, cursor and currency parameters
+func parseListQuery(c *gin.Context, defaultCurrency string) (*model.ProductListQuery, error) {
+	filter, err := parseProductFilter(c, defaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &model.ProductListQuery{
+		Filter:   filter,
+		Sort:     c.Query("sort"),
+		Order:    strings.ToLower(c.Query("order")),
+		Cursor:   c.Query("cursor"),
+		Currency: displayCurrency(c),
+	}
+
+	if query.Page, err = queryInt(c, "page"); err != nil {
+		return nil, err
+	}
+	if query.Limit, err = queryInt(c, "limit"); err != nil {
+		return nil, err
+	}
+	if query.Facets, err = parseFacetRequest(c); err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// parseFacetRequest reads facets (comma separated facet names) and disjunctive.
+// It returns nil when no facets are requested.
+func parseFacetRequest(c *gin.Context) (*model.FacetRequest, error) {
+	value := c.Query("facets")
+	if value == "" {
+		return nil, nil
+	}
+
+	request := &model.FacetRequest{}
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			request.Fields = append(request.Fields, field)
+		}
+	}
+
+	if disjunctive := c.Query("disjunctive"); disjunctive != "" {
+		var err error
+		if request.Disjunctive, err = strconv.ParseBool(disjunctive); err != nil {
+			return nil, fmt.Errorf("%w: disjunctive must be true or false", model.ErrInvalidQuery)
+		}
+	}
+
+	return request, nil
+}
+
+// setLinkHeader advertises the neighbouring pages (RFC 8288) relative to the request URL
+func setLinkHeader(c *gin.Context, page *model.ProductPage) {
+	var links []string
+	link := func(rel string, set map[string]string) {
+		values := c.Request.URL.Query()
+		values.Del("page")
+		values.Del("cursor")
+		for key, value := range set {
+			values.Set(key, value)
+		}
+		target := url.URL{Path: c.Request.URL.Path, RawQuery: values.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, target.String(), rel))
+	}
+
+	if page.NextCursor != "" {
+		link("next", map[string]string{"cursor": page.NextCursor})
+	}
+	if page.PrevCursor != "" {
+		link("prev", map[string]string{"cursor": page.PrevCursor})
+	}
+	if page.Page > 0 {
+		lastPage := 1
+		if page.Total > 0 {
+			lastPage = (page.Total + page.Limit - 1) / page.Limit
+		}
+		link("first", map[string]string{"page": "1"})
+		link("last", map[string]string{"page": strconv.Itoa(lastPage)})
+	}
+
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+func queryInt(c *gin.Context, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer", model.ErrInvalidQuery, key)
+	}
+	return n, nil
+}
+
+// displayCurrency returns the currency prices were requested in, if any
+func displayCurrency(c *gin.Context) string {
+	return strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+}
+
+// queryAmount parses a decimal price parameter into minor units of currency
+func queryAmount(c *gin.Context, key, currency string) (*int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	price, err := model.ParseMoney(value, currency)
+	if err != nil || price.Amount < 0 {
+		return nil, fmt.Errorf("%w: %s must be a non-negative amount in %s", model.ErrInvalidQuery, key, currency)
+	}
+	return &price.Amount, nil
+}
+
+func queryTime(c *gin.Context, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s must be an RFC 3339 timestamp or a YYYY-MM-DD date", model.ErrInvalidQuery, key)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type PriceHandler struct {
+	service service.PriceService
+	logger  *zap.SugaredLogger
+}
+
+func NewPriceHandler(service service.PriceService, logger *zap.SugaredLogger) *PriceHandler {
+	return &PriceHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetPriceHistory lists the price changes of a product, newest first. Pages
+// continue with before set to the next_before of the previous page.
+func (h *PriceHandler) GetPriceHistory(c *gin.Context) {
+	query := &model.PriceHistoryQuery{}
+	limit, err := queryInt(c, "limit")
+	if err == nil && c.Query("before") != "" {
+		if query.Before, err = strconv.ParseInt(c.Query("before"), 10, 64); err != nil {
+			err = fmt.Errorf("%w: before must be a price change ID", model.ErrInvalidQuery)
+		}
+	}
+	query.Limit = limit
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.PriceHistoryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	changes, nextBefore, err := h.service.GetPriceHistory(c.Param("id"), query)
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to get price history")
+		c.JSON(status, model.PriceHistoryResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PriceHistoryResponse{
+		Success:    true,
+		Data:       changes,
+		NextBefore: nextBefore,
+	})
+}
+
+func (h *PriceHandler) GetPriceSchedules(c *gin.Context) {
+	schedules, err := h.service.GetPriceSchedules(c.Param("id"))
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to get price schedules")
+		c.JSON(status, model.PriceSchedulesResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PriceSchedulesResponse{
+		Success: true,
+		Data:    schedules,
+	})
+}
+
+// SchedulePrice prepares a future price of a product, e.g. for a sale
+func (h *PriceHandler) SchedulePrice(c *gin.Context) {
+	var req model.CreatePriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.PriceScheduleResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	req.CreatedBy = c.GetHeader(HeaderUserID)
+
+	schedule, err := h.service.SchedulePrice(c.Param("id"), &req)
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to schedule price")
+		c.JSON(status, model.PriceScheduleResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+schedule.ID)
+	c.JSON(http.StatusCreated, model.PriceScheduleResponse{
+		Success: true,
+		Data:    schedule,
+	})
+}
+
+// CancelPriceSchedule cancels a pending schedule, or ends an active one and
+// restores the price it replaced
+func (h *PriceHandler) CancelPriceSchedule(c *gin.Context) {
+	schedule, err := h.service.CancelPriceSchedule(c.Param("id"), c.Param("scheduleId"), c.GetHeader(HeaderUserID))
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to cancel price schedule")
+		c.JSON(status, model.PriceScheduleResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PriceScheduleResponse{
+		Success: true,
+		Data:    schedule,
+	})
+}
+
+// errorStatus maps a service error to a status and message, logging unexpected errors
+func (h *PriceHandler) errorStatus(c *gin.Context, err error, fallback string) (int, string) {
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.Is(err, model.ErrProductNotFound):
+		return http.StatusNotFound, "Product not found"
+	case errors.Is(err, model.ErrScheduleNotFound):
+		return http.StatusNotFound, "Price schedule not found"
+	case errors.Is(err, model.ErrScheduleOverlap), errors.Is(err, model.ErrScheduleEnded):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrInvalidSchedule), errors.Is(err, model.ErrInvalidPrice),
+		errors.Is(err, model.ErrUnknownCurrency), errors.Is(err, model.ErrInvalidQuery),
+		errors.Is(err, model.ErrInvalidID), errors.As(err, &validationErrors):
+		return http.StatusBadRequest, err.Error()
+	default:
+		h.logger.Errorw(fallback, "error", err, "product_id", c.Param("id"))
+		return http.StatusInternalServerError, fallback
+	}
+}
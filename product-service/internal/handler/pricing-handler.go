@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type PricingHandler struct {
+	service service.PricingService
+	logger  *zap.SugaredLogger
+}
+
+func NewPricingHandler(service service.PricingService, logger *zap.SugaredLogger) *PricingHandler {
+	return &PricingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Quote prices a list of products with the promotions in effect and the coupons
+// given. Coupons that did not apply are listed with the reason.
+func (h *PricingHandler) Quote(c *gin.Context) {
+	var req model.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.QuoteResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.service.Quote(&req)
+	if err != nil {
+		var validationErrors validator.ValidationErrors
+		status, message := http.StatusInternalServerError, "Failed to quote prices"
+		switch {
+		case errors.Is(err, model.ErrProductNotFound), errors.Is(err, model.ErrVariantNotFound):
+			status, message = http.StatusNotFound, err.Error()
+		case errors.Is(err, model.ErrInvalidQuote), errors.As(err, &validationErrors):
+			status, message = http.StatusBadRequest, err.Error()
+		default:
+			h.logger.Errorw(message, "error", err)
+		}
+		c.JSON(status, model.QuoteResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.QuoteResponse{
+		Success: true,
+		Data:    quote,
+	})
+}
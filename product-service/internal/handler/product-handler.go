@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/leandrowiemesfilho/product-service/internal/model"
 	"github.com/leandrowiemesfilho/product-service/internal/service"
 	"go.uber.org/zap"
@@ -31,9 +35,44 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		})
 		return
 	}
+	req.CreatedBy = c.GetHeader(HeaderUserID)
+
+	// Publishing is admin only, so everyone else can only create drafts
+	if !isAdmin(c) && ((req.Status != "" && req.Status != model.StatusDraft) || req.PublishAt != nil || req.UnpublishAt != nil) {
+		c.JSON(http.StatusForbidden, model.ProductResponse{
+			Success: false,
+			Error:   "Only admins can create products that are not drafts",
+		})
+		return
+	}
 
 	product, err := h.service.CreateProduct(&req)
 	if err != nil {
+		if errors.Is(err, model.ErrCategoryNotFound) {
+			c.JSON(http.StatusBadRequest, model.ProductResponse{
+				Success: false,
+				Error:   "Category not found",
+			})
+			return
+		}
+
+		if errors.Is(err, model.ErrInvalidPrice) || errors.Is(err, model.ErrUnknownCurrency) || errors.Is(err, model.ErrInvalidAttribute) ||
+			errors.Is(err, model.ErrInvalidStatus) || errors.Is(err, model.ErrStatusTransition) || errors.Is(err, model.ErrInvalidSlug) {
+			c.JSON(http.StatusBadRequest, model.ProductResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, model.ErrDuplicateSKU) || errors.Is(err, model.ErrDuplicateExternalID) || errors.Is(err, model.ErrDuplicateSlug) {
+			c.JSON(http.StatusConflict, model.ProductResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		h.logger.Errorw("Failed to create product", "error", err)
 		c.JSON(http.StatusInternalServerError, model.ProductResponse{
 			Success: false,
@@ -42,6 +81,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", productETag(product))
 	c.JSON(http.StatusCreated, model.ProductResponse{
 		Success: true,
 		Data:    product,
@@ -58,8 +98,17 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.GetProduct(id)
+	// Admins also see products that are not published
+	product, err := h.service.GetProduct(id, displayCurrency(c), isAdmin(c))
 	if err != nil {
+		if errors.Is(err, model.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, model.ProductResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		if err.Error() == "product not found" {
 			c.JSON(http.StatusNotFound, model.ProductResponse{
 				Success: false,
@@ -76,6 +125,55 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	etag := productETag(product)
+	c.Header("ETag", etag)
+	if notModified(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ProductResponse{
+		Success: true,
+		Data:    product,
+	})
+}
+
+// GetProductBySlug returns a product by its slug. A former slug of a product is
+// answered with a permanent redirect to its current one.
+func (h *ProductHandler) GetProductBySlug(c *gin.Context) {
+	product, redirected, err := h.service.GetProductBySlug(c.Param("slug"), displayCurrency(c), isAdmin(c))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to get product"
+		switch {
+		case errors.Is(err, model.ErrProductNotFound):
+			status, message = http.StatusNotFound, "Product not found"
+		case errors.Is(err, model.ErrInvalidQuery):
+			status, message = http.StatusBadRequest, err.Error()
+		default:
+			h.logger.Errorw("Failed to get product by slug", "error", err, "slug", c.Param("slug"))
+		}
+
+		c.JSON(status, model.ProductResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	if redirected {
+		location := url.URL{Path: "/api/v1/products/by-slug/" + product.Slug, RawQuery: c.Request.URL.RawQuery}
+		c.Header("Location", location.String())
+		c.JSON(http.StatusMovedPermanently, gin.H{"message": "Product moved", "slug": product.Slug})
+		return
+	}
+
+	etag := productETag(product)
+	c.Header("ETag", etag)
+	if notModified(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, model.ProductResponse{
 		Success: true,
 		Data:    product,
@@ -83,8 +181,25 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 }
 
 func (h *ProductHandler) GetAllProducts(c *gin.Context) {
-	products, err := h.service.GetAllProducts()
+	query, err := parseListQuery(c, h.service.DefaultCurrency())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductsResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	page, err := h.service.GetAllProducts(query)
 	if err != nil {
+		if errors.Is(err, model.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, model.ProductsResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		h.logger.Errorw("Failed to get products", "error", err)
 		c.JSON(http.StatusInternalServerError, model.ProductsResponse{
 			Success: false,
@@ -93,10 +208,75 @@ func (h *ProductHandler) GetAllProducts(c *gin.Context) {
 		return
 	}
 
+	setLinkHeader(c, page)
 	c.JSON(http.StatusOK, model.ProductsResponse{
+		Success:    true,
+		Data:       page.Products,
+		Total:      page.Total,
+		Page:       page.Page,
+		Limit:      page.Limit,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		Facets:     page.Facets,
+	})
+}
+
+func (h *ProductHandler) SearchProducts(c *gin.Context) {
+	filter, err := parseProductFilter(c, h.service.DefaultCurrency())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.SearchResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	query := &model.ProductSearchQuery{
+		Query:    c.Query("q"),
+		Filter:   filter,
+		Sort:     c.Query("sort"),
+		Order:    strings.ToLower(c.Query("order")),
+		Currency: displayCurrency(c),
+	}
+	if query.Page, err = queryInt(c, "page"); err == nil {
+		query.Limit, err = queryInt(c, "limit")
+	}
+	if err == nil {
+		query.Facets, err = parseFacetRequest(c)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.SearchResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	page, err := h.service.SearchProducts(query)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, model.SearchResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		h.logger.Errorw("Failed to search products", "error", err)
+		c.JSON(http.StatusInternalServerError, model.SearchResponse{
+			Success: false,
+			Error:   "Failed to search products",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SearchResponse{
 		Success: true,
-		Data:    products,
-		Total:   len(products),
+		Data:    page.Results,
+		Total:   page.Total,
+		Page:    page.Page,
+		Limit:   page.Limit,
+		Facets:  page.Facets,
 	})
 }
 
@@ -120,30 +300,123 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.UpdateProduct(id, &req)
+	precondition, err := parseIfMatch(c)
 	if err != nil {
-		if err.Error() == "product not found" {
-			c.JSON(http.StatusNotFound, model.ProductResponse{
-				Success: false,
-				Error:   "Product not found",
-			})
-			return
-		}
+		c.JSON(http.StatusBadRequest, model.ProductResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
 
-		h.logger.Errorw("Failed to update product", "error", err, "product_id", id)
-		c.JSON(http.StatusInternalServerError, model.ProductResponse{
+	req.UpdatedBy = c.GetHeader(HeaderUserID)
+
+	product, err := h.service.UpdateProduct(id, &req, precondition)
+	if err != nil {
+		h.respondUpdateError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.Header("ETag", productETag(product))
+	c.JSON(http.StatusOK, model.ProductResponse{
+		Success: true,
+		Data:    product,
+	})
+}
+
+// PatchProduct applies a JSON Merge Patch (application/merge-patch+json, or plain
+// application/json) or a JSON Patch (application/json-patch+json) to a product
+func (h *ProductHandler) PatchProduct(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, model.ProductResponse{
+			Success: false,
+			Error:   "Product ID is required",
+		})
+		return
+	}
+
+	patch := &model.Patch{ContentType: c.ContentType(), UpdatedBy: c.GetHeader(HeaderUserID)}
+	switch patch.ContentType {
+	case model.MergePatchContentType, model.JSONPatchContentType:
+	case "application/json":
+		patch.ContentType = model.MergePatchContentType
+	default:
+		c.Header("Accept-Patch", model.MergePatchContentType+", "+model.JSONPatchContentType)
+		c.JSON(http.StatusUnsupportedMediaType, model.ProductResponse{
+			Success: false,
+			Error:   "Unsupported patch content type",
+		})
+		return
+	}
+
+	precondition, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if patch.Document, err = c.GetRawData(); err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductResponse{
 			Success: false,
-			Error:   "Failed to update product",
+			Error:   "Invalid request body: " + err.Error(),
 		})
 		return
 	}
 
+	product, err := h.service.PatchProduct(id, patch, precondition)
+	if err != nil {
+		h.respondUpdateError(c, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	c.Header("ETag", productETag(product))
 	c.JSON(http.StatusOK, model.ProductResponse{
 		Success: true,
 		Data:    product,
 	})
 }
 
+// respondUpdateError writes the response for a failed update or patch. An invalid
+// resulting product is answered with invalidStatus.
+func (h *ProductHandler) respondUpdateError(c *gin.Context, err error, invalidStatus int) {
+	var validationErrors validator.ValidationErrors
+
+	status := http.StatusInternalServerError
+	message := "Failed to update product"
+	switch {
+	case errors.Is(err, model.ErrProductNotFound):
+		status, message = http.StatusNotFound, "Product not found"
+	case errors.Is(err, model.ErrCategoryNotFound):
+		status, message = http.StatusBadRequest, "Category not found"
+	case errors.Is(err, model.ErrInvalidPatch):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, model.ErrStockReadOnly):
+		status, message = http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, model.ErrPatchConflict), errors.Is(err, model.ErrDuplicateSKU), errors.Is(err, model.ErrDuplicateExternalID),
+		errors.Is(err, model.ErrInsufficientStock), errors.Is(err, model.ErrStatusTransition), errors.Is(err, model.ErrDuplicateSlug):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrVersionMismatch):
+		status, message = http.StatusPreconditionFailed, "Product has been modified, fetch it again and retry"
+	case errors.Is(err, model.ErrPreconditionRequired):
+		status, message = http.StatusPreconditionRequired, "If-Match header is required"
+	case errors.Is(err, model.ErrInvalidProduct), errors.Is(err, model.ErrInvalidPrice), errors.Is(err, model.ErrUnknownCurrency),
+		errors.Is(err, model.ErrInvalidAttribute), errors.Is(err, model.ErrInvalidStatus), errors.Is(err, model.ErrInvalidSlug),
+		errors.As(err, &validationErrors):
+		status, message = invalidStatus, err.Error()
+	default:
+		h.logger.Errorw("Failed to update product", "error", err, "product_id", c.Param("id"))
+	}
+
+	c.JSON(status, model.ProductResponse{
+		Success: false,
+		Error:   message,
+	})
+}
+
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -151,13 +424,29 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	err := h.service.DeleteProduct(id)
+	precondition, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.service.DeleteProduct(id, precondition, c.GetHeader(HeaderUserID))
 	if err != nil {
 		if err.Error() == "product not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 			return
 		}
 
+		if errors.Is(err, model.ErrVersionMismatch) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product has been modified, fetch it again and retry"})
+			return
+		}
+
+		if errors.Is(err, model.ErrPreconditionRequired) {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+			return
+		}
+
 		h.logger.Errorw("Failed to delete product", "error", err, "product_id", id)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
@@ -165,3 +454,121 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
+
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	product, err := h.service.RestoreProduct(id)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to restore product"
+		switch {
+		case errors.Is(err, model.ErrProductNotFound):
+			status, message = http.StatusNotFound, "Product not found"
+		case errors.Is(err, model.ErrProductNotDeleted):
+			status, message = http.StatusConflict, "Product is not deleted"
+		default:
+			h.logger.Errorw("Failed to restore product", "error", err, "product_id", id)
+		}
+
+		c.JSON(status, model.ProductResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.Header("ETag", productETag(product))
+	c.JSON(http.StatusOK, model.ProductResponse{
+		Success: true,
+		Data:    product,
+	})
+}
+
+// SetStatus moves a product to another lifecycle status, honouring If-Match
+func (h *ProductHandler) SetStatus(c *gin.Context) {
+	var req model.StatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	precondition, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product, err := h.service.SetStatus(c.Param("id"), &req, precondition)
+	if err != nil {
+		h.respondUpdateError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.Header("ETag", productETag(product))
+	c.JSON(http.StatusOK, model.ProductResponse{
+		Success: true,
+		Data:    product,
+	})
+}
+
+// GetTrash lists deleted products with the listing parameters, sorted by deleted_at by default
+func (h *ProductHandler) GetTrash(c *gin.Context) {
+	h.listProducts(c, h.service.GetTrash, "Failed to get trash")
+}
+
+// GetDrafts lists draft products with the listing parameters
+func (h *ProductHandler) GetDrafts(c *gin.Context) {
+	h.listProducts(c, h.service.GetDrafts, "Failed to get drafts")
+}
+
+// GetScheduled lists scheduled products with the listing parameters, sorted by publish_at by default
+func (h *ProductHandler) GetScheduled(c *gin.Context) {
+	h.listProducts(c, h.service.GetScheduled, "Failed to get scheduled products")
+}
+
+// listProducts answers an admin listing with the page list returns for the listing parameters
+func (h *ProductHandler) listProducts(c *gin.Context, list func(*model.ProductListQuery) (*model.ProductPage, error), message string) {
+	query, err := parseListQuery(c, h.service.DefaultCurrency())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductsResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	page, err := list(query)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, model.ProductsResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		h.logger.Errorw(message, "error", err)
+		c.JSON(http.StatusInternalServerError, model.ProductsResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	setLinkHeader(c, page)
+	c.JSON(http.StatusOK, model.ProductsResponse{
+		Success:    true,
+		Data:       page.Products,
+		Total:      page.Total,
+		Page:       page.Page,
+		Limit:      page.Limit,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	})
+}
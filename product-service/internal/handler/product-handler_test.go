@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// stubProductService implements the calls a test makes; any other call panics
+type stubProductService struct {
+	service.ProductService
+	created *model.CreateProductRequest
+}
+
+func (s *stubProductService) CreateProduct(req *model.CreateProductRequest) (*model.Product, error) {
+	s.created = req
+	status := req.Status
+	if status == "" {
+		status = model.StatusDraft
+	}
+	return &model.Product{ID: "product", Name: req.Name, Status: status, Version: 1}, nil
+}
+
+// GetProduct returns version 3 of a USD product priced in currency when requested
+func (s *stubProductService) GetProduct(id, currency string, unpublished bool) (*model.Product, error) {
+	product := &model.Product{ID: id, Name: "Lamp", Price: model.Money{Amount: 1999, Currency: "USD"}, Status: model.StatusActive, Version: 3}
+	if currency != "" {
+		product.DisplayPrice = &model.DisplayPrice{Money: model.Money{Amount: 1999, Currency: currency}, Converted: currency != "USD"}
+	}
+	return product, nil
+}
+
+func TestGetProductConditionalByCurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/products/:id", NewProductHandler(&stubProductService{}, zap.NewNop().Sugar()).GetProduct)
+
+	tests := []struct {
+		name        string
+		query       string
+		ifNoneMatch string
+		wantStatus  int
+		wantETag    string
+	}{
+		{"no currency", "", "", http.StatusOK, `"3"`},
+		{"currency", "?currency=eur", "", http.StatusOK, `"3-EUR"`},
+		{"same currency not modified", "?currency=EUR", `"3-EUR"`, http.StatusNotModified, `"3-EUR"`},
+		{"same currency weak tag not modified", "?currency=EUR", `W/"3-EUR"`, http.StatusNotModified, `"3-EUR"`},
+		{"other currency is modified", "?currency=GBP", `"3-EUR"`, http.StatusOK, `"3-GBP"`},
+		{"currency after none is modified", "?currency=EUR", `"3"`, http.StatusOK, `"3-EUR"`},
+		{"none after currency is modified", "", `"3-EUR"`, http.StatusOK, `"3"`},
+		{"none not modified", "", `"3"`, http.StatusNotModified, `"3"`},
+		{"older version is modified", "?currency=EUR", `"2-EUR"`, http.StatusOK, `"3-EUR"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/products/product"+tt.query, nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if etag := rec.Header().Get("ETag"); etag != tt.wantETag {
+				t.Errorf("ETag = %s, want %s", etag, tt.wantETag)
+			}
+			if tt.wantStatus == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("304 has a body: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestCreateProductStatusByRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		role       string
+		body       string
+		wantStatus int
+	}{
+		{"user creates a draft by default", "user", `{"name":"Lamp","price":{"amount":1999,"currency":"USD"}}`, http.StatusCreated},
+		{"user creates an explicit draft", "user", `{"name":"Lamp","price":{"amount":1999,"currency":"USD"},"status":"draft"}`, http.StatusCreated},
+		{"user can not create active", "user", `{"name":"Lamp","price":{"amount":1999,"currency":"USD"},"status":"active"}`, http.StatusForbidden},
+		{"user can not create scheduled", "user", `{"name":"Lamp","price":{"amount":1999,"currency":"USD"},"status":"scheduled","publish_at":"2030-01-01T00:00:00Z"}`, http.StatusForbidden},
+		{"user can not schedule a draft", "user", `{"name":"Lamp","price":{"amount":1999,"currency":"USD"},"unpublish_at":"2030-01-01T00:00:00Z"}`, http.StatusForbidden},
+		{"anonymous can not create active", "", `{"name":"Lamp","price":{"amount":1999,"currency":"USD"},"status":"active"}`, http.StatusForbidden},
+		{"admin creates a draft", RoleAdmin, `{"name":"Lamp","price":{"amount":1999,"currency":"USD"}}`, http.StatusCreated},
+		{"admin creates active", RoleAdmin, `{"name":"Lamp","price":{"amount":1999,"currency":"USD"},"status":"active"}`, http.StatusCreated},
+		{"admin creates scheduled", RoleAdmin, `{"name":"Lamp","price":{"amount":1999,"currency":"USD"},"status":"scheduled","publish_at":"2030-01-01T00:00:00Z"}`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProductService{}
+			router := gin.New()
+			router.POST("/products", NewProductHandler(stub, zap.NewNop().Sugar()).CreateProduct)
+
+			req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(HeaderUserID, "user-1")
+			if tt.role != "" {
+				req.Header.Set(HeaderUserRole, tt.role)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if created := stub.created != nil; created != (tt.wantStatus == http.StatusCreated) {
+				t.Errorf("service called = %v, want %v", created, !created)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type PromotionHandler struct {
+	service service.PromotionService
+	logger  *zap.SugaredLogger
+}
+
+func NewPromotionHandler(service service.PromotionService, logger *zap.SugaredLogger) *PromotionHandler {
+	return &PromotionHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *PromotionHandler) GetPromotions(c *gin.Context) {
+	promotions, err := h.service.GetPromotions()
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to get promotions")
+		c.JSON(status, model.PromotionsResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PromotionsResponse{
+		Success: true,
+		Data:    promotions,
+	})
+}
+
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	promotion, err := h.service.GetPromotion(c.Param("id"))
+	h.respond(c, http.StatusOK, promotion, err, "Failed to get promotion")
+}
+
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req model.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.invalidBody(c, err)
+		return
+	}
+	req.CreatedBy = c.GetHeader(HeaderUserID)
+
+	promotion, err := h.service.CreatePromotion(&req)
+	if err == nil {
+		c.Header("Location", c.Request.URL.Path+"/"+promotion.ID)
+	}
+	h.respond(c, http.StatusCreated, promotion, err, "Failed to create promotion")
+}
+
+// UpdatePromotion replaces every field of a promotion. Its usage count is kept.
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	var req model.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.invalidBody(c, err)
+		return
+	}
+
+	promotion, err := h.service.UpdatePromotion(c.Param("id"), &req)
+	h.respond(c, http.StatusOK, promotion, err, "Failed to update promotion")
+}
+
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	if err := h.service.DeletePromotion(c.Param("id")); err != nil {
+		status, message := h.errorStatus(c, err, "Failed to delete promotion")
+		c.JSON(status, model.PromotionResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted successfully"})
+}
+
+// RedeemCoupon uses a coupon up for an order, once per reference_id
+func (h *PromotionHandler) RedeemCoupon(c *gin.Context) {
+	var req model.RedeemCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.invalidBody(c, err)
+		return
+	}
+	req.RedeemedBy = c.GetHeader(HeaderUserID)
+
+	promotion, err := h.service.RedeemCoupon(&req)
+	h.respond(c, http.StatusOK, promotion, err, "Failed to redeem coupon")
+}
+
+func (h *PromotionHandler) invalidBody(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, model.PromotionResponse{
+		Success: false,
+		Error:   "Invalid request body: " + err.Error(),
+	})
+}
+
+func (h *PromotionHandler) respond(c *gin.Context, status int, promotion *model.Promotion, err error, fallback string) {
+	if err != nil {
+		status, message := h.errorStatus(c, err, fallback)
+		c.JSON(status, model.PromotionResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(status, model.PromotionResponse{
+		Success: true,
+		Data:    promotion,
+	})
+}
+
+// errorStatus maps a service error to a status and message, logging unexpected errors
+func (h *PromotionHandler) errorStatus(c *gin.Context, err error, fallback string) (int, string) {
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.Is(err, model.ErrPromotionNotFound):
+		return http.StatusNotFound, "Promotion not found"
+	case errors.Is(err, model.ErrCouponNotFound):
+		return http.StatusNotFound, "Coupon not found"
+	case errors.Is(err, model.ErrDuplicateCoupon), errors.Is(err, model.ErrCouponUnavailable),
+		errors.Is(err, model.ErrCouponExhausted):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrProductNotFound), errors.Is(err, model.ErrCategoryNotFound),
+		errors.Is(err, model.ErrInvalidPromotion), errors.Is(err, model.ErrInvalidPrice),
+		errors.Is(err, model.ErrUnknownCurrency), errors.As(err, &validationErrors):
+		return http.StatusBadRequest, err.Error()
+	default:
+		h.logger.Errorw(fallback, "error", err, "promotion_id", c.Param("id"))
+		return http.StatusInternalServerError, fallback
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type ReservationHandler struct {
+	service service.ReservationService
+	logger  *zap.SugaredLogger
+}
+
+func NewReservationHandler(service service.ReservationService, logger *zap.SugaredLogger) *ReservationHandler {
+	return &ReservationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateReservation holds stock of every requested item for a checkout, or of
+// none of them
+func (h *ReservationHandler) CreateReservation(c *gin.Context) {
+	var req model.CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ReservationResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	req.CreatedBy = c.GetHeader(HeaderUserID)
+
+	reservation, err := h.service.CreateReservation(&req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create reservation")
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+reservation.ID)
+	c.JSON(http.StatusCreated, model.ReservationResponse{
+		Success: true,
+		Data:    reservation,
+	})
+}
+
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	reservation, err := h.service.GetReservation(c.Param("id"), c.GetHeader(HeaderUserID), isAdmin(c))
+	h.respond(c, reservation, err, "Failed to get reservation")
+}
+
+// CommitReservation turns the reserved stock into sales
+func (h *ReservationHandler) CommitReservation(c *gin.Context) {
+	reservation, err := h.service.CommitReservation(c.Param("id"), c.GetHeader(HeaderUserID), isAdmin(c))
+	h.respond(c, reservation, err, "Failed to commit reservation")
+}
+
+// CancelReservation releases the reserved stock
+func (h *ReservationHandler) CancelReservation(c *gin.Context) {
+	reservation, err := h.service.CancelReservation(c.Param("id"), c.GetHeader(HeaderUserID), isAdmin(c))
+	h.respond(c, reservation, err, "Failed to cancel reservation")
+}
+
+func (h *ReservationHandler) respond(c *gin.Context, reservation *model.Reservation, err error, fallback string) {
+	if err != nil {
+		h.respondError(c, err, fallback)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ReservationResponse{
+		Success: true,
+		Data:    reservation,
+	})
+}
+
+func (h *ReservationHandler) respondError(c *gin.Context, err error, fallback string) {
+	status, message := h.errorStatus(c, err, fallback)
+	c.JSON(status, model.ReservationResponse{
+		Success: false,
+		Error:   message,
+	})
+}
+
+// errorStatus maps a service error to a status and message, logging unexpected errors
+func (h *ReservationHandler) errorStatus(c *gin.Context, err error, fallback string) (int, string) {
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.Is(err, model.ErrReservationNotFound):
+		return http.StatusNotFound, "Reservation not found"
+	case errors.Is(err, model.ErrProductNotFound), errors.Is(err, model.ErrVariantNotFound):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, model.ErrInsufficientStock), errors.Is(err, model.ErrReservationNotActive):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrReservationExpired):
+		return http.StatusGone, err.Error()
+	case errors.Is(err, model.ErrVariantRequired), errors.Is(err, model.ErrInvalidReservation),
+		errors.Is(err, model.ErrInvalidID), errors.As(err, &validationErrors):
+		return http.StatusBadRequest, err.Error()
+	default:
+		h.logger.Errorw(fallback, "error", err, "reservation_id", c.Param("id"))
+		return http.StatusInternalServerError, fallback
+	}
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type StockHandler struct {
+	service service.StockService
+	logger  *zap.SugaredLogger
+}
+
+func NewStockHandler(service service.StockService, logger *zap.SugaredLogger) *StockHandler {
+	return &StockHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *StockHandler) AdjustStock(c *gin.Context) {
+	var req model.StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.StockMovementResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	req.ActorID = actorID(c)
+
+	movement, err := h.service.AdjustStock(c.Param("id"), &req)
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to adjust stock")
+		c.JSON(status, model.StockMovementResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.StockMovementResponse{
+		Success: true,
+		Data:    movement,
+	})
+}
+
+// GetStockHistory lists the stock movements of a product, newest first. Pages
+// continue with before set to the next_before of the previous page.
+func (h *StockHandler) GetStockHistory(c *gin.Context) {
+	query := &model.StockMovementQuery{VariantID: c.Query("variant_id")}
+	limit, err := queryInt(c, "limit")
+	if err == nil && c.Query("before") != "" {
+		if query.Before, err = strconv.ParseInt(c.Query("before"), 10, 64); err != nil {
+			err = fmt.Errorf("%w: before must be a movement ID", model.ErrInvalidQuery)
+		}
+	}
+	query.Limit = limit
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.StockHistoryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	history, err := h.service.GetStockHistory(c.Param("id"), query)
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to get stock history")
+		c.JSON(status, model.StockHistoryResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.StockHistoryResponse{
+		Success:     true,
+		Data:        history.Movements,
+		Stock:       history.Stock,
+		LedgerStock: history.LedgerStock,
+		NextBefore:  history.NextBefore,
+	})
+}
+
+// errorStatus maps a service error to a status and message, logging unexpected errors
+func (h *StockHandler) errorStatus(c *gin.Context, err error, fallback string) (int, string) {
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.Is(err, model.ErrProductNotFound):
+		return http.StatusNotFound, "Product not found"
+	case errors.Is(err, model.ErrVariantNotFound):
+		return http.StatusNotFound, "Variant not found"
+	case errors.Is(err, model.ErrInsufficientStock):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrVariantRequired), errors.Is(err, model.ErrInvalidStock),
+		errors.Is(err, model.ErrInvalidQuery), errors.Is(err, model.ErrInvalidID),
+		errors.As(err, &validationErrors):
+		return http.StatusBadRequest, err.Error()
+	default:
+		h.logger.Errorw(fallback, "error", err, "product_id", c.Param("id"))
+		return http.StatusInternalServerError, fallback
+	}
+}
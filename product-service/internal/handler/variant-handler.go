@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type VariantHandler struct {
+	service service.VariantService
+	logger  *zap.SugaredLogger
+}
+
+func NewVariantHandler(service service.VariantService, logger *zap.SugaredLogger) *VariantHandler {
+	return &VariantHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *VariantHandler) SetVariantOptions(c *gin.Context) {
+	var req model.SetVariantOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ProductResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	product, err := h.service.SetVariantOptions(c.Param("id"), &req)
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to set variant options")
+		c.JSON(status, model.ProductResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ProductResponse{
+		Success: true,
+		Data:    product,
+	})
+}
+
+func (h *VariantHandler) GetVariants(c *gin.Context) {
+	variants, err := h.service.GetVariants(c.Param("id"))
+	if err != nil {
+		status, message := h.errorStatus(c, err, "Failed to get variants")
+		c.JSON(status, model.VariantsResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.VariantsResponse{
+		Success: true,
+		Data:    variants,
+	})
+}
+
+func (h *VariantHandler) GetVariant(c *gin.Context) {
+	variant, err := h.service.GetVariant(c.Param("id"), c.Param("variantId"))
+	h.respond(c, http.StatusOK, variant, err, "Failed to get variant")
+}
+
+func (h *VariantHandler) CreateVariant(c *gin.Context) {
+	var req model.VariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.VariantResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	req.ActorID = actorID(c)
+
+	variant, err := h.service.CreateVariant(c.Param("id"), &req)
+	h.respond(c, http.StatusCreated, variant, err, "Failed to create variant")
+}
+
+func (h *VariantHandler) UpdateVariant(c *gin.Context) {
+	var req model.VariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.VariantResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	variant, err := h.service.UpdateVariant(c.Param("id"), c.Param("variantId"), &req)
+	h.respond(c, http.StatusOK, variant, err, "Failed to update variant")
+}
+
+func (h *VariantHandler) DeleteVariant(c *gin.Context) {
+	if err := h.service.DeleteVariant(c.Param("id"), c.Param("variantId"), actorID(c)); err != nil {
+		status, message := h.errorStatus(c, err, "Failed to delete variant")
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Variant deleted successfully"})
+}
+
+func (h *VariantHandler) respond(c *gin.Context, status int, variant *model.ProductVariant, err error, message string) {
+	if err != nil {
+		status, message = h.errorStatus(c, err, message)
+		c.JSON(status, model.VariantResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(status, model.VariantResponse{
+		Success: true,
+		Data:    variant,
+	})
+}
+
+func (h *VariantHandler) errorStatus(c *gin.Context, err error, message string) (int, string) {
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, model.ErrProductNotFound):
+		return http.StatusNotFound, "Product not found"
+	case errors.Is(err, model.ErrVariantNotFound):
+		return http.StatusNotFound, "Variant not found"
+	case errors.Is(err, model.ErrDuplicateSKU), errors.Is(err, model.ErrDuplicateBarcode),
+		errors.Is(err, model.ErrDuplicateVariant), errors.Is(err, model.ErrVariantOptionsInUse),
+		errors.Is(err, model.ErrInsufficientStock):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrStockReadOnly):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, model.ErrInvalidVariantOption), errors.Is(err, model.ErrInvalidPrice),
+		errors.Is(err, model.ErrUnknownCurrency), errors.As(err, &validationErrors):
+		return http.StatusBadRequest, err.Error()
+	}
+
+	h.logger.Errorw(message, "error", err, "product_id", c.Param("id"), "variant_id", c.Param("variantId"))
+	return http.StatusInternalServerError, message
+}
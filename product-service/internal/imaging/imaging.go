@@ -0,0 +1,134 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooManyPixels     = errors.New("image has too many pixels")
+)
+
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeGIF  = "image/gif"
+	TypeWebP = "image/webp"
+)
+
+// extensions of the formats uploads may be in
+var extensions = map[string]string{
+	TypeJPEG: "jpg",
+	TypePNG:  "png",
+	TypeGIF:  "gif",
+}
+
+// Source is a decoded upload. Its content type is sniffed from the data, not
+// taken from the client.
+type Source struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	image       image.Image
+}
+
+// Rendition is a thumbnail of a source fitting a Size by Size box
+type Rendition struct {
+	Size        int
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Decode checks data is a JPEG, PNG or GIF of at most maxPixels pixels and
+// decodes it. GIFs are reduced to their first frame. Dimensions are checked
+// before decoding so small files can not expand into huge images.
+func Decode(data []byte, maxPixels int) (*Source, error) {
+	contentType := http.DetectContentType(data)
+	extension, ok := extensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s, expected JPEG, PNG or GIF", ErrUnsupportedFormat, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrTooManyPixels, config.Width, config.Height, maxPixels)
+	}
+
+	var img image.Image
+	switch contentType {
+	case TypeJPEG:
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case TypePNG:
+		img, err = png.Decode(bytes.NewReader(data))
+	case TypeGIF:
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	return &Source{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+		image:       img,
+	}, nil
+}
+
+// Thumbnails renders the source at every size smaller than it, both in its own
+// family of formats, JPEG for photos and PNG for the rest, and in WebP
+func (s *Source) Thumbnails(sizes []int, jpegQuality int) ([]*Rendition, error) {
+	var renditions []*Rendition
+	for _, size := range sizes {
+		if size >= s.Width && size >= s.Height {
+			continue
+		}
+
+		width, height := Fit(s.Width, s.Height, size)
+		thumbnail := Resize(s.image, width, height)
+
+		var buf bytes.Buffer
+		rendition := &Rendition{Size: size, Width: width, Height: height}
+		if s.ContentType == TypeJPEG {
+			rendition.ContentType, rendition.Extension = TypeJPEG, "jpg"
+			if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: jpegQuality}); err != nil {
+				return nil, err
+			}
+		} else {
+			rendition.ContentType, rendition.Extension = TypePNG, "png"
+			if err := png.Encode(&buf, thumbnail); err != nil {
+				return nil, err
+			}
+		}
+		rendition.Data = buf.Bytes()
+
+		var webp bytes.Buffer
+		if err := EncodeWebP(&webp, thumbnail); err != nil {
+			return nil, err
+		}
+		renditions = append(renditions, rendition, &Rendition{
+			Size:        size,
+			ContentType: TypeWebP,
+			Extension:   "webp",
+			Width:       width,
+			Height:      height,
+			Data:        webp.Bytes(),
+		})
+	}
+	return renditions, nil
+}
@@ -0,0 +1,130 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Fit returns the size of a width by height image scaled down to fit a box of
+// size by size, keeping its aspect ratio. Images already within the box keep
+// their size.
+func Fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, (height*size+width/2)/width)
+	}
+	return max(1, (width*size+height/2)/height), size
+}
+
+// Resize scales img down to width by height by averaging the source pixels
+// each destination pixel covers, which avoids the aliasing of sampling when
+// making thumbnails. Alpha is premultiplied while averaging.
+func Resize(img image.Image, width, height int) *image.NRGBA {
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	// Source range and weights of every destination column, in 1/width units
+	xSpans := spans(srcWidth, width)
+	ySpans := spans(srcHeight, height)
+
+	row := make([]float64, srcWidth*4)
+	for y, ySpan := range ySpans {
+		for i := range row {
+			row[i] = 0
+		}
+		for sy := ySpan.start; sy < ySpan.end; sy++ {
+			weight := ySpan.weight(sy)
+			offset := sy * src.Stride
+			for sx := 0; sx < srcWidth; sx++ {
+				p := src.Pix[offset+sx*4 : offset+sx*4+4]
+				alpha := float64(p[3]) * weight
+				row[sx*4] += float64(p[0]) * alpha
+				row[sx*4+1] += float64(p[1]) * alpha
+				row[sx*4+2] += float64(p[2]) * alpha
+				row[sx*4+3] += alpha
+			}
+		}
+
+		for x, xSpan := range xSpans {
+			var r, g, b, a, total float64
+			for sx := xSpan.start; sx < xSpan.end; sx++ {
+				weight := xSpan.weight(sx)
+				r += row[sx*4] * weight
+				g += row[sx*4+1] * weight
+				b += row[sx*4+2] * weight
+				a += row[sx*4+3] * weight
+			}
+			total = xSpan.total * ySpan.total
+
+			d := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4]
+			if a > 0 {
+				d[0] = clamp(r / a)
+				d[1] = clamp(g / a)
+				d[2] = clamp(b / a)
+			}
+			d[3] = clamp(a / total)
+		}
+	}
+	return dst
+}
+
+// span is the range of source pixels one destination pixel covers. The first
+// and last source pixels may be covered in part.
+type span struct {
+	start, end  int
+	first, last float64
+	total       float64
+}
+
+func (s span) weight(i int) float64 {
+	switch i {
+	case s.start:
+		return s.first
+	case s.end - 1:
+		return s.last
+	default:
+		return 1
+	}
+}
+
+func spans(srcSize, dstSize int) []span {
+	scale := float64(srcSize) / float64(dstSize)
+	result := make([]span, dstSize)
+	for i := range result {
+		from, to := float64(i)*scale, float64(i+1)*scale
+		start, end := int(from), int(to)
+		if float64(end) < to {
+			end++
+		}
+		end = min(end, srcSize)
+
+		s := span{start: start, end: end, first: 1, last: 1}
+		if end-start == 1 {
+			s.first = to - from
+			s.last = s.first
+		} else {
+			s.first = float64(start+1) - from
+			s.last = to - float64(end-1)
+		}
+		s.total = to - from
+		result[i] = s
+	}
+	return result
+}
+
+func clamp(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	default:
+		return uint8(v + 0.5)
+	}
+}
@@ -0,0 +1,439 @@
+package imaging
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// The encoder writes lossless WebP (VP8L): the subtract green and gradient
+// predictor transforms followed by Huffman coded residuals. It does no LZ77
+// matching, which keeps it small at the cost of some compression.
+
+const (
+	maxWebPDimension = 1 << 14
+
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+
+	// Block size of the predictor transform is 1 << predictorBits
+	predictorBits = 9
+	// predictorGradient predicts every channel as clamp(left + top - top-left)
+	predictorGradient = 12
+
+	maxCodeLength           = 15
+	maxCodeLengthCodeLength = 7
+	numCodeLengthCodes      = 19
+	numLiteralCodes         = 256
+	numLengthCodes          = 24
+	numDistanceCodes        = 40
+)
+
+// codeLengthCodeOrder is the order code length code lengths are written in
+var codeLengthCodeOrder = [numCodeLengthCodes]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP writes img as a lossless WebP
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > maxWebPDimension || height > maxWebPDimension {
+		return errors.New("webp: image dimensions out of range")
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	argb := make([]uint32, width*height)
+	opaque := true
+	for i := range argb {
+		p := nrgba.Pix[i*4 : i*4+4]
+		argb[i] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		opaque = opaque && p[3] == 0xff
+	}
+
+	bw := &bitWriter{}
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if opaque {
+		bw.write(0, 1)
+	} else {
+		bw.write(1, 1)
+	}
+	bw.write(0, 3)
+
+	subtractGreen(argb)
+	bw.write(1, 1)
+	bw.write(transformSubtractGreen, 2)
+
+	residuals := predictGradient(argb, width, height)
+	bw.write(1, 1)
+	bw.write(transformPredictor, 2)
+	bw.write(predictorBits-2, 3)
+	blocks := make([]uint32, subSampleSize(width)*subSampleSize(height))
+	for i := range blocks {
+		blocks[i] = 0xff000000 | predictorGradient<<8
+	}
+	// The block modes are an image of their own, without a color cache
+	bw.write(0, 1)
+	writeImageData(bw, blocks)
+
+	// End of transforms, no color cache and no meta prefix codes
+	bw.write(0, 1)
+	bw.write(0, 1)
+	bw.write(0, 1)
+	writeImageData(bw, residuals)
+
+	data := bw.bytes()
+	padding := len(data) & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(data)+padding))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+func subSampleSize(size int) int {
+	return (size + 1<<predictorBits - 1) >> predictorBits
+}
+
+// subtractGreen subtracts the green channel from the red and blue channels
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		green := p >> 8 & 0xff
+		red := (p>>16 - green) & 0xff
+		blue := (p - green) & 0xff
+		argb[i] = p&0xff00ff00 | red<<16 | blue
+	}
+}
+
+// predictGradient returns the residuals of the gradient predictor. The first
+// pixel is predicted as opaque black, the rest of the first row from the left
+// and the first column from the top, as the decoder does.
+func predictGradient(argb []uint32, width, height int) []uint32 {
+	residuals := make([]uint32, len(argb))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			var prediction uint32
+			switch {
+			case x == 0 && y == 0:
+				prediction = 0xff000000
+			case y == 0:
+				prediction = argb[i-1]
+			case x == 0:
+				prediction = argb[i-width]
+			default:
+				prediction = clampAddSubtractFull(argb[i-1], argb[i-width], argb[i-width-1])
+			}
+			residuals[i] = subtractPixels(argb[i], prediction)
+		}
+	}
+	return residuals
+}
+
+func clampAddSubtractFull(a, b, c uint32) uint32 {
+	var result uint32
+	for shift := 0; shift < 32; shift += 8 {
+		v := int32(a>>shift&0xff) + int32(b>>shift&0xff) - int32(c>>shift&0xff)
+		if v < 0 {
+			v = 0
+		} else if v > 0xff {
+			v = 0xff
+		}
+		result |= uint32(v) << shift
+	}
+	return result
+}
+
+// subtractPixels subtracts b from a channel by channel, modulo 256
+func subtractPixels(a, b uint32) uint32 {
+	alphaGreen := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	redBlue := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return alphaGreen&0xff00ff00 | redBlue&0x00ff00ff
+}
+
+// writeImageData writes the prefix codes of pixels and the pixels coded with them
+func writeImageData(bw *bitWriter, pixels []uint32) {
+	histograms := [4][]uint32{
+		make([]uint32, numLiteralCodes+numLengthCodes),
+		make([]uint32, numLiteralCodes),
+		make([]uint32, numLiteralCodes),
+		make([]uint32, numLiteralCodes),
+	}
+	for _, p := range pixels {
+		histograms[0][p>>8&0xff]++
+		histograms[1][p>>16&0xff]++
+		histograms[2][p&0xff]++
+		histograms[3][p>>24]++
+	}
+
+	var codes [4]*prefixCode
+	for i, histogram := range histograms {
+		codes[i] = writePrefixCode(bw, histogram)
+	}
+	// Distances are never used; a single symbol takes no bits
+	writePrefixCode(bw, make([]uint32, numDistanceCodes))
+
+	for _, p := range pixels {
+		codes[0].write(bw, p>>8&0xff)
+		codes[1].write(bw, p>>16&0xff)
+		codes[2].write(bw, p&0xff)
+		codes[3].write(bw, p>>24)
+	}
+}
+
+// prefixCode holds the bit reversed canonical code of every symbol
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+}
+
+func (c *prefixCode) write(bw *bitWriter, symbol uint32) {
+	bw.write(uint32(c.codes[symbol]), uint(c.lengths[symbol]))
+}
+
+// writePrefixCode writes the code of histogram and returns it. Codes of up to
+// two symbols below 256 are written in the simple form.
+func writePrefixCode(bw *bitWriter, histogram []uint32) *prefixCode {
+	var symbols []uint32
+	for symbol, count := range histogram {
+		if count > 0 {
+			symbols = append(symbols, uint32(symbol))
+		}
+	}
+	if len(symbols) == 0 {
+		symbols = []uint32{0}
+	}
+
+	if len(symbols) <= 2 && symbols[len(symbols)-1] < 256 {
+		bw.write(1, 1)
+		bw.write(uint32(len(symbols)-1), 1)
+		if symbols[0] < 2 {
+			bw.write(0, 1)
+			bw.write(symbols[0], 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(symbols[0], 8)
+		}
+		if len(symbols) == 2 {
+			bw.write(symbols[1], 8)
+		}
+
+		code := &prefixCode{lengths: make([]uint8, len(histogram)), codes: make([]uint16, len(histogram))}
+		if len(symbols) == 2 {
+			code.lengths[symbols[0]], code.lengths[symbols[1]] = 1, 1
+			code.codes[symbols[1]] = 1
+		}
+		return code
+	}
+
+	lengths := huffmanLengths(histogram, maxCodeLength)
+
+	lengthHistogram := make([]uint32, numCodeLengthCodes)
+	for _, length := range lengths {
+		lengthHistogram[length]++
+	}
+	lengthCode := canonicalCode(huffmanLengths(lengthHistogram, maxCodeLengthCodeLength))
+
+	count := 4
+	for i := numCodeLengthCodes - 1; i >= 4; i-- {
+		if lengthCode.lengths[codeLengthCodeOrder[i]] > 0 {
+			count = i + 1
+			break
+		}
+	}
+	bw.write(0, 1)
+	bw.write(uint32(count-4), 4)
+	for _, symbol := range codeLengthCodeOrder[:count] {
+		bw.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+
+	// Every code length is written, so max_symbol is not used
+	bw.write(0, 1)
+	single := countNonZero(lengthCode.lengths) == 1
+	for _, length := range lengths {
+		if !single {
+			lengthCode.write(bw, uint32(length))
+		}
+	}
+
+	return canonicalCode(lengths)
+}
+
+func countNonZero(lengths []uint8) int {
+	n := 0
+	for _, length := range lengths {
+		if length > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// canonicalCode assigns canonical codes to lengths, bit reversed for the LSB
+// first bit stream
+func canonicalCode(lengths []uint8) *prefixCode {
+	var lengthCounts [maxCodeLength + 1]uint16
+	for _, length := range lengths {
+		if length > 0 {
+			lengthCounts[length]++
+		}
+	}
+
+	var next [maxCodeLength + 1]uint16
+	code := uint16(0)
+	for length := 1; length <= maxCodeLength; length++ {
+		next[length] = code
+		code = (code + lengthCounts[length]) << 1
+	}
+
+	codes := make([]uint16, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		codes[symbol] = reverseBits(next[length], length)
+		next[length]++
+	}
+	return &prefixCode{lengths: lengths, codes: codes}
+}
+
+func reverseBits(code uint16, length uint8) uint16 {
+	var reversed uint16
+	for i := uint8(0); i < length; i++ {
+		reversed = reversed<<1 | code&1
+		code >>= 1
+	}
+	return reversed
+}
+
+// huffmanLengths returns Huffman code lengths of at most limit bits for
+// histogram. A lone symbol gets length 1. Rare symbols are made more frequent
+// until the lengths fit the limit.
+func huffmanLengths(histogram []uint32, limit int) []uint8 {
+	counts := make([]uint32, len(histogram))
+	copy(counts, histogram)
+
+	for minCount := uint32(1); ; minCount *= 2 {
+		lengths, maxLength := buildHuffman(counts)
+		if maxLength <= limit {
+			return lengths
+		}
+		for i, count := range counts {
+			if count > 0 && count < minCount {
+				counts[i] = minCount
+			}
+		}
+	}
+}
+
+type huffmanNode struct {
+	count       uint32
+	symbol      int
+	left, right *huffmanNode
+}
+
+type huffmanHeap []*huffmanNode
+
+func (h huffmanHeap) Len() int { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+	return h[i].symbol < h[j].symbol
+}
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	node := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return node
+}
+
+func buildHuffman(counts []uint32) ([]uint8, int) {
+	lengths := make([]uint8, len(counts))
+	nodes := &huffmanHeap{}
+	for symbol, count := range counts {
+		if count > 0 {
+			*nodes = append(*nodes, &huffmanNode{count: count, symbol: symbol})
+		}
+	}
+	switch nodes.Len() {
+	case 0:
+		return lengths, 0
+	case 1:
+		lengths[(*nodes)[0].symbol] = 1
+		return lengths, 1
+	}
+
+	heap.Init(nodes)
+	for nodes.Len() > 1 {
+		left := heap.Pop(nodes).(*huffmanNode)
+		right := heap.Pop(nodes).(*huffmanNode)
+		symbol := left.symbol
+		if right.symbol < symbol {
+			symbol = right.symbol
+		}
+		heap.Push(nodes, &huffmanNode{count: left.count + right.count, symbol: symbol, left: left, right: right})
+	}
+
+	maxLength := 0
+	type entry struct {
+		node  *huffmanNode
+		depth int
+	}
+	stack := []entry{{heap.Pop(nodes).(*huffmanNode), 0}}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.node.left == nil {
+			lengths[e.node.symbol] = uint8(min(e.depth, 255))
+			maxLength = max(maxLength, e.depth)
+			continue
+		}
+		stack = append(stack, entry{e.node.left, e.depth + 1}, entry{e.node.right, e.depth + 1})
+	}
+	return lengths, maxLength
+}
+
+// bitWriter packs bits LSB first
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (w *bitWriter) write(bits uint32, n uint) {
+	w.acc |= uint64(bits) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
@@ -0,0 +1,113 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name          string
+		width, height int
+		pixel         func(x, y int) color.NRGBA
+	}{
+		{"single pixel", 1, 1, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: 200, G: 100, B: 50, A: 255}
+		}},
+		{"uniform", 16, 16, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: 10, G: 20, B: 30, A: 255}
+		}},
+		{"opaque gradient", 64, 48, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), B: uint8(x + y), A: 255}
+		}},
+		{"odd size", 37, 23, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * y), G: uint8(x ^ y), B: uint8(255 - x), A: 255}
+		}},
+		{"alpha", 31, 17, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * 8), G: uint8(y * 15), B: 128, A: uint8(x*y) | 1}
+		}},
+		{"transparent with colors", 9, 9, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * 30), G: uint8(y * 30), B: 7, A: 0}
+		}},
+		{"noise", 50, 40, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(random.Intn(256)), G: uint8(random.Intn(256)), B: uint8(random.Intn(256)), A: uint8(random.Intn(256))}
+		}},
+		{"several predictor blocks", 1100, 3, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x), G: uint8(x >> 3), B: uint8(y * 80), A: 255}
+		}},
+		{"tall", 1, 600, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(y), G: uint8(y * 3), B: uint8(y * 7), A: uint8(255 - y%50)}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewNRGBA(image.Rect(0, 0, tt.width, tt.height))
+			for y := 0; y < tt.height; y++ {
+				for x := 0; x < tt.width; x++ {
+					img.SetNRGBA(x, y, tt.pixel(x, y))
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := EncodeWebP(&buf, img); err != nil {
+				t.Fatalf("EncodeWebP() error = %v", err)
+			}
+
+			decoded, err := webp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("webp.Decode() error = %v", err)
+			}
+			if got := decoded.Bounds(); got != img.Bounds() {
+				t.Fatalf("decoded bounds = %v, want %v", got, img.Bounds())
+			}
+			for y := 0; y < tt.height; y++ {
+				for x := 0; x < tt.width; x++ {
+					got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+					if want := img.NRGBAAt(x, y); got != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebPOffsetBounds(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(5, 7, 12, 10))
+	for y := 7; y < 10; y++ {
+		for x := 5; x < 12; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 20), G: uint8(y * 20), B: 1, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, img); err != nil {
+		t.Fatalf("EncodeWebP() error = %v", err)
+	}
+	decoded, err := webp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("webp.Decode() error = %v", err)
+	}
+	if got, want := decoded.Bounds(), image.Rect(0, 0, 7, 3); got != want {
+		t.Fatalf("decoded bounds = %v, want %v", got, want)
+	}
+	got := color.NRGBAModel.Convert(decoded.At(0, 0)).(color.NRGBA)
+	if want := img.NRGBAAt(5, 7); got != want {
+		t.Fatalf("first pixel = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeWebPRejectsEmptyImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, image.NewNRGBA(image.Rect(0, 0, 0, 4))); err == nil {
+		t.Fatal("EncodeWebP() of an empty image succeeded")
+	}
+}
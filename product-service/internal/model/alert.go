@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrAlertNotFound  = errors.New("stock alert not found")
+	ErrAlertResolved  = errors.New("stock alert is already resolved")
+	ErrInvalidAlertID = errors.New("invalid stock alert ID")
+)
+
+// Stock alert statuses. An alert is open from the moment the stock of a product
+// falls to its reorder threshold until someone acknowledges it, and resolved once
+// the stock is above the threshold again.
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+const (
+	DefaultAlertLimit = 50
+	MaxAlertLimit     = 200
+)
+
+// StockAlert reports a product whose stock fell to its reorder threshold.
+// Threshold and Stock are the values when the alert was raised.
+type StockAlert struct {
+	ID             int64      `json:"id" db:"id"`
+	ProductID      string     `json:"product_id" db:"product_id"`
+	ProductName    string     `json:"product_name" db:"product_name"`
+	ProductSKU     *string    `json:"product_sku,omitempty" db:"product_sku"`
+	Status         string     `json:"status" db:"status"`
+	Threshold      int        `json:"threshold" db:"threshold"`
+	Stock          int        `json:"stock" db:"stock"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	LastError      *string    `json:"last_error,omitempty" db:"last_error"`
+	NotifiedAt     *time.Time `json:"notified_at,omitempty" db:"notified_at"`
+	AcknowledgedBy *string    `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// StockAlertQuery pages through alerts from the newest. Before is the ID of the
+// last alert of the previous page.
+type StockAlertQuery struct {
+	Status    string
+	ProductID string
+	Before    int64
+	Limit     int
+}
+
+// Normalize applies defaults and validates the query
+func (q *StockAlertQuery) Normalize() error {
+	switch q.Status {
+	case "", AlertOpen, AlertAcknowledged, AlertResolved:
+	default:
+		return fmt.Errorf("%w: status must be one of %s, %s or %s", ErrInvalidQuery, AlertOpen, AlertAcknowledged, AlertResolved)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultAlertLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxAlertLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxAlertLimit)
+	}
+	if q.Before < 0 {
+		return fmt.Errorf("%w: before must be an alert ID", ErrInvalidQuery)
+	}
+	return nil
+}
+
+type StockAlertResponse struct {
+	Success bool        `json:"success"`
+	Data    *StockAlert `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type StockAlertsResponse struct {
+	Success    bool          `json:"success"`
+	Error      string        `json:"error,omitempty"`
+	Data       []*StockAlert `json:"data"`
+	NextBefore int64         `json:"next_before,omitempty"`
+}
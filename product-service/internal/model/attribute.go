@@ -0,0 +1,262 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrAttributeNotFound  = errors.New("attribute not found")
+	ErrInvalidAttribute   = errors.New("invalid attribute")
+	ErrDuplicateAttribute = errors.New("an attribute with this code already exists")
+	ErrAttributeInUse     = errors.New("attribute is used by products")
+)
+
+const (
+	AttributeText    = "text"
+	AttributeNumber  = "number"
+	AttributeBoolean = "boolean"
+	AttributeEnum    = "enum"
+)
+
+// MaxAttributeTextLength is the longest text attribute value, in characters
+const MaxAttributeTextLength = 500
+
+var attributeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Attributes are the attribute values of a product by attribute code, as
+// decoded from JSON
+type Attributes map[string]interface{}
+
+// AttributeDefinition describes a typed attribute of products, e.g. the screen
+// size of TVs. Code is its key in product attributes and in filters; Unit only
+// applies to numbers and Values lists the options of an enum.
+type AttributeDefinition struct {
+	ID        string    `json:"id" db:"id"`
+	Code      string    `json:"code" db:"code"`
+	Name      string    `json:"name" db:"name"`
+	Type      string    `json:"type" db:"type"`
+	Unit      string    `json:"unit,omitempty" db:"unit"`
+	Values    []string  `json:"values,omitempty" db:"enum_values"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AttributeRequest creates an attribute or replaces its name, unit and values.
+// The code and type of an attribute can not change.
+type AttributeRequest struct {
+	Code   string   `json:"code" validate:"required,max=63"`
+	Name   string   `json:"name" validate:"required,max=100"`
+	Type   string   `json:"type" validate:"required,oneof=text number boolean enum"`
+	Unit   string   `json:"unit" validate:"max=20"`
+	Values []string `json:"values" validate:"dive,required,max=100"`
+}
+
+// CategoryAttribute is an attribute assigned to a category, which applies to
+// its subcategories too. CategoryID is the category it was assigned to. The
+// products of a category must have a value for its required attributes when
+// they are created or updated.
+type CategoryAttribute struct {
+	Attribute  *AttributeDefinition `json:"attribute"`
+	Required   bool                 `json:"required"`
+	CategoryID string               `json:"category_id"`
+}
+
+// SetCategoryAttributesRequest replaces the attributes assigned to a category
+type SetCategoryAttributesRequest struct {
+	Attributes []CategoryAttributeRequest `json:"attributes" validate:"dive"`
+}
+
+type CategoryAttributeRequest struct {
+	Code     string `json:"code" validate:"required"`
+	Required bool   `json:"required"`
+}
+
+type AttributeResponse struct {
+	Success bool                 `json:"success"`
+	Data    *AttributeDefinition `json:"data,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+type AttributesResponse struct {
+	Success bool                   `json:"success"`
+	Error   string                 `json:"error,omitempty"`
+	Data    []*AttributeDefinition `json:"data"`
+}
+
+type CategoryAttributesResponse struct {
+	Success bool                 `json:"success"`
+	Error   string               `json:"error,omitempty"`
+	Data    []*CategoryAttribute `json:"data"`
+}
+
+func (r *AttributeRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	if !attributeCodePattern.MatchString(r.Code) {
+		return fmt.Errorf("%w: code may only contain lowercase letters, digits and underscores, starting with a letter", ErrInvalidAttribute)
+	}
+	if r.Unit != "" && r.Type != AttributeNumber {
+		return fmt.Errorf("%w: only number attributes have a unit", ErrInvalidAttribute)
+	}
+
+	if r.Type != AttributeEnum {
+		if len(r.Values) > 0 {
+			return fmt.Errorf("%w: only enum attributes have values", ErrInvalidAttribute)
+		}
+		return nil
+	}
+	if len(r.Values) == 0 {
+		return fmt.Errorf("%w: enum attributes need at least one value", ErrInvalidAttribute)
+	}
+	seen := make(map[string]bool, len(r.Values))
+	for _, value := range r.Values {
+		if seen[value] {
+			return fmt.Errorf("%w: value %q is listed more than once", ErrInvalidAttribute, value)
+		}
+		seen[value] = true
+	}
+	return nil
+}
+
+func (r *SetCategoryAttributesRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(r.Attributes))
+	for _, attribute := range r.Attributes {
+		if seen[attribute.Code] {
+			return fmt.Errorf("%w: attribute %s is listed more than once", ErrInvalidAttribute, attribute.Code)
+		}
+		seen[attribute.Code] = true
+	}
+	return nil
+}
+
+// CheckValue checks value, as decoded from JSON, is a valid value of the attribute
+func (d *AttributeDefinition) CheckValue(value interface{}) error {
+	switch d.Type {
+	case AttributeText:
+		if text, ok := value.(string); ok && text != "" && utf8.RuneCountInString(text) <= MaxAttributeTextLength {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must be a text of 1 to %d characters", ErrInvalidAttribute, d.Code, MaxAttributeTextLength)
+	case AttributeNumber:
+		if number, ok := value.(float64); ok && !math.IsInf(number, 0) && !math.IsNaN(number) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must be a number", ErrInvalidAttribute, d.Code)
+	case AttributeBoolean:
+		if _, ok := value.(bool); ok {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must be true or false", ErrInvalidAttribute, d.Code)
+	case AttributeEnum:
+		if option, ok := value.(string); ok && slices.Contains(d.Values, option) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must be one of %s", ErrInvalidAttribute, d.Code, strings.Join(d.Values, ", "))
+	}
+	return fmt.Errorf("%w: %s has unknown type %s", ErrInvalidAttribute, d.Code, d.Type)
+}
+
+// Attribute filter operators. Equality tests take a comma separated list of
+// values and match any of them; the ordering ones only apply to numbers.
+const (
+	AttributeEqual        = "="
+	AttributeNotEqual     = "!="
+	AttributeGreater      = ">"
+	AttributeGreaterEqual = ">="
+	AttributeLess         = "<"
+	AttributeLessEqual    = "<="
+)
+
+// attributeOperators are matched longest first
+var attributeOperators = []string{
+	AttributeGreaterEqual, AttributeLessEqual, AttributeNotEqual, AttributeGreater, AttributeLess, AttributeEqual,
+}
+
+// AttributeFilter matches products on the value of an attribute, e.g.
+// screen_size>=50 or material=cotton,linen. Products without a value for the
+// attribute never match. Values holds the parsed operands once the filter is
+// resolved against the attribute definition.
+type AttributeFilter struct {
+	Code     string
+	Operator string
+	Operands []string
+	Values   []interface{}
+}
+
+// ParseAttributeFilter parses a filter expression: an attribute code, an
+// operator and the value or values it is compared with
+func ParseAttributeFilter(expression string) (AttributeFilter, error) {
+	end := strings.IndexAny(expression, "!<>=")
+	if end <= 0 {
+		return AttributeFilter{}, fmt.Errorf("%w: attribute filter %q needs an attribute code and an operator", ErrInvalidQuery, expression)
+	}
+
+	filter := AttributeFilter{Code: expression[:end]}
+	for _, operator := range attributeOperators {
+		if strings.HasPrefix(expression[end:], operator) {
+			filter.Operator = operator
+			break
+		}
+	}
+	if filter.Operator == "" {
+		return filter, fmt.Errorf("%w: attribute filter %q has an unknown operator", ErrInvalidQuery, expression)
+	}
+
+	value := expression[end+len(filter.Operator):]
+	if filter.Operator == AttributeEqual || filter.Operator == AttributeNotEqual {
+		filter.Operands = strings.Split(value, ",")
+	} else {
+		filter.Operands = []string{value}
+	}
+	for _, operand := range filter.Operands {
+		if operand == "" {
+			return filter, fmt.Errorf("%w: attribute filter %q has an empty value", ErrInvalidQuery, expression)
+		}
+	}
+	return filter, nil
+}
+
+// Resolve parses the operands of the filter as values of the attribute
+func (f *AttributeFilter) Resolve(definition *AttributeDefinition) error {
+	ordering := f.Operator != AttributeEqual && f.Operator != AttributeNotEqual
+	if ordering && definition.Type != AttributeNumber {
+		return fmt.Errorf("%w: %s is a %s attribute and can only be filtered with = or !=", ErrInvalidQuery, f.Code, definition.Type)
+	}
+
+	f.Values = make([]interface{}, len(f.Operands))
+	for i, operand := range f.Operands {
+		var value interface{} = operand
+		switch definition.Type {
+		case AttributeNumber:
+			number, err := strconv.ParseFloat(operand, 64)
+			if err != nil {
+				return fmt.Errorf("%w: %s must be compared with a number", ErrInvalidQuery, f.Code)
+			}
+			value = number
+		case AttributeBoolean:
+			boolean, err := strconv.ParseBool(operand)
+			if err != nil {
+				return fmt.Errorf("%w: %s must be compared with true or false", ErrInvalidQuery, f.Code)
+			}
+			value = boolean
+		}
+		if err := definition.CheckValue(value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		}
+		f.Values[i] = value
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category with this slug or name already exists")
+	ErrCategoryNotEmpty = errors.New("category has products or subcategories")
+	ErrCategoryCycle    = errors.New("category can not be moved below itself")
+	ErrInvalidSlug      = errors.New("slug may only contain lowercase letters, digits and single hyphens")
+)
+
+// ReassignToParent moves products and subcategories of a deleted category to its parent
+const ReassignToParent = "parent"
+
+type Category struct {
+	ID        string      `json:"id" db:"id"`
+	ParentID  *string     `json:"parent_id" db:"parent_id"`
+	Name      string      `json:"name" db:"name"`
+	Slug      string      `json:"slug" db:"slug"`
+	SortOrder int         `json:"sort_order" db:"sort_order"`
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at" db:"updated_at"`
+	Children  []*Category `json:"children,omitempty"`
+}
+
+type CreateCategoryRequest struct {
+	Name      string  `json:"name" validate:"required,min=1,max=100"`
+	Slug      string  `json:"slug" validate:"omitempty,max=120"`
+	ParentID  *string `json:"parent_id" validate:"omitempty,max=255"`
+	SortOrder int     `json:"sort_order"`
+}
+
+// UpdateCategoryRequest changes the given fields. Use MoveCategoryRequest to
+// change the parent.
+type UpdateCategoryRequest struct {
+	Name      *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Slug      *string `json:"slug" validate:"omitempty,min=1,max=120"`
+	SortOrder *int    `json:"sort_order"`
+}
+
+// MoveCategoryRequest moves a category, with its subtree and products, below
+// ParentID or to the root when ParentID is null
+type MoveCategoryRequest struct {
+	ParentID *string `json:"parent_id" validate:"omitempty,max=255"`
+}
+
+type CategoryResponse struct {
+	Success bool      `json:"success"`
+	Data    *Category `json:"data,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+type CategoriesResponse struct {
+	Success bool        `json:"success"`
+	Error   string      `json:"error,omitempty"`
+	Data    []*Category `json:"data"`
+}
+
+func (r *CreateCategoryRequest) Validate() error {
+	return validate.Struct(r)
+}
+
+func (r *UpdateCategoryRequest) Validate() error {
+	return validate.Struct(r)
+}
+
+func (r *MoveCategoryRequest) Validate() error {
+	return validate.Struct(r)
+}
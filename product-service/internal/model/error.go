@@ -4,8 +4,16 @@ import "errors"
 
 var (
 	ErrProductNotFound = errors.New("product not found")
-	ErrInvalidID       = errors.New("invalid product ID")
-	ErrInvalidPrice    = errors.New("invalid price")
-	ErrInvalidStock    = errors.New("invalid stock quantity")
-	ErrDatabase        = errors.New("database error")
+	// ErrProductNotDeleted is returned when restoring a product that is not in the trash
+	ErrProductNotDeleted = errors.New("product is not deleted")
+	ErrInvalidID         = errors.New("invalid product ID")
+	// ErrDuplicateExternalID is returned when another product has the same external ID
+	ErrDuplicateExternalID = errors.New("external ID already exists")
+	ErrInvalidPrice        = errors.New("invalid price")
+	ErrInvalidStock        = errors.New("invalid stock quantity")
+	ErrDatabase            = errors.New("database error")
+	// ErrDuplicateSlug is returned when another product has or had the slug
+	ErrDuplicateSlug = errors.New("slug already exists")
+	// ErrStockReadOnly is returned when an update changes the stock of a product
+	ErrStockReadOnly = errors.New("stock is read-only, adjust it through POST /api/v1/products/:id/stock/adjust")
 )
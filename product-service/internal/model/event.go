@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+const (
+	EventUserErased = "user.erased"
+)
+
+// Event is a domain event delivered by another service
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "fmt"
+
+// Catalog export formats. The feed formats follow the Google Merchant Center
+// product data specification.
+const (
+	ExportFormatCSV         = "csv"
+	ExportFormatNDJSON      = "ndjson"
+	ExportFormatMerchantXML = "merchant_xml"
+	ExportFormatMerchantTSV = "merchant_tsv"
+)
+
+// ExportFormats whitelists the formats the catalog can be exported in
+var ExportFormats = map[string]bool{
+	ExportFormatCSV:         true,
+	ExportFormatNDJSON:      true,
+	ExportFormatMerchantXML: true,
+	ExportFormatMerchantTSV: true,
+}
+
+// ExportQuery selects the products to export with the listing filters. Products
+// are always exported in ID order so successive exports diff cleanly.
+type ExportQuery struct {
+	Filter ProductFilter
+	Format string
+	// Currency, when set, adds display prices in that currency; feeds are priced in it
+	Currency string
+}
+
+// Normalize applies defaults and validates the query
+func (q *ExportQuery) Normalize() error {
+	if q.Format == "" {
+		q.Format = ExportFormatCSV
+	}
+	if !ExportFormats[q.Format] {
+		return fmt.Errorf("%w: unknown export format %q", ErrInvalidQuery, q.Format)
+	}
+	return q.Filter.Validate()
+}
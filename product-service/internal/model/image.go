@@ -0,0 +1,118 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrImageNotFound = errors.New("image not found")
+	ErrInvalidImage  = errors.New("invalid image")
+	ErrImageTooLarge = errors.New("image is too large")
+	// ErrUnsupportedImage is returned for files that are not a JPEG, PNG or GIF
+	ErrUnsupportedImage = errors.New("unsupported image")
+	ErrTooManyImages    = errors.New("product has too many images")
+)
+
+// ProductImage is an uploaded image of a product. Images are shown in Position
+// order starting at 0. URL serves the original file and Variants its thumbnails;
+// URLs never change, so they can be cached forever.
+type ProductImage struct {
+	ID          string         `json:"id" db:"id"`
+	ProductID   string         `json:"product_id" db:"product_id"`
+	Position    int            `json:"position" db:"position"`
+	AltText     string         `json:"alt_text" db:"alt_text"`
+	URL         string         `json:"url" db:"url"`
+	ContentType string         `json:"content_type" db:"content_type"`
+	Width       int            `json:"width" db:"width"`
+	Height      int            `json:"height" db:"height"`
+	Size        int64          `json:"size_bytes" db:"size_bytes"`
+	Checksum    string         `json:"checksum" db:"checksum"`
+	Variants    []ImageVariant `json:"variants" db:"variants"`
+	CreatedBy   *string        `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+
+	// StorageKey locates the original file in blob storage
+	StorageKey string `json:"-" db:"storage_key"`
+}
+
+// ImageVariant is a thumbnail fitting a Size by Size box. Each size is rendered
+// in the format of the original, JPEG or PNG, and in WebP.
+type ImageVariant struct {
+	Size        int    `json:"size"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Bytes       int64  `json:"size_bytes"`
+
+	// Key locates the thumbnail in blob storage
+	Key string `json:"-"`
+}
+
+// ImageUpload is an image file uploaded to a product. Position inserts it before
+// the image at that position; nil or past the end appends it.
+type ImageUpload struct {
+	ProductID string
+	AltText   string
+	Position  *int
+	Data      []byte
+	// CreatedBy is taken from the identity forwarded by the gateway
+	CreatedBy string
+}
+
+type UpdateImageRequest struct {
+	AltText string `json:"alt_text" validate:"max=500"`
+}
+
+// ReorderImagesRequest lists every image of a product in its new order
+type ReorderImagesRequest struct {
+	ImageIDs []string `json:"image_ids" validate:"required,min=1,dive,required"`
+}
+
+type ProductImageResponse struct {
+	Success bool          `json:"success"`
+	Data    *ProductImage `json:"data,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type ProductImagesResponse struct {
+	Success bool            `json:"success"`
+	Error   string          `json:"error,omitempty"`
+	Data    []*ProductImage `json:"data"`
+}
+
+func (u *ImageUpload) Validate() error {
+	if utf8.RuneCountInString(u.AltText) > 500 {
+		return fmt.Errorf("%w: alt_text must be at most 500 characters", ErrInvalidImage)
+	}
+	if u.Position != nil && *u.Position < 0 {
+		return fmt.Errorf("%w: position must not be negative", ErrInvalidImage)
+	}
+	if len(u.Data) == 0 {
+		return fmt.Errorf("%w: file is empty", ErrInvalidImage)
+	}
+	return nil
+}
+
+func (r *UpdateImageRequest) Validate() error {
+	return validate.Struct(r)
+}
+
+func (r *ReorderImagesRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(r.ImageIDs))
+	for _, id := range r.ImageIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: image %s is listed more than once", ErrInvalidImage, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
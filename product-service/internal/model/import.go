@@ -0,0 +1,89 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrImportNotFound = errors.New("import not found")
+	ErrInvalidImport  = errors.New("invalid import")
+)
+
+// Import job statuses. A job is pending until a worker claims it and running
+// until every row has been processed.
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// Supported import file formats
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// Keys rows are matched against existing products on
+const (
+	ImportKeySKU        = "sku"
+	ImportKeyExternalID = "external_id"
+)
+
+// ImportJob is an asynchronous bulk import of products. Rows are upserted in
+// batches; a dry run validates and matches every row without saving anything.
+type ImportJob struct {
+	ID            string           `json:"id" db:"id"`
+	Status        string           `json:"status" db:"status"`
+	Format        string           `json:"format" db:"format"`
+	Key           string           `json:"key" db:"match_key"`
+	DryRun        bool             `json:"dry_run" db:"dry_run"`
+	TotalRows     int              `json:"total_rows" db:"total_rows"`
+	ProcessedRows int              `json:"processed_rows" db:"processed_rows"`
+	Created       int              `json:"created" db:"created_count"`
+	Updated       int              `json:"updated" db:"updated_count"`
+	Failed        int              `json:"failed" db:"failed_count"`
+	Errors        []ImportRowError `json:"-" db:"errors"`
+	// ErrorReport is the URL of the row errors as CSV, set when rows failed
+	ErrorReport string     `json:"error_report,omitempty"`
+	Error       *string    `json:"error,omitempty" db:"error"`
+	CreatedBy   *string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// ImportRequest describes an uploaded import file. CreatedBy is taken from the
+// identity forwarded by the gateway and recorded as the creator of new products.
+type ImportRequest struct {
+	Format    string
+	Key       string
+	DryRun    bool
+	CreatedBy string
+}
+
+// ImportRowError describes why a row was not imported. Row is the line of the
+// row in the file; Field is empty when the error is not about a single field.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportRow is a parsed row of an import file. Category is a category ID or slug.
+// The price list of a matched product is only replaced when ReplacePrices is set.
+type ImportRow struct {
+	Row           int
+	Product       CreateProductRequest
+	Category      string
+	ReplacePrices bool
+	Errors        []ImportRowError
+}
+
+type ImportJobResponse struct {
+	Success bool       `json:"success"`
+	Data    *ImportJob `json:"data,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrNoExchangeRate  = errors.New("no exchange rate")
+)
+
+// currencyExponents holds the number of minor unit digits of the supported ISO 4217 currencies
+var currencyExponents = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0,
+	"CNY": 2, "COP": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2,
+	"IDR": 2, "ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PEN": 2, "PHP": 2, "PLN": 2,
+	"RON": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2,
+	"UAH": 2, "USD": 2, "UYU": 2, "VND": 0, "ZAR": 2,
+}
+
+// CurrencyExponent returns the number of minor unit digits of an ISO 4217 currency code
+func CurrencyExponent(currency string) (int, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return exponent, nil
+}
+
+// Money is an exact amount in the minor units of its currency, e.g. 1999 USD is $19.99.
+//
+// In JSON the amount is an integer number of minor units. A decimal string such as
+// "19.99" is accepted too, as long as it has no more digits than the currency allows.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// ParseMoney parses a decimal amount in major units, e.g. "19.99", into minor units of currency
+func ParseMoney(value, currency string) (Money, error) {
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(value), ".")
+	negative := strings.HasPrefix(whole, "-")
+	whole = strings.TrimPrefix(whole, "-")
+	if whole == "" || len(fraction) > exponent || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q is not an amount with at most %d decimals in %s", ErrInvalidPrice, value, exponent, currency)
+	}
+
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidPrice, value)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal formats the amount in major units, e.g. "19.99"
+func (m Money) Decimal() string {
+	exponent, ok := currencyExponents[m.Currency]
+	if !ok || exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	scale := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exponent, amount%scale)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Validate checks the currency is known and the amount positive
+func (m Money) Validate() error {
+	if _, err := CurrencyExponent(m.Currency); err != nil {
+		return err
+	}
+	if m.Amount <= 0 {
+		return fmt.Errorf("%w: %s must be greater than zero", ErrInvalidPrice, m)
+	}
+	return nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(raw.Currency))
+	if _, err := CurrencyExponent(currency); err != nil {
+		return err
+	}
+	if len(raw.Amount) == 0 {
+		return fmt.Errorf("%w: amount is required", ErrInvalidPrice)
+	}
+
+	if raw.Amount[0] == '"' {
+		var value string
+		if err := json.Unmarshal(raw.Amount, &value); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(value, currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	amount, err := strconv.ParseInt(string(bytes.TrimSpace(raw.Amount)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: amount must be an integer number of minor units or a decimal string", ErrInvalidPrice)
+	}
+
+	*m = Money{Amount: amount, Currency: currency}
+	return nil
+}
+
+// ValidatePriceList checks a product's per-currency prices: every price must be
+// valid, currencies may appear once and must differ from the product's own price
+func ValidatePriceList(price Money, prices []Money) error {
+	seen := map[string]bool{price.Currency: true}
+	for _, p := range prices {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		if seen[p.Currency] {
+			return fmt.Errorf("%w: more than one %s price", ErrInvalidPrice, p.Currency)
+		}
+		seen[p.Currency] = true
+	}
+	return nil
+}
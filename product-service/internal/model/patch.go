@@ -0,0 +1,28 @@
+package model
+
+import "errors"
+
+const (
+	// MergePatchContentType selects JSON Merge Patch (RFC 7396): present fields are
+	// replaced, null removes a field and absent fields are left unchanged
+	MergePatchContentType = "application/merge-patch+json"
+	// JSONPatchContentType selects JSON Patch (RFC 6902), a list of operations
+	JSONPatchContentType = "application/json-patch+json"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPatchConflict is returned when a patch does not apply to the current
+	// document, e.g. a test operation fails or a path does not exist
+	ErrPatchConflict = errors.New("patch does not apply")
+	// ErrInvalidProduct is returned when a patched product is not a valid product
+	ErrInvalidProduct = errors.New("invalid product")
+)
+
+// Patch is a patch document of one of the supported content types
+type Patch struct {
+	ContentType string
+	Document    []byte
+	// UpdatedBy is the user patching, recorded in the price history
+	UpdatedBy string
+}
@@ -0,0 +1,33 @@
+package model
+
+import "errors"
+
+var (
+	// ErrVersionMismatch is returned when a write's precondition does not match
+	// the current version of a product
+	ErrVersionMismatch = errors.New("product has been modified")
+	// ErrPreconditionRequired is returned when writes must be conditional and are not
+	ErrPreconditionRequired = errors.New("precondition required")
+)
+
+// Precondition is the If-Match condition of a write. A nil *Precondition means
+// the write is unconditional.
+type Precondition struct {
+	// Any matches every existing product (If-Match: *)
+	Any bool
+	// Versions lists the versions the write may be applied to
+	Versions []int64
+}
+
+// Matches reports whether a product at version satisfies the precondition
+func (p *Precondition) Matches(version int64) bool {
+	if p == nil || p.Any {
+		return true
+	}
+	for _, v := range p.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
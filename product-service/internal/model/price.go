@@ -0,0 +1,137 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("price schedule not found")
+	// ErrScheduleOverlap is returned when a scheduled price would be in effect at
+	// the same time as another one of the product
+	ErrScheduleOverlap = errors.New("price schedule overlaps another schedule of the product")
+	ErrScheduleEnded   = errors.New("price schedule has already ended")
+	ErrInvalidSchedule = errors.New("invalid price schedule")
+)
+
+// Sources of price changes. Schedule changes are made by the price scheduler
+// when a scheduled price starts and ends.
+const (
+	PriceSourceInitial       = "initial"
+	PriceSourceManual        = "manual"
+	PriceSourceImport        = "import"
+	PriceSourceScheduleStart = "schedule_start"
+	PriceSourceScheduleEnd   = "schedule_end"
+)
+
+// Price schedule statuses. A schedule is active while its price is in effect.
+const (
+	ScheduleScheduled = "scheduled"
+	ScheduleActive    = "active"
+	ScheduleCompleted = "completed"
+	ScheduleCancelled = "cancelled"
+)
+
+const (
+	DefaultPriceHistoryLimit = 50
+	MaxPriceHistoryLimit     = 200
+)
+
+// PriceChange is an entry of the price history of a product
+type PriceChange struct {
+	ID            int64     `json:"id" db:"id"`
+	ProductID     string    `json:"product_id" db:"product_id"`
+	Price         Money     `json:"price" db:"price"`
+	PreviousPrice *Money    `json:"previous_price,omitempty" db:"previous_price"`
+	Source        string    `json:"source" db:"source"`
+	ScheduleID    *string   `json:"schedule_id,omitempty" db:"schedule_id"`
+	ActorID       *string   `json:"actor_id,omitempty" db:"actor_id"`
+	ChangedAt     time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// PriceHistoryQuery pages through the price history of a product from the newest
+// change. Before is the ID of the last change of the previous page.
+type PriceHistoryQuery struct {
+	Before int64
+	Limit  int
+}
+
+// Normalize applies defaults and validates the query
+func (q *PriceHistoryQuery) Normalize() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultPriceHistoryLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxPriceHistoryLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPriceHistoryLimit)
+	}
+	if q.Before < 0 {
+		return fmt.Errorf("%w: before must be a price change ID", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// PriceSchedule replaces the price of a product from StartsAt and restores the
+// price it replaced at EndsAt, unless the price was changed by hand meanwhile.
+// Without EndsAt the scheduled price stays. Note explains a schedule that ended
+// differently than planned.
+type PriceSchedule struct {
+	ID            string     `json:"id" db:"id"`
+	ProductID     string     `json:"product_id" db:"product_id"`
+	Price         Money      `json:"price" db:"price"`
+	StartsAt      time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	Status        string     `json:"status" db:"status"`
+	PreviousPrice *Money     `json:"previous_price,omitempty" db:"previous_price"`
+	Note          *string    `json:"note,omitempty" db:"note"`
+	CreatedBy     *string    `json:"created_by,omitempty" db:"created_by"`
+	ActivatedAt   *time.Time `json:"activated_at,omitempty" db:"activated_at"`
+	EndedAt       *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreatePriceScheduleRequest schedules a price in the product currency. A start
+// in the past takes effect on the next run of the scheduler.
+type CreatePriceScheduleRequest struct {
+	Price    Money      `json:"price"`
+	StartsAt time.Time  `json:"starts_at" validate:"required"`
+	EndsAt   *time.Time `json:"ends_at"`
+	// CreatedBy is taken from the identity forwarded by the gateway, never from the body
+	CreatedBy string `json:"-"`
+}
+
+func (r *CreatePriceScheduleRequest) Validate(now time.Time) error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	if err := r.Price.Validate(); err != nil {
+		return err
+	}
+	if r.EndsAt != nil && !r.EndsAt.After(r.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSchedule)
+	}
+	if r.EndsAt != nil && !r.EndsAt.After(now) {
+		return fmt.Errorf("%w: ends_at must be in the future", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+type PriceHistoryResponse struct {
+	Success    bool           `json:"success"`
+	Error      string         `json:"error,omitempty"`
+	Data       []*PriceChange `json:"data"`
+	NextBefore int64          `json:"next_before,omitempty"`
+}
+
+type PriceScheduleResponse struct {
+	Success bool           `json:"success"`
+	Data    *PriceSchedule `json:"data,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type PriceSchedulesResponse struct {
+	Success bool             `json:"success"`
+	Error   string           `json:"error,omitempty"`
+	Data    []*PriceSchedule `json:"data"`
+}
@@ -6,31 +6,107 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// Product is priced in the currency of Price. Prices optionally fix the price in
+// other currencies; DisplayPrice is only set when a display currency was requested.
+// Version is incremented on every write and is the product's ETag.
 type Product struct {
-	ID          string    `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name" validate:"required,min=1,max=255"`
-	Description string    `json:"description" db:"description" validate:"max=1000"`
-	Price       float64   `json:"price" db:"price" validate:"required,gt=0"`
-	Category    string    `json:"category" db:"category" validate:"max=100"`
-	Stock       int       `json:"stock" db:"stock" validate:"gte=0"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID           string        `json:"id" db:"id"`
+	SKU          *string       `json:"sku,omitempty" db:"sku"`
+	ExternalID   *string       `json:"external_id,omitempty" db:"external_id"`
+	Name         string        `json:"name" db:"name" validate:"required,min=1,max=255"`
+	Slug         string        `json:"slug" db:"slug"`
+	Description  string        `json:"description" db:"description" validate:"max=1000"`
+	Price        Money         `json:"price" db:"price"`
+	Prices       []Money       `json:"prices,omitempty" db:"prices"`
+	DisplayPrice *DisplayPrice `json:"display_price,omitempty"`
+	CategoryID   *string       `json:"category_id" db:"category_id"`
+	Category     string        `json:"category" db:"category" validate:"max=100"`
+	Stock        int           `json:"stock" db:"stock" validate:"gte=0"`
+	// Available is the stock not held by active reservations
+	Available int `json:"available" db:"available"`
+	// ReorderThreshold raises a low-stock alert when stock falls to it
+	ReorderThreshold *int            `json:"reorder_threshold,omitempty" db:"reorder_threshold"`
+	CreatedBy        *string         `json:"created_by,omitempty" db:"created_by"`
+	VariantOptions   []VariantOption `json:"variant_options,omitempty" db:"variant_options"`
+	Variants         *VariantSummary `json:"variants,omitempty"`
+	Images           []*ProductImage `json:"images,omitempty"`
+	Attributes       Attributes      `json:"attributes,omitempty" db:"attributes"`
+	SEO              SEO             `json:"seo"`
+	Status           string          `json:"status" db:"status"`
+	PublishAt        *time.Time      `json:"publish_at,omitempty" db:"publish_at"`
+	UnpublishAt      *time.Time      `json:"unpublish_at,omitempty" db:"unpublish_at"`
+	Version          int64           `json:"version" db:"version"`
+	DeletedAt        *time.Time      `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy        *string         `json:"deleted_by,omitempty" db:"deleted_by"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at" db:"updated_at"`
 }
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required,min=1,max=255"`
-	Description string  `json:"description" validate:"max=1000"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Category    string  `json:"category" validate:"max=100"`
-	Stock       int     `json:"stock" validate:"gte=0"`
+	SKU              string  `json:"sku" validate:"omitempty,max=64"`
+	ExternalID       string  `json:"external_id" validate:"omitempty,max=255"`
+	Name             string  `json:"name" validate:"required,min=1,max=255"`
+	Description      string  `json:"description" validate:"max=1000"`
+	Price            Money   `json:"price"`
+	Prices           []Money `json:"prices"`
+	CategoryID       string  `json:"category_id" validate:"omitempty,max=255"`
+	Stock            int     `json:"stock" validate:"gte=0"`
+	ReorderThreshold *int    `json:"reorder_threshold" validate:"omitempty,gte=0"`
+	// Attributes are checked against their definitions and the required
+	// attributes of the category
+	Attributes Attributes `json:"attributes"`
+	// Slug is generated from the name when empty, with a numeric suffix when
+	// another product has or had it
+	Slug string `json:"slug" validate:"omitempty,max=120"`
+	SEO  SEO    `json:"seo"`
+	// Status defaults to draft; products can not be created archived. The dates
+	// follow the rules of StatusRequest. Only admins may set them.
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	// CreatedBy is taken from the identity forwarded by the gateway, never from the body
+	CreatedBy string `json:"-"`
 }
 
+// UpdateProductRequest is the complete editable state of a product. It replaces
+// every field, so an omitted description or price list is cleared and a null
+// category_id removes the product from its category. Patches are applied to it.
+// A changed price is recorded in the price history. Stock is read-only: it is
+// kept when omitted and a different value is rejected, as stock only moves
+// through stock adjustments. The status is changed through StatusRequest.
 type UpdateProductRequest struct {
-	Name        string  `json:"name" validate:"omitempty,min=1,max=255"`
-	Description string  `json:"description" validate:"omitempty,max=1000"`
-	Price       float64 `json:"price" validate:"omitempty,gt=0"`
-	Category    string  `json:"category" validate:"omitempty,max=100"`
-	Stock       int     `json:"stock" validate:"omitempty,gte=0"`
+	SKU              *string `json:"sku" validate:"omitempty,min=1,max=64"`
+	ExternalID       *string `json:"external_id" validate:"omitempty,min=1,max=255"`
+	Name             string  `json:"name" validate:"required,min=1,max=255"`
+	Description      string  `json:"description" validate:"max=1000"`
+	Price            Money   `json:"price"`
+	Prices           []Money `json:"prices"`
+	CategoryID       *string `json:"category_id" validate:"omitempty,min=1,max=255"`
+	Stock            *int    `json:"stock" validate:"omitempty,gte=0"`
+	ReorderThreshold *int    `json:"reorder_threshold" validate:"omitempty,gte=0"`
+	// Attributes replace every attribute value, so omitted ones are removed
+	Attributes Attributes `json:"attributes"`
+	// Slug is kept when empty. The former slug of a product redirects to the new one.
+	Slug string `json:"slug" validate:"omitempty,max=120"`
+	SEO  SEO    `json:"seo"`
+	// UpdatedBy is taken from the identity forwarded by the gateway and recorded
+	// in the price history, never from the body
+	UpdatedBy string `json:"-"`
+}
+
+// SEO is the search engine metadata of a product. Empty fields are left to the
+// defaults of the storefront.
+type SEO struct {
+	MetaTitle       string `json:"meta_title,omitempty" db:"meta_title" validate:"max=255"`
+	MetaDescription string `json:"meta_description,omitempty" db:"meta_description" validate:"max=500"`
+	CanonicalURL    string `json:"canonical_url,omitempty" db:"canonical_url" validate:"omitempty,http_url,max=2048"`
+}
+
+// DisplayPrice is a product price in a requested currency. Converted is set when
+// it was derived from an exchange rate rather than a fixed price.
+type DisplayPrice struct {
+	Money
+	Converted bool `json:"converted"`
 }
 
 type ProductResponse struct {
@@ -40,18 +116,71 @@ type ProductResponse struct {
 }
 
 type ProductsResponse struct {
-	Success bool       `json:"success"`
-	Error   string     `json:"error,omitempty"`
-	Data    []*Product `json:"data"`
-	Total   int        `json:"total"`
+	Success    bool       `json:"success"`
+	Error      string     `json:"error,omitempty"`
+	Data       []*Product `json:"data"`
+	Total      int        `json:"total"`
+	Page       int        `json:"page,omitempty"`
+	Limit      int        `json:"limit,omitempty"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	PrevCursor string     `json:"prev_cursor,omitempty"`
+	Facets     Facets     `json:"facets,omitempty"`
+}
+
+type SearchResponse struct {
+	Success bool            `json:"success"`
+	Error   string          `json:"error,omitempty"`
+	Data    []*SearchResult `json:"data"`
+	Total   int             `json:"total"`
+	Page    int             `json:"page,omitempty"`
+	Limit   int             `json:"limit,omitempty"`
+	Facets  Facets          `json:"facets,omitempty"`
 }
 
 var validate = validator.New()
 
 func (p *CreateProductRequest) Validate() error {
-	return validate.Struct(p)
+	if err := validate.Struct(p); err != nil {
+		return err
+	}
+	if err := p.Price.Validate(); err != nil {
+		return err
+	}
+	return ValidatePriceList(p.Price, p.Prices)
 }
 
 func (p *UpdateProductRequest) Validate() error {
-	return validate.Struct(p)
+	if err := validate.Struct(p); err != nil {
+		return err
+	}
+	if err := p.Price.Validate(); err != nil {
+		return err
+	}
+	return ValidatePriceList(p.Price, p.Prices)
+}
+
+// UpdateRequest returns the editable state of the product, the document patches apply to
+func (p *Product) UpdateRequest() *UpdateProductRequest {
+	prices := p.Prices
+	if prices == nil {
+		prices = []Money{}
+	}
+	attributes := p.Attributes
+	if attributes == nil {
+		attributes = Attributes{}
+	}
+	return &UpdateProductRequest{
+		SKU:              p.SKU,
+		ExternalID:       p.ExternalID,
+		Name:             p.Name,
+		Description:      p.Description,
+		Price:            p.Price,
+		Prices:           prices,
+		CategoryID:       p.CategoryID,
+		Stock:            &p.Stock,
+		ReorderThreshold: p.ReorderThreshold,
+		Attributes:       attributes,
+		Slug:             p.Slug,
+		SEO:              p.SEO,
+	}
 }
@@ -0,0 +1,283 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+	// ErrDuplicateCoupon is returned when another promotion has the same coupon code
+	ErrDuplicateCoupon = errors.New("coupon code already exists")
+	ErrCouponNotFound  = errors.New("coupon not found")
+	// ErrCouponUnavailable is returned when redeeming a coupon that is inactive or
+	// outside its validity window
+	ErrCouponUnavailable = errors.New("coupon is not valid now")
+	ErrCouponExhausted   = errors.New("coupon usage limit reached")
+	ErrInvalidQuote      = errors.New("invalid quote")
+)
+
+// Promotion types. Percentage and fixed promotions take a share or an amount off
+// every unit; buy X get Y gives Y of every X+Y units of a line for free.
+const (
+	PromotionPercentage = "percentage"
+	PromotionFixed      = "fixed"
+	PromotionBuyXGetY   = "buy_x_get_y"
+)
+
+// Reasons a coupon presented for a quote was not applied
+const (
+	CouponUnknown       = "unknown"
+	CouponInactive      = "inactive"
+	CouponNotStarted    = "not_started"
+	CouponExpired       = "expired"
+	CouponExhausted     = "exhausted"
+	CouponNotApplicable = "not_applicable"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{1,64}$`)
+
+// NormalizeCouponCode returns code as stored: trimmed and upper case
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Promotion is a discount rule. It targets a product, a category with its
+// subcategories, or every product when neither is set. A promotion with a coupon
+// code only applies to quotes presenting the code, and UsageLimit caps how often
+// the coupon can be redeemed.
+type Promotion struct {
+	ID          string     `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	Type        string     `json:"type" db:"type"`
+	Percent     *int       `json:"percent,omitempty" db:"percent"`
+	Amount      *Money     `json:"amount,omitempty" db:"amount"`
+	BuyQuantity *int       `json:"buy_quantity,omitempty" db:"buy_quantity"`
+	GetQuantity *int       `json:"get_quantity,omitempty" db:"get_quantity"`
+	ProductID   *string    `json:"product_id,omitempty" db:"product_id"`
+	CategoryID  *string    `json:"category_id,omitempty" db:"category_id"`
+	CouponCode  *string    `json:"coupon_code,omitempty" db:"coupon_code"`
+	UsageLimit  *int       `json:"usage_limit,omitempty" db:"usage_limit"`
+	UsageCount  int        `json:"usage_count" db:"usage_count"`
+	StartsAt    *time.Time `json:"starts_at,omitempty" db:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	Active      bool       `json:"active" db:"active"`
+	CreatedBy   *string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Availability reports why the promotion can not be used at now, or "" when it can
+func (p *Promotion) Availability(now time.Time) string {
+	switch {
+	case !p.Active:
+		return CouponInactive
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return CouponNotStarted
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return CouponExpired
+	case p.UsageLimit != nil && p.UsageCount >= *p.UsageLimit:
+		return CouponExhausted
+	default:
+		return ""
+	}
+}
+
+// PromotionRequest creates a promotion or replaces one. Only the fields of the
+// promotion type may be set. Active defaults to true.
+type PromotionRequest struct {
+	Name        string     `json:"name" validate:"required,min=1,max=255"`
+	Description string     `json:"description" validate:"max=2000"`
+	Type        string     `json:"type" validate:"required,oneof=percentage fixed buy_x_get_y"`
+	Percent     *int       `json:"percent" validate:"omitempty,min=1,max=100"`
+	Amount      *Money     `json:"amount"`
+	BuyQuantity *int       `json:"buy_quantity" validate:"omitempty,min=1"`
+	GetQuantity *int       `json:"get_quantity" validate:"omitempty,min=1"`
+	ProductID   string     `json:"product_id" validate:"omitempty,max=255"`
+	CategoryID  string     `json:"category_id" validate:"omitempty,max=255"`
+	CouponCode  string     `json:"coupon_code"`
+	UsageLimit  *int       `json:"usage_limit" validate:"omitempty,min=1"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Active      *bool      `json:"active"`
+	// CreatedBy is taken from the identity forwarded by the gateway, never from the body
+	CreatedBy string `json:"-"`
+}
+
+// Validate checks the request and normalizes its coupon code
+func (r *PromotionRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+
+	switch r.Type {
+	case PromotionPercentage:
+		if r.Percent == nil || r.Amount != nil || r.BuyQuantity != nil || r.GetQuantity != nil {
+			return fmt.Errorf("%w: percentage promotions take percent only", ErrInvalidPromotion)
+		}
+	case PromotionFixed:
+		if r.Amount == nil || r.Percent != nil || r.BuyQuantity != nil || r.GetQuantity != nil {
+			return fmt.Errorf("%w: fixed promotions take amount only", ErrInvalidPromotion)
+		}
+		if err := r.Amount.Validate(); err != nil {
+			return err
+		}
+		if r.Amount.Amount == 0 {
+			return fmt.Errorf("%w: amount must be greater than 0", ErrInvalidPromotion)
+		}
+	case PromotionBuyXGetY:
+		if r.BuyQuantity == nil || r.GetQuantity == nil || r.Percent != nil || r.Amount != nil {
+			return fmt.Errorf("%w: buy X get Y promotions take buy_quantity and get_quantity only", ErrInvalidPromotion)
+		}
+	}
+
+	if r.ProductID != "" && r.CategoryID != "" {
+		return fmt.Errorf("%w: a promotion targets a product or a category, not both", ErrInvalidPromotion)
+	}
+	if r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+
+	r.CouponCode = NormalizeCouponCode(r.CouponCode)
+	if r.CouponCode != "" && !couponCodePattern.MatchString(r.CouponCode) {
+		return fmt.Errorf("%w: coupon codes are up to 64 letters, digits, dashes and underscores", ErrInvalidPromotion)
+	}
+	if r.UsageLimit != nil && r.CouponCode == "" {
+		return fmt.Errorf("%w: usage_limit requires a coupon code", ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// IsActive returns Active, true when unset
+func (r *PromotionRequest) IsActive() bool {
+	return r.Active == nil || *r.Active
+}
+
+// RedeemCouponRequest uses a coupon up for an order. Redeeming again with the
+// same reference does not count again.
+type RedeemCouponRequest struct {
+	Code        string `json:"code" validate:"required,max=64"`
+	ReferenceID string `json:"reference_id" validate:"required,max=255"`
+	// RedeemedBy is taken from the identity forwarded by the gateway, never from the body
+	RedeemedBy string `json:"-"`
+}
+
+// Validate checks the request and normalizes its coupon code
+func (r *RedeemCouponRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	r.Code = NormalizeCouponCode(r.Code)
+	return nil
+}
+
+type QuoteItem struct {
+	ProductID string  `json:"product_id" validate:"required,max=255"`
+	VariantID *string `json:"variant_id" validate:"omitempty,max=255"`
+	Quantity  int     `json:"quantity" validate:"required,min=1,max=10000"`
+}
+
+// QuoteRequest prices items in Currency, the catalog currency when empty, with
+// the automatic promotions and those unlocked by Coupons
+type QuoteRequest struct {
+	Items    []QuoteItem `json:"items" validate:"required,min=1,max=100,dive"`
+	Coupons  []string    `json:"coupons" validate:"max=10,dive,required,max=64"`
+	Currency string      `json:"currency" validate:"omitempty,len=3"`
+}
+
+// Validate checks the request and normalizes its coupon codes
+func (r *QuoteRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(r.Items))
+	for _, item := range r.Items {
+		key := item.ProductID
+		if item.VariantID != nil {
+			key += "/" + *item.VariantID
+		}
+		if seen[key] {
+			return fmt.Errorf("%w: product %s is quoted more than once", ErrInvalidQuote, key)
+		}
+		seen[key] = true
+	}
+
+	codes := make([]string, 0, len(r.Coupons))
+	unique := make(map[string]bool, len(r.Coupons))
+	for _, code := range r.Coupons {
+		code = NormalizeCouponCode(code)
+		if !unique[code] {
+			unique[code] = true
+			codes = append(codes, code)
+		}
+	}
+	r.Coupons = codes
+	r.Currency = strings.ToUpper(r.Currency)
+	return nil
+}
+
+// QuoteLine prices a quoted item. UnitPrice is Converted when the product has no
+// price in the quote currency. PromotionID is the promotion behind Discount.
+type QuoteLine struct {
+	ProductID   string  `json:"product_id"`
+	VariantID   *string `json:"variant_id,omitempty"`
+	Name        string  `json:"name"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   Money   `json:"unit_price"`
+	Converted   bool    `json:"converted,omitempty"`
+	Subtotal    Money   `json:"subtotal"`
+	Discount    Money   `json:"discount"`
+	Total       Money   `json:"total"`
+	PromotionID *string `json:"promotion_id,omitempty"`
+}
+
+// AppliedPromotion totals the discount a promotion gave across the lines of a quote
+type AppliedPromotion struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	CouponCode *string `json:"coupon_code,omitempty"`
+	Discount   Money   `json:"discount"`
+}
+
+// RejectedCoupon is a coupon presented for a quote that did not apply
+type RejectedCoupon struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// Quote prices a list of items. Every line gets the single promotion that takes
+// the most off it; promotions do not stack.
+type Quote struct {
+	Currency        string              `json:"currency"`
+	Lines           []*QuoteLine        `json:"lines"`
+	Promotions      []*AppliedPromotion `json:"promotions"`
+	RejectedCoupons []*RejectedCoupon   `json:"rejected_coupons,omitempty"`
+	Subtotal        Money               `json:"subtotal"`
+	Discount        Money               `json:"discount"`
+	Total           Money               `json:"total"`
+}
+
+type PromotionResponse struct {
+	Success bool       `json:"success"`
+	Data    *Promotion `json:"data,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+type PromotionsResponse struct {
+	Success bool         `json:"success"`
+	Error   string       `json:"error,omitempty"`
+	Data    []*Promotion `json:"data"`
+}
+
+type QuoteResponse struct {
+	Success bool   `json:"success"`
+	Data    *Quote `json:"data,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
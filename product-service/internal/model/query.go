@@ -0,0 +1,317 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// ErrInvalidQuery is wrapped by every invalid filter, sort or pagination parameter
+var ErrInvalidQuery = errors.New("invalid query")
+
+// SortFields whitelists the fields products can be sorted by
+var SortFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"name":       true,
+	"price":      true,
+	"stock":      true,
+	// Only products in the trash can be sorted by deletion time
+	"deleted_at": true,
+	// Only scheduled products can be sorted by publication time
+	"publish_at": true,
+}
+
+// ProductFilter narrows listing and search results. Nil fields are not applied.
+type ProductFilter struct {
+	// Categories are category IDs or slugs
+	Categories []string
+	// IncludeDescendants also matches products in subcategories of Categories
+	IncludeDescendants bool
+	// Currency is the currency of MinPrice and MaxPrice. Products match on their
+	// fixed price in it; products without one are not matched by price bounds.
+	Currency      string
+	MinPrice      *int64
+	MaxPrice      *int64
+	InStock       *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Attributes are all matched
+	Attributes []AttributeFilter
+	// Statuses are the lifecycle statuses matched. Without any only active
+	// products are matched, except in the trash where every status is.
+	Statuses []string
+	// Deleted selects products in the trash instead of live ones
+	Deleted bool
+}
+
+// ProductListQuery selects one page of products, either by page number or by an
+// opaque cursor returned with a previous page
+type ProductListQuery struct {
+	Filter ProductFilter
+	Sort   string
+	Order  string
+	Page   int
+	Limit  int
+	Cursor string
+	Facets *FacetRequest
+	// Currency, when set, adds display prices in that currency
+	Currency string
+}
+
+// ProductPage is one page of products with what is needed to fetch its neighbours
+type ProductPage struct {
+	Products   []*Product
+	Total      int
+	Page       int
+	Limit      int
+	NextCursor string
+	PrevCursor string
+	Facets     Facets
+}
+
+// Normalize applies defaults and validates the query
+func (q *ProductListQuery) Normalize() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxPageLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageLimit)
+	}
+
+	if q.Cursor != "" && q.Page != 0 {
+		return fmt.Errorf("%w: use either page or cursor", ErrInvalidQuery)
+	}
+	if q.Page < 0 {
+		return fmt.Errorf("%w: page must be positive", ErrInvalidQuery)
+	}
+	if q.Cursor == "" && q.Page == 0 {
+		q.Page = 1
+	}
+
+	if q.Sort == "" {
+		q.Sort = "created_at"
+		if q.Filter.Deleted {
+			q.Sort = "deleted_at"
+		} else if q.Filter.OnlyScheduled() {
+			q.Sort = "publish_at"
+		}
+	}
+	if !SortFields[q.Sort] || (q.Sort == "deleted_at" && !q.Filter.Deleted) ||
+		(q.Sort == "publish_at" && !q.Filter.OnlyScheduled()) {
+		return fmt.Errorf("%w: can not sort by %q", ErrInvalidQuery, q.Sort)
+	}
+
+	switch q.Order {
+	case "":
+		q.Order = SortAsc
+		if q.Sort == "created_at" || q.Sort == "updated_at" || q.Sort == "deleted_at" {
+			q.Order = SortDesc
+		}
+	case SortAsc, SortDesc:
+	default:
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidQuery)
+	}
+
+	if q.Facets != nil {
+		if err := q.Facets.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return q.Filter.Validate()
+}
+
+// Validate rejects contradictory filters
+func (f *ProductFilter) Validate() error {
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return fmt.Errorf("%w: min_price is greater than max_price", ErrInvalidQuery)
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return fmt.Errorf("%w: created_after must be before created_before", ErrInvalidQuery)
+	}
+	for _, status := range f.Statuses {
+		if !ValidStatus(status) {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, status)
+		}
+	}
+	return nil
+}
+
+// OnlyScheduled reports whether the filter only matches scheduled products
+func (f *ProductFilter) OnlyScheduled() bool {
+	return len(f.Statuses) > 0 && !slices.ContainsFunc(f.Statuses, func(status string) bool {
+		return status != StatusScheduled
+	})
+}
+
+const MaxSearchQueryLength = 200
+
+// SortRelevance orders search results by rank, best match first
+const SortRelevance = "relevance"
+
+// ProductSearchQuery is a full-text search combined with the listing filters
+type ProductSearchQuery struct {
+	Query  string
+	Filter ProductFilter
+	Sort   string
+	Order  string
+	Page   int
+	Limit  int
+	Facets *FacetRequest
+	// Currency, when set, adds display prices in that currency
+	Currency string
+}
+
+// SearchResult is a matching product with its relevance and highlighted fields
+type SearchResult struct {
+	*Product
+	Rank       float64          `json:"rank"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights holds HTML escaped fields with matches wrapped in <mark> tags,
+// ready to be rendered as HTML
+type SearchHighlights struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SearchPage struct {
+	Results []*SearchResult
+	Total   int
+	Page    int
+	Limit   int
+	Facets  Facets
+}
+
+// SearchTerms splits a search query into the words it matches on
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Normalize applies defaults and validates the query
+func (q *ProductSearchQuery) Normalize() error {
+	q.Query = strings.TrimSpace(q.Query)
+	if q.Query == "" || len(SearchTerms(q.Query)) == 0 {
+		return fmt.Errorf("%w: q is required", ErrInvalidQuery)
+	}
+	if len(q.Query) > MaxSearchQueryLength {
+		return fmt.Errorf("%w: q must be at most %d characters", ErrInvalidQuery, MaxSearchQueryLength)
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxPageLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageLimit)
+	}
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.Page < 0 {
+		return fmt.Errorf("%w: page must be positive", ErrInvalidQuery)
+	}
+
+	if q.Sort == "" {
+		q.Sort = SortRelevance
+	}
+	if q.Sort != SortRelevance && (!SortFields[q.Sort] || q.Sort == "deleted_at" || q.Sort == "publish_at") {
+		return fmt.Errorf("%w: can not sort by %q", ErrInvalidQuery, q.Sort)
+	}
+
+	switch q.Order {
+	case "":
+		q.Order = SortDesc
+		if q.Sort == "name" || q.Sort == "price" || q.Sort == "stock" {
+			q.Order = SortAsc
+		}
+	case SortAsc, SortDesc:
+	default:
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidQuery)
+	}
+
+	if q.Facets != nil {
+		if err := q.Facets.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return q.Filter.Validate()
+}
+
+const (
+	FacetCategory = "category"
+	FacetPrice    = "price"
+	FacetStock    = "stock"
+)
+
+// FacetFields whitelists the facets that can be requested
+var FacetFields = map[string]bool{
+	FacetCategory: true,
+	FacetPrice:    true,
+	FacetStock:    true,
+}
+
+// FacetRequest asks for bucket counts alongside a listing or search. Counts use
+// the same filters as the results; with Disjunctive set each facet ignores its
+// own filter, so selecting one category still shows the counts of the others.
+type FacetRequest struct {
+	Fields      []string
+	Disjunctive bool
+}
+
+// PriceRange is a half-open price band [From, To). A nil bound is unbounded.
+type PriceRange struct {
+	From *Money `json:"from,omitempty"`
+	To   *Money `json:"to,omitempty"`
+}
+
+type FacetBucket struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+	From  *Money `json:"from,omitempty"`
+	To    *Money `json:"to,omitempty"`
+}
+
+// Facets maps each requested facet to its buckets
+type Facets map[string][]FacetBucket
+
+// Validate rejects unknown facets
+func (r *FacetRequest) Validate() error {
+	for _, field := range r.Fields {
+		if !FacetFields[field] {
+			return fmt.Errorf("%w: unknown facet %q", ErrInvalidQuery, field)
+		}
+	}
+	return nil
+}
+
+// WithoutFacet returns a copy of the filter without the condition facet buckets on
+func (f ProductFilter) WithoutFacet(facet string) ProductFilter {
+	switch facet {
+	case FacetCategory:
+		f.Categories = nil
+	case FacetPrice:
+		f.MinPrice, f.MaxPrice = nil, nil
+	case FacetStock:
+		f.InStock = nil
+	}
+	return f
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationNotActive = errors.New("reservation is no longer active")
+	ErrReservationExpired   = errors.New("reservation has expired")
+	ErrInvalidReservation   = errors.New("invalid reservation")
+)
+
+// Reservation statuses. Only active reservations hold stock; committing one turns
+// its units into sales, cancelling or expiring it releases them.
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationCancelled = "cancelled"
+	ReservationExpired   = "expired"
+)
+
+// Reservation holds stock of several products for a checkout until it is
+// committed, cancelled or expires
+type Reservation struct {
+	ID          string             `json:"id" db:"id"`
+	Status      string             `json:"status" db:"status"`
+	ReferenceID *string            `json:"reference_id,omitempty" db:"reference_id"`
+	Items       []*ReservationItem `json:"items"`
+	CreatedBy   *string            `json:"created_by,omitempty" db:"created_by"`
+	ExpiresAt   time.Time          `json:"expires_at" db:"expires_at"`
+	ClosedAt    *time.Time         `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// ReservationItem is a quantity of a product held by a reservation. Products
+// sold in variants are reserved per variant.
+type ReservationItem struct {
+	ProductID string  `json:"product_id" db:"product_id" validate:"required,max=255"`
+	VariantID *string `json:"variant_id,omitempty" db:"variant_id" validate:"omitempty,max=255"`
+	Quantity  int     `json:"quantity" db:"quantity" validate:"required,gt=0"`
+}
+
+// CreateReservationRequest reserves every item or none of them. TTLSeconds
+// defaults to the configured reservation TTL.
+type CreateReservationRequest struct {
+	Items       []*ReservationItem `json:"items" validate:"required,min=1,max=100,dive,required"`
+	ReferenceID string             `json:"reference_id" validate:"omitempty,max=255"`
+	TTLSeconds  int                `json:"ttl_seconds" validate:"gte=0"`
+	// CreatedBy is taken from the identity forwarded by the gateway, never from the body
+	CreatedBy string `json:"-"`
+}
+
+func (r *CreateReservationRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(r.Items))
+	for _, item := range r.Items {
+		key := item.ProductID
+		if item.VariantID != nil {
+			key += "/" + *item.VariantID
+		}
+		if seen[key] {
+			return fmt.Errorf("%w: product %s is listed more than once", ErrInvalidReservation, item.ProductID)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+type ReservationResponse struct {
+	Success bool         `json:"success"`
+	Data    *Reservation `json:"data,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
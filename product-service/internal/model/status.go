@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidStatus    = errors.New("invalid status")
+	ErrStatusTransition = errors.New("status transition not allowed")
+)
+
+// Product lifecycle statuses. Only active products are listed publicly.
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusActive    = "active"
+	StatusArchived  = "archived"
+)
+
+// StatusTransitions lists the statuses a product may move to from each status.
+// Scheduled and active products may keep their status to change their dates.
+var StatusTransitions = map[string][]string{
+	StatusDraft:     {StatusScheduled, StatusActive, StatusArchived},
+	StatusScheduled: {StatusDraft, StatusScheduled, StatusActive, StatusArchived},
+	StatusActive:    {StatusDraft, StatusActive, StatusArchived},
+	StatusArchived:  {StatusDraft, StatusActive},
+}
+
+// ValidStatus reports whether status is a lifecycle status
+func ValidStatus(status string) bool {
+	_, ok := StatusTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether a product may move from one status to another
+func CanTransition(from, to string) bool {
+	return slices.Contains(StatusTransitions[from], to)
+}
+
+// StatusRequest moves a product to a status. Scheduled products need a future
+// PublishAt; scheduled and active ones may end at UnpublishAt, when they are
+// archived. The dates of active and archived products are set when they go
+// live and are archived.
+type StatusRequest struct {
+	Status      string     `json:"status" validate:"required"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// Validate checks the request on its own at now; whether the product may move
+// to the status is checked against its current status
+func (r *StatusRequest) Validate(now time.Time) error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	if !ValidStatus(r.Status) {
+		return fmt.Errorf("%w: status must be one of draft, scheduled, active or archived", ErrInvalidStatus)
+	}
+
+	if r.PublishAt != nil && r.Status != StatusScheduled {
+		return fmt.Errorf("%w: only scheduled products take publish_at", ErrInvalidStatus)
+	}
+	if r.Status == StatusScheduled && (r.PublishAt == nil || !r.PublishAt.After(now)) {
+		return fmt.Errorf("%w: scheduled products need a publish_at in the future", ErrInvalidStatus)
+	}
+
+	if r.UnpublishAt == nil {
+		return nil
+	}
+	if r.Status != StatusScheduled && r.Status != StatusActive {
+		return fmt.Errorf("%w: only scheduled and active products take unpublish_at", ErrInvalidStatus)
+	}
+	if !r.UnpublishAt.After(now) || (r.PublishAt != nil && !r.UnpublishAt.After(*r.PublishAt)) {
+		return fmt.Errorf("%w: unpublish_at must be in the future and after publish_at", ErrInvalidStatus)
+	}
+	return nil
+}
+
+// Schedule returns the publish_at and unpublish_at a product moving from status
+// current, published at publishedAt, is stored with
+func (r *StatusRequest) Schedule(current string, publishedAt *time.Time, now time.Time) (publishAt, unpublishAt *time.Time) {
+	switch r.Status {
+	case StatusScheduled:
+		return r.PublishAt, r.UnpublishAt
+	case StatusActive:
+		if current == StatusActive && publishedAt != nil {
+			return publishedAt, r.UnpublishAt
+		}
+		return &now, r.UnpublishAt
+	case StatusArchived:
+		if current == StatusActive {
+			return publishedAt, &now
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrVariantRequired is returned when adjusting the stock of a product sold in
+	// variants without naming the variant
+	ErrVariantRequired = errors.New("product is sold in variants, variant_id is required")
+)
+
+// Reasons stock changes. Initial is only recorded for the stock a product or
+// variant is created with.
+const (
+	StockReasonInitial    = "initial"
+	StockReasonReceipt    = "receipt"
+	StockReasonSale       = "sale"
+	StockReasonReturn     = "return"
+	StockReasonCorrection = "correction"
+)
+
+const (
+	DefaultStockMovementLimit = 50
+	MaxStockMovementLimit     = 200
+)
+
+// StockMovement is an entry of the stock ledger. StockAfter is the stock of the
+// product, across all its variants, after the movement.
+type StockMovement struct {
+	ID          int64     `json:"id" db:"id"`
+	ProductID   string    `json:"product_id" db:"product_id"`
+	VariantID   *string   `json:"variant_id,omitempty" db:"variant_id"`
+	Delta       int       `json:"delta" db:"delta"`
+	Reason      string    `json:"reason" db:"reason"`
+	ReferenceID *string   `json:"reference_id,omitempty" db:"reference_id"`
+	ActorID     *string   `json:"actor_id,omitempty" db:"actor_id"`
+	StockAfter  int       `json:"stock_after" db:"stock_after"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// StockAdjustmentRequest changes the stock of a product by Delta. Receipts and
+// returns add stock, sales remove it and corrections go either way. The stock of
+// a product sold in variants is adjusted per variant.
+type StockAdjustmentRequest struct {
+	Delta       int    `json:"delta" validate:"required"`
+	Reason      string `json:"reason" validate:"required,oneof=receipt sale return correction"`
+	ReferenceID string `json:"reference_id" validate:"omitempty,max=255"`
+	VariantID   string `json:"variant_id" validate:"omitempty,max=255"`
+	// ActorID is taken from the identity forwarded by the gateway, never from the body
+	ActorID string `json:"-"`
+}
+
+func (r *StockAdjustmentRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+
+	switch {
+	case (r.Reason == StockReasonReceipt || r.Reason == StockReasonReturn) && r.Delta < 0:
+		return fmt.Errorf("%w: a %s must add stock", ErrInvalidStock, r.Reason)
+	case r.Reason == StockReasonSale && r.Delta > 0:
+		return fmt.Errorf("%w: a sale must remove stock", ErrInvalidStock)
+	}
+	return nil
+}
+
+// StockMovementQuery pages through the ledger of a product from the newest
+// movement. Before is the ID of the last movement of the previous page.
+type StockMovementQuery struct {
+	VariantID string
+	Before    int64
+	Limit     int
+}
+
+// Normalize applies defaults and validates the query
+func (q *StockMovementQuery) Normalize() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultStockMovementLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxStockMovementLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxStockMovementLimit)
+	}
+	if q.Before < 0 {
+		return fmt.Errorf("%w: before must be a movement ID", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// StockHistory is a page of the ledger of a product. Stock is the stored stock
+// and LedgerStock the sum of every movement; they differ only if the stock was
+// changed around the ledger.
+type StockHistory struct {
+	Stock       int
+	LedgerStock int
+	Movements   []*StockMovement
+	// NextBefore fetches the next, older page; zero on the last page
+	NextBefore int64
+}
+
+type StockMovementResponse struct {
+	Success bool           `json:"success"`
+	Data    *StockMovement `json:"data,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type StockHistoryResponse struct {
+	Success     bool             `json:"success"`
+	Error       string           `json:"error,omitempty"`
+	Data        []*StockMovement `json:"data"`
+	Stock       int              `json:"stock"`
+	LedgerStock int              `json:"ledger_stock"`
+	NextBefore  int64            `json:"next_before,omitempty"`
+}
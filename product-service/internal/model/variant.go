@@ -0,0 +1,136 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrVariantNotFound      = errors.New("variant not found")
+	ErrDuplicateSKU         = errors.New("sku already exists")
+	ErrDuplicateBarcode     = errors.New("barcode already exists")
+	ErrDuplicateVariant     = errors.New("a variant with these options already exists")
+	ErrInvalidVariantOption = errors.New("invalid variant options")
+	ErrVariantOptionsInUse  = errors.New("variant options are used by existing variants")
+)
+
+// VariantOption defines one dimension products vary in, e.g. size or colour
+type VariantOption struct {
+	Name   string   `json:"name" validate:"required,min=1,max=50"`
+	Values []string `json:"values" validate:"required,min=1,dive,required,max=50"`
+}
+
+// ProductVariant is a purchasable combination of option values with its own SKU.
+// Price overrides the product price when set and is in the product's currency.
+type ProductVariant struct {
+	ID             string            `json:"id" db:"id"`
+	ProductID      string            `json:"product_id" db:"product_id"`
+	SKU            string            `json:"sku" db:"sku"`
+	Options        map[string]string `json:"options" db:"options"`
+	Price          *Money            `json:"price,omitempty" db:"price"`
+	EffectivePrice Money             `json:"effective_price"`
+	Stock          int               `json:"stock" db:"stock"`
+	Available      int               `json:"available" db:"available"`
+	Barcode        *string           `json:"barcode,omitempty" db:"barcode"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// VariantSummary aggregates the variants of a product for listings. The product
+// stock of a product sold in variants is the total across its variants.
+type VariantSummary struct {
+	Count    int   `json:"count"`
+	MinPrice Money `json:"min_price"`
+	MaxPrice Money `json:"max_price"`
+}
+
+type SetVariantOptionsRequest struct {
+	Options []VariantOption `json:"options" validate:"dive"`
+}
+
+// VariantRequest creates a variant or replaces all of its fields. Stock is the
+// initial stock of a new variant; afterwards it is read-only, kept when omitted
+// and rejected when different, and changes through stock adjustments.
+type VariantRequest struct {
+	SKU     string            `json:"sku" validate:"required,min=1,max=64"`
+	Options map[string]string `json:"options"`
+	Price   *Money            `json:"price"`
+	Stock   *int              `json:"stock" validate:"omitempty,gte=0"`
+	Barcode string            `json:"barcode" validate:"omitempty,max=64"`
+	// ActorID is taken from the identity forwarded by the gateway, never from the body
+	ActorID string `json:"-"`
+}
+
+type VariantResponse struct {
+	Success bool            `json:"success"`
+	Data    *ProductVariant `json:"data,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type VariantsResponse struct {
+	Success bool              `json:"success"`
+	Error   string            `json:"error,omitempty"`
+	Data    []*ProductVariant `json:"data"`
+}
+
+func (r *SetVariantOptionsRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(r.Options))
+	for _, option := range r.Options {
+		if names[option.Name] {
+			return fmt.Errorf("%w: option %q is defined twice", ErrInvalidVariantOption, option.Name)
+		}
+		names[option.Name] = true
+
+		values := make(map[string]bool, len(option.Values))
+		for _, value := range option.Values {
+			if values[value] {
+				return fmt.Errorf("%w: option %q lists %q twice", ErrInvalidVariantOption, option.Name, value)
+			}
+			values[value] = true
+		}
+	}
+
+	return nil
+}
+
+func (r *VariantRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	if r.Price != nil {
+		return r.Price.Validate()
+	}
+	return nil
+}
+
+// MatchVariantOptions checks that selected holds exactly one allowed value for every defined option
+func MatchVariantOptions(definitions []VariantOption, selected map[string]string) error {
+	if len(selected) != len(definitions) {
+		return fmt.Errorf("%w: a value is required for each of the %d product options", ErrInvalidVariantOption, len(definitions))
+	}
+
+	for _, definition := range definitions {
+		value, ok := selected[definition.Name]
+		if !ok {
+			return fmt.Errorf("%w: missing option %q", ErrInvalidVariantOption, definition.Name)
+		}
+
+		allowed := false
+		for _, candidate := range definition.Values {
+			if candidate == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %q is not a value of option %q", ErrInvalidVariantOption, value, definition.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+// alertColumns selects alerts as a joined to their product as p, in scanStockAlert order
+const alertColumns = `a.id, a.product_id, p.name, p.sku, a.status, a.threshold, a.stock, a.attempts, a.last_error,
+        a.notified_at, a.acknowledged_by, a.acknowledged_at, a.resolved_at, a.created_at, a.updated_at`
+
+// lowStock matches products p with alerts enabled whose stock is at or below the threshold
+const lowStock = `p.deleted_at IS NULL AND p.reorder_threshold IS NOT NULL AND p.stock <= p.reorder_threshold`
+
+type AlertRepository interface {
+	// Evaluate raises an alert for every product that fell to its reorder
+	// threshold and resolves the alerts of products above it again. A product
+	// falling back within cooldown of its last alert being resolved reopens that
+	// alert, which is not delivered again. It returns how many alerts were
+	// raised or reopened and how many were resolved.
+	Evaluate(now time.Time, cooldown time.Duration) (raised, resolved int64, err error)
+	// Deliver passes up to limit undelivered alerts to deliver and records the
+	// outcome. Alerts that failed maxAttempts times are given up on.
+	Deliver(limit, maxAttempts int, deliver func(*model.StockAlert) error) (delivered, failed int, err error)
+	GetByID(id int64) (*model.StockAlert, error)
+	List(query *model.StockAlertQuery) ([]*model.StockAlert, int64, error)
+	// Acknowledge marks an open alert as seen by actorID
+	Acknowledge(id int64, actorID string) (*model.StockAlert, error)
+}
+
+type alertRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewAlertRepository(db *sql.DB, logger *zap.SugaredLogger) AlertRepository {
+	return &alertRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func scanStockAlert(row rowScanner) (*model.StockAlert, error) {
+	alert := &model.StockAlert{}
+	err := row.Scan(
+		&alert.ID, &alert.ProductID, &alert.ProductName, &alert.ProductSKU, &alert.Status, &alert.Threshold,
+		&alert.Stock, &alert.Attempts, &alert.LastError, &alert.NotifiedAt, &alert.AcknowledgedBy,
+		&alert.AcknowledgedAt, &alert.ResolvedAt, &alert.CreatedAt, &alert.UpdatedAt,
+	)
+	return alert, err
+}
+
+func (r *alertRepository) Evaluate(now time.Time, cooldown time.Duration) (int64, int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+        UPDATE stock_alerts a
+        SET status = $1, resolved_at = $2, updated_at = $2
+        FROM products p
+        WHERE p.id = a.product_id AND a.status <> $1 AND NOT (`+lowStock+`)`,
+		model.AlertResolved, now)
+	if err != nil {
+		r.logger.Errorw("Failed to resolve stock alerts", "error", err)
+		return 0, 0, fmt.Errorf("failed to resolve stock alerts: %w", err)
+	}
+	resolved, _ := result.RowsAffected()
+
+	// The newest alert of a product is the only one that can be unresolved
+	result, err = tx.Exec(`
+        UPDATE stock_alerts a
+        SET status = $1, threshold = p.reorder_threshold, stock = p.stock, acknowledged_by = NULL,
+            acknowledged_at = NULL, resolved_at = NULL, updated_at = $2
+        FROM products p
+        WHERE p.id = a.product_id AND `+lowStock+`
+          AND a.status = $3 AND a.resolved_at > $4
+          AND a.id = (SELECT MAX(id) FROM stock_alerts WHERE product_id = p.id)`,
+		model.AlertOpen, now, model.AlertResolved, now.Add(-cooldown))
+	if err != nil {
+		r.logger.Errorw("Failed to reopen stock alerts", "error", err)
+		return 0, 0, fmt.Errorf("failed to reopen stock alerts: %w", err)
+	}
+	reopened, _ := result.RowsAffected()
+
+	result, err = tx.Exec(`
+        INSERT INTO stock_alerts (product_id, status, threshold, stock, created_at, updated_at)
+        SELECT p.id, $1, p.reorder_threshold, p.stock, $2, $2
+        FROM products p
+        WHERE `+lowStock+`
+          AND NOT EXISTS (SELECT 1 FROM stock_alerts a WHERE a.product_id = p.id AND a.status <> $3)
+        ON CONFLICT (product_id) WHERE status <> 'resolved' DO NOTHING`,
+		model.AlertOpen, now, model.AlertResolved)
+	if err != nil {
+		r.logger.Errorw("Failed to raise stock alerts", "error", err)
+		return 0, 0, fmt.Errorf("failed to raise stock alerts: %w", err)
+	}
+	raised, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to evaluate stock alerts: %w", err)
+	}
+	return raised + reopened, resolved, nil
+}
+
+func (r *alertRepository) Deliver(limit, maxAttempts int, deliver func(*model.StockAlert) error) (int, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locked alerts are being delivered by another instance
+	rows, err := tx.Query(`
+        SELECT `+alertColumns+`
+        FROM stock_alerts a JOIN products p ON p.id = a.product_id
+        WHERE a.notified_at IS NULL AND a.status <> $1 AND a.attempts < $2
+        ORDER BY a.id
+        LIMIT $3
+        FOR UPDATE OF a SKIP LOCKED`, model.AlertResolved, maxAttempts, limit)
+	if err != nil {
+		r.logger.Errorw("Failed to claim stock alerts", "error", err)
+		return 0, 0, fmt.Errorf("failed to claim stock alerts: %w", err)
+	}
+
+	alerts := []*model.StockAlert{}
+	for rows.Next() {
+		alert, err := scanStockAlert(rows)
+		if err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan stock alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("error iterating stock alerts: %w", err)
+	}
+
+	delivered, failed := 0, 0
+	for _, alert := range alerts {
+		var lastError *string
+		var notifiedAt *time.Time
+		if err := deliver(alert); err != nil {
+			message := err.Error()
+			lastError = &message
+			failed++
+		} else {
+			now := time.Now()
+			notifiedAt = &now
+			delivered++
+		}
+
+		query := `UPDATE stock_alerts SET attempts = attempts + 1, last_error = $1, notified_at = $2, updated_at = $3 WHERE id = $4`
+		if _, err := tx.Exec(query, lastError, notifiedAt, time.Now(), alert.ID); err != nil {
+			r.logger.Errorw("Failed to record stock alert delivery", "error", err, "alert_id", alert.ID)
+			return 0, 0, fmt.Errorf("failed to record stock alert delivery: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to record stock alert delivery: %w", err)
+	}
+	return delivered, failed, nil
+}
+
+func (r *alertRepository) GetByID(id int64) (*model.StockAlert, error) {
+	return r.get(r.db, id)
+}
+
+func (r *alertRepository) get(db queryRower, id int64) (*model.StockAlert, error) {
+	query := `SELECT ` + alertColumns + ` FROM stock_alerts a JOIN products p ON p.id = a.product_id WHERE a.id = $1`
+	alert, err := scanStockAlert(db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrAlertNotFound
+		}
+		r.logger.Errorw("Failed to get stock alert", "error", err, "alert_id", id)
+		return nil, fmt.Errorf("failed to get stock alert: %w", err)
+	}
+	return alert, nil
+}
+
+func (r *alertRepository) List(query *model.StockAlertQuery) ([]*model.StockAlert, int64, error) {
+	b := &queryBuilder{}
+	if query.Status != "" {
+		b.where("a.status = " + b.arg(query.Status))
+	}
+	if query.ProductID != "" {
+		b.where("a.product_id = " + b.arg(query.ProductID))
+	}
+	if query.Before > 0 {
+		b.where("a.id < " + b.arg(query.Before))
+	}
+
+	rows, err := r.db.Query(`SELECT `+alertColumns+` FROM stock_alerts a JOIN products p ON p.id = a.product_id`+
+		b.whereClause()+` ORDER BY a.id DESC LIMIT `+b.arg(query.Limit+1), b.args...)
+	if err != nil {
+		r.logger.Errorw("Failed to list stock alerts", "error", err)
+		return nil, 0, fmt.Errorf("failed to list stock alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []*model.StockAlert{}
+	for rows.Next() {
+		alert, err := scanStockAlert(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan stock alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating stock alerts: %w", err)
+	}
+
+	var nextBefore int64
+	if len(alerts) > query.Limit {
+		alerts = alerts[:query.Limit]
+		nextBefore = alerts[query.Limit-1].ID
+	}
+	return alerts, nextBefore, nil
+}
+
+func (r *alertRepository) Acknowledge(id int64, actorID string) (*model.StockAlert, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow(`SELECT status FROM stock_alerts WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrAlertNotFound
+		}
+		return nil, fmt.Errorf("failed to lock stock alert: %w", err)
+	}
+
+	// Acknowledging twice keeps the first acknowledgement
+	switch status {
+	case model.AlertResolved:
+		return nil, model.ErrAlertResolved
+	case model.AlertOpen:
+		query := `
+            UPDATE stock_alerts
+            SET status = $1, acknowledged_by = NULLIF($2, ''), acknowledged_at = $3, updated_at = $3
+            WHERE id = $4`
+		if _, err := tx.Exec(query, model.AlertAcknowledged, actorID, time.Now(), id); err != nil {
+			r.logger.Errorw("Failed to acknowledge stock alert", "error", err, "alert_id", id)
+			return nil, fmt.Errorf("failed to acknowledge stock alert: %w", err)
+		}
+	}
+
+	alert, err := r.get(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to acknowledge stock alert: %w", err)
+	}
+
+	r.logger.Infow("Stock alert acknowledged", "alert_id", id, "product_id", alert.ProductID, "actor_id", actorID)
+	return alert, nil
+}
@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// attributeColumns selects attribute definitions as d, in scanAttribute order
+const attributeColumns = `d.id, d.code, d.name, d.type, d.unit, d.enum_values, d.created_at, d.updated_at`
+
+func scanAttribute(row rowScanner, extra ...interface{}) (*model.AttributeDefinition, error) {
+	attribute := &model.AttributeDefinition{}
+	dest := append([]interface{}{
+		&attribute.ID, &attribute.Code, &attribute.Name, &attribute.Type, &attribute.Unit,
+		pq.Array(&attribute.Values), &attribute.CreatedAt, &attribute.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return attribute, nil
+}
+
+type AttributeRepository interface {
+	Create(req *model.AttributeRequest) (*model.AttributeDefinition, error)
+	GetByID(id string) (*model.AttributeDefinition, error)
+	// GetByCodes returns the attributes of codes that exist
+	GetByCodes(codes []string) ([]*model.AttributeDefinition, error)
+	// List returns every attribute in code order
+	List() ([]*model.AttributeDefinition, error)
+	// Update replaces the name, unit and enum values of an attribute. Enum values
+	// products still have can not be removed.
+	Update(id string, req *model.AttributeRequest) (*model.AttributeDefinition, error)
+	// Delete removes an attribute no product, including those in the trash, has a value of
+	Delete(id string) error
+	// CategoryAttributes returns the attributes assigned to a category or to one
+	// of its ancestors, in code order. An attribute is required when any of them
+	// requires it.
+	CategoryAttributes(categoryID string) ([]*model.CategoryAttribute, error)
+	// SetCategoryAttributes replaces the attributes assigned to a category
+	SetCategoryAttributes(categoryID string, attributes []model.CategoryAttributeRequest) error
+}
+
+type attributeRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewAttributeRepository(db *sql.DB, logger *zap.SugaredLogger) AttributeRepository {
+	return &attributeRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// translateAttributeError maps constraint violations of attribute writes to model errors, or returns nil
+func translateAttributeError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "idx_attribute_definitions_code" {
+		return model.ErrDuplicateAttribute
+	}
+	return nil
+}
+
+func (r *attributeRepository) Create(req *model.AttributeRequest) (*model.AttributeDefinition, error) {
+	query := `
+        INSERT INTO attribute_definitions AS d (id, code, name, type, unit, enum_values, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+        RETURNING ` + attributeColumns
+
+	attribute, err := scanAttribute(r.db.QueryRow(
+		query, uuid.New().String(), req.Code, req.Name, req.Type, req.Unit, pq.Array(enumValues(req.Values)), time.Now(),
+	))
+	if err != nil {
+		if err := translateAttributeError(err); err != nil {
+			return nil, err
+		}
+		r.logger.Errorw("Failed to create attribute", "error", err, "code", req.Code)
+		return nil, fmt.Errorf("failed to create attribute: %w", err)
+	}
+	return attribute, nil
+}
+
+func (r *attributeRepository) GetByID(id string) (*model.AttributeDefinition, error) {
+	return r.get(r.db, id, "")
+}
+
+func (r *attributeRepository) get(db queryRower, id, lock string) (*model.AttributeDefinition, error) {
+	query := `SELECT ` + attributeColumns + ` FROM attribute_definitions d WHERE d.id = $1 ` + lock
+	attribute, err := scanAttribute(db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrAttributeNotFound
+		}
+		r.logger.Errorw("Failed to get attribute", "error", err, "attribute_id", id)
+		return nil, fmt.Errorf("failed to get attribute: %w", err)
+	}
+	return attribute, nil
+}
+
+func (r *attributeRepository) GetByCodes(codes []string) ([]*model.AttributeDefinition, error) {
+	query := `SELECT ` + attributeColumns + ` FROM attribute_definitions d WHERE d.code = ANY($1) ORDER BY d.code`
+	return r.query(query, pq.Array(codes))
+}
+
+func (r *attributeRepository) List() ([]*model.AttributeDefinition, error) {
+	return r.query(`SELECT ` + attributeColumns + ` FROM attribute_definitions d ORDER BY d.code`)
+}
+
+func (r *attributeRepository) query(query string, args ...interface{}) ([]*model.AttributeDefinition, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Errorw("Failed to get attributes", "error", err)
+		return nil, fmt.Errorf("failed to get attributes: %w", err)
+	}
+	defer rows.Close()
+
+	attributes := []*model.AttributeDefinition{}
+	for rows.Next() {
+		attribute, err := scanAttribute(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attribute: %w", err)
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attributes: %w", err)
+	}
+	return attributes, nil
+}
+
+func (r *attributeRepository) Update(id string, req *model.AttributeRequest) (*model.AttributeDefinition, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := r.get(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if req.Code != current.Code || req.Type != current.Type {
+		return nil, fmt.Errorf("%w: the code and type of an attribute can not change", model.ErrInvalidAttribute)
+	}
+
+	var removed []string
+	for _, value := range current.Values {
+		if !slices.Contains(req.Values, value) {
+			removed = append(removed, value)
+		}
+	}
+	if len(removed) > 0 {
+		var used []string
+		query := `
+            SELECT DISTINCT attributes ->> $1 FROM products
+            WHERE attributes ->> $1 = ANY($2)
+            ORDER BY 1`
+		rows, err := tx.Query(query, current.Code, pq.Array(removed))
+		if err != nil {
+			r.logger.Errorw("Failed to check attribute values", "error", err, "attribute_id", id)
+			return nil, fmt.Errorf("failed to check attribute values: %w", err)
+		}
+		for rows.Next() {
+			var value string
+			if err := rows.Scan(&value); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to check attribute values: %w", err)
+			}
+			used = append(used, value)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to check attribute values: %w", err)
+		}
+		if len(used) > 0 {
+			return nil, fmt.Errorf("%w: products still have the values %q", model.ErrAttributeInUse, used)
+		}
+	}
+
+	query := `
+        UPDATE attribute_definitions d
+        SET name = $1, unit = $2, enum_values = $3, updated_at = $4
+        WHERE d.id = $5
+        RETURNING ` + attributeColumns
+
+	attribute, err := scanAttribute(tx.QueryRow(query, req.Name, req.Unit, pq.Array(enumValues(req.Values)), time.Now(), id))
+	if err != nil {
+		r.logger.Errorw("Failed to update attribute", "error", err, "attribute_id", id)
+		return nil, fmt.Errorf("failed to update attribute: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update attribute: %w", err)
+	}
+	return attribute, nil
+}
+
+func (r *attributeRepository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	attribute, err := r.get(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	var used int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM products WHERE attributes ? $1`, attribute.Code).Scan(&used); err != nil {
+		r.logger.Errorw("Failed to check attribute use", "error", err, "attribute_id", id)
+		return fmt.Errorf("failed to check attribute use: %w", err)
+	}
+	if used > 0 {
+		return fmt.Errorf("%w: %d products have a value of %s", model.ErrAttributeInUse, used, attribute.Code)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM attribute_definitions WHERE id = $1`, id); err != nil {
+		r.logger.Errorw("Failed to delete attribute", "error", err, "attribute_id", id)
+		return fmt.Errorf("failed to delete attribute: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete attribute: %w", err)
+	}
+	return nil
+}
+
+func (r *attributeRepository) CategoryAttributes(categoryID string) ([]*model.CategoryAttribute, error) {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)`, categoryID).Scan(&exists); err != nil {
+		r.logger.Errorw("Failed to get category", "error", err, "category_id", categoryID)
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if !exists {
+		return nil, model.ErrCategoryNotFound
+	}
+
+	// The nearest category requiring an attribute is reported, else the nearest assigning it
+	query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id, 0 AS depth FROM categories WHERE id = $1
+            UNION ALL
+            SELECT c.id, c.parent_id, a.depth + 1 FROM categories c JOIN ancestors a ON c.id = a.parent_id
+        )
+        SELECT * FROM (
+            SELECT DISTINCT ON (d.id) ` + attributeColumns + `, ca.required, ca.category_id
+            FROM ancestors a
+            JOIN category_attributes ca ON ca.category_id = a.id
+            JOIN attribute_definitions d ON d.id = ca.attribute_id
+            ORDER BY d.id, ca.required DESC, a.depth
+        ) assigned
+        ORDER BY code`
+
+	rows, err := r.db.Query(query, categoryID)
+	if err != nil {
+		r.logger.Errorw("Failed to get category attributes", "error", err, "category_id", categoryID)
+		return nil, fmt.Errorf("failed to get category attributes: %w", err)
+	}
+	defer rows.Close()
+
+	attributes := []*model.CategoryAttribute{}
+	for rows.Next() {
+		assigned := &model.CategoryAttribute{}
+		if assigned.Attribute, err = scanAttribute(rows, &assigned.Required, &assigned.CategoryID); err != nil {
+			return nil, fmt.Errorf("failed to scan category attribute: %w", err)
+		}
+		attributes = append(attributes, assigned)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating category attributes: %w", err)
+	}
+	return attributes, nil
+}
+
+func (r *attributeRepository) SetCategoryAttributes(categoryID string, attributes []model.CategoryAttributeRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked string
+	if err := tx.QueryRow(`SELECT id FROM categories WHERE id = $1 FOR UPDATE`, categoryID).Scan(&locked); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrCategoryNotFound
+		}
+		r.logger.Errorw("Failed to lock category", "error", err, "category_id", categoryID)
+		return fmt.Errorf("failed to lock category: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM category_attributes WHERE category_id = $1`, categoryID); err != nil {
+		r.logger.Errorw("Failed to clear category attributes", "error", err, "category_id", categoryID)
+		return fmt.Errorf("failed to clear category attributes: %w", err)
+	}
+
+	for _, attribute := range attributes {
+		query := `
+            INSERT INTO category_attributes (category_id, attribute_id, required)
+            SELECT $1, id, $2 FROM attribute_definitions WHERE code = $3`
+		result, err := tx.Exec(query, categoryID, attribute.Required, attribute.Code)
+		if err != nil {
+			r.logger.Errorw("Failed to assign category attribute", "error", err, "category_id", categoryID)
+			return fmt.Errorf("failed to assign category attribute: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return fmt.Errorf("%w: unknown attribute %s", model.ErrInvalidAttribute, attribute.Code)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to set category attributes: %w", err)
+	}
+	return nil
+}
+
+func enumValues(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// inCategorySubtree is a checkRequiredAttributes condition matching the products
+// in the category $1 and its descendants
+const inCategorySubtree = `p.category_id IN (
+                WITH RECURSIVE subtree AS (
+                    SELECT id FROM categories WHERE id = $1
+                    UNION ALL
+                    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+                )
+                SELECT id FROM subtree)`
+
+// checkRequiredAttributes fails with ErrInvalidAttribute when a product matching
+// condition, on products p, lacks an attribute its category or one of the
+// category's ancestors requires. Writers that place products in categories
+// without going through the product service call it before committing.
+func checkRequiredAttributes(q queryRower, condition string, args ...interface{}) error {
+	query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT p.id AS product_id, c.id AS category_id, c.parent_id
+            FROM products p JOIN categories c ON c.id = p.category_id
+            WHERE ` + condition + `
+            UNION ALL
+            SELECT a.product_id, c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+        )
+        SELECT p.name, d.code
+        FROM ancestors a
+        JOIN category_attributes ca ON ca.category_id = a.category_id AND ca.required
+        JOIN attribute_definitions d ON d.id = ca.attribute_id
+        JOIN products p ON p.id = a.product_id
+        WHERE NOT (p.attributes ? d.code)
+        LIMIT 1`
+
+	var name, code string
+	err := q.QueryRow(query, args...).Scan(&name, &code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check required attributes: %w", err)
+	}
+	return fmt.Errorf("%w: %s is required in the category of %q", model.ErrInvalidAttribute, code, name)
+}
@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+const categoryColumns = `id, parent_id, name, slug, sort_order, created_at, updated_at`
+
+func scanCategory(row rowScanner) (*model.Category, error) {
+	category := &model.Category{}
+	err := row.Scan(
+		&category.ID, &category.ParentID, &category.Name, &category.Slug,
+		&category.SortOrder, &category.CreatedAt, &category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+type CategoryRepository interface {
+	Create(req *model.CreateCategoryRequest) (*model.Category, error)
+	GetByID(id string) (*model.Category, error)
+	// GetAll returns every category ordered by sort order and name
+	GetAll() ([]*model.Category, error)
+	Update(id string, req *model.UpdateCategoryRequest) (*model.Category, error)
+	Move(id string, parentID *string) (*model.Category, error)
+	// Delete removes a category. Without reassignTo it fails with ErrCategoryNotEmpty
+	// when the category still has products or subcategories; otherwise they are
+	// moved to the category reassignTo, or to the parent for model.ReassignToParent.
+	Delete(id string, reassignTo string) error
+}
+
+type categoryRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewCategoryRepository(db *sql.DB, logger *zap.SugaredLogger) CategoryRepository {
+	return &categoryRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *categoryRepository) Create(req *model.CreateCategoryRequest) (*model.Category, error) {
+	now := time.Now()
+	query := `
+        INSERT INTO categories (id, parent_id, name, slug, sort_order, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)
+        RETURNING ` + categoryColumns
+
+	category, err := scanCategory(r.db.QueryRow(query, uuid.New().String(), req.ParentID, req.Name, req.Slug, req.SortOrder, now))
+	if err != nil {
+		if err := r.translate(err); err != nil {
+			return nil, err
+		}
+		r.logger.Errorw("Failed to create category", "error", err, "name", req.Name)
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	r.logger.Infow("Category created successfully", "category_id", category.ID)
+	return category, nil
+}
+
+func (r *categoryRepository) GetByID(id string) (*model.Category, error) {
+	category, err := scanCategory(r.db.QueryRow(`SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrCategoryNotFound
+		}
+		r.logger.Errorw("Failed to get category", "error", err, "category_id", id)
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return category, nil
+}
+
+func (r *categoryRepository) GetAll() ([]*model.Category, error) {
+	rows, err := r.db.Query(`SELECT ` + categoryColumns + ` FROM categories ORDER BY sort_order, name`)
+	if err != nil {
+		r.logger.Errorw("Failed to get categories", "error", err)
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []*model.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (r *categoryRepository) Update(id string, req *model.UpdateCategoryRequest) (*model.Category, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        UPDATE categories
+        SET name = COALESCE($1, name),
+            slug = COALESCE($2, slug),
+            sort_order = COALESCE($3, sort_order),
+            updated_at = $4
+        WHERE id = $5
+        RETURNING ` + categoryColumns
+
+	category, err := scanCategory(tx.QueryRow(query, req.Name, req.Slug, req.SortOrder, time.Now(), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrCategoryNotFound
+		}
+		if err := r.translate(err); err != nil {
+			return nil, err
+		}
+		r.logger.Errorw("Failed to update category", "error", err, "category_id", id)
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	// Keep the denormalized name products are searched by in sync
+	if req.Name != nil {
+		if _, err := tx.Exec(`UPDATE products SET category = $1 WHERE category_id = $2`, category.Name, id); err != nil {
+			return nil, fmt.Errorf("failed to rename product categories: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	r.logger.Infow("Category updated successfully", "category_id", id)
+	return category, nil
+}
+
+func (r *categoryRepository) Move(id string, parentID *string) (*model.Category, error) {
+	tx, err := r.lockTree()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if parentID != nil {
+		inside, err := r.inSubtree(tx, *parentID, id)
+		if err != nil {
+			return nil, err
+		}
+		if inside {
+			return nil, model.ErrCategoryCycle
+		}
+	}
+
+	query := `UPDATE categories SET parent_id = $1, updated_at = $2 WHERE id = $3 RETURNING ` + categoryColumns
+	category, err := scanCategory(tx.QueryRow(query, parentID, time.Now(), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrCategoryNotFound
+		}
+		if err := r.translate(err); err != nil {
+			return nil, err
+		}
+		r.logger.Errorw("Failed to move category", "error", err, "category_id", id)
+		return nil, fmt.Errorf("failed to move category: %w", err)
+	}
+	if err := checkRequiredAttributes(tx, inCategorySubtree, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to move category: %w", err)
+	}
+
+	r.logger.Infow("Category moved successfully", "category_id", id, "parent_id", parentID)
+	return category, nil
+}
+
+func (r *categoryRepository) Delete(id string, reassignTo string) error {
+	tx, err := r.lockTree()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parentID *string
+	if err := tx.QueryRow(`SELECT parent_id FROM categories WHERE id = $1`, id).Scan(&parentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to get category: %w", err)
+	}
+
+	var target *string
+	switch reassignTo {
+	case "":
+		var inUse bool
+		query := `SELECT EXISTS(SELECT 1 FROM products WHERE category_id = $1) OR EXISTS(SELECT 1 FROM categories WHERE parent_id = $1)`
+		if err := tx.QueryRow(query, id).Scan(&inUse); err != nil {
+			return fmt.Errorf("failed to check category usage: %w", err)
+		}
+		if inUse {
+			return model.ErrCategoryNotEmpty
+		}
+	case model.ReassignToParent:
+		target = parentID
+	default:
+		inside, err := r.inSubtree(tx, reassignTo, id)
+		if err != nil {
+			return err
+		}
+		if inside {
+			return model.ErrCategoryCycle
+		}
+		target = &reassignTo
+	}
+
+	if _, err := tx.Exec(`UPDATE categories SET parent_id = $1, updated_at = NOW() WHERE parent_id = $2`, target, id); err != nil {
+		if err := r.translate(err); err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to reassign subcategories: %w", err)
+	}
+
+	query := `
+        UPDATE products
+        SET category_id = $1,
+            category = (SELECT name FROM categories WHERE id = $1),
+            updated_at = NOW()
+        WHERE category_id = $2
+    `
+	result, err := tx.Exec(query, target, id)
+	if err != nil {
+		return fmt.Errorf("failed to reassign products: %w", err)
+	}
+	products, _ := result.RowsAffected()
+	if target != nil {
+		if err := checkRequiredAttributes(tx, inCategorySubtree, *target); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM categories WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	r.logger.Infow("Category deleted successfully", "category_id", id, "reassigned_to", target, "products", products)
+	return nil
+}
+
+// lockTree starts a transaction that changes the shape of the tree. Structure
+// changes are serialized so two concurrent moves can not create a cycle.
+func (r *categoryRepository) lockTree() (*sql.Tx, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.Exec(`LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to lock categories: %w", err)
+	}
+
+	return tx, nil
+}
+
+// inSubtree reports whether id is rootID or one of its descendants. It fails with
+// ErrCategoryNotFound when id does not exist.
+func (r *categoryRepository) inSubtree(tx *sql.Tx, id, rootID string) (bool, error) {
+	query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM categories WHERE id = $1
+            UNION ALL
+            SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+        )
+        SELECT COUNT(*) > 0, COALESCE(bool_or(id = $2), false) FROM ancestors
+    `
+
+	var exists, inside bool
+	if err := tx.QueryRow(query, id, rootID).Scan(&exists, &inside); err != nil {
+		return false, fmt.Errorf("failed to check category ancestry: %w", err)
+	}
+	if !exists {
+		return false, model.ErrCategoryNotFound
+	}
+	return inside, nil
+}
+
+// translate maps constraint violations to model errors, or returns nil
+func (r *categoryRepository) translate(err error) error {
+	switch {
+	case hasErrorCode(err, uniqueViolation):
+		return model.ErrCategoryExists
+	case hasErrorCode(err, foreignKeyViolation):
+		return model.ErrCategoryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+// sortColumn maps a whitelisted sort field to its column and the type cursor
+// values are cast to
+type sortColumn struct {
+	name string
+	cast string
+}
+
+var sortColumns = map[string]sortColumn{
+	"created_at": {name: "created_at", cast: "timestamptz"},
+	"updated_at": {name: "updated_at", cast: "timestamptz"},
+	"name":       {name: "name", cast: "text"},
+	"price":      {name: "price", cast: "bigint"},
+	"stock":      {name: "stock", cast: "integer"},
+	"deleted_at": {name: "deleted_at", cast: "timestamptz"},
+	"publish_at": {name: "publish_at", cast: "timestamptz"},
+}
+
+// cursor is the keyset position of a page boundary. It is serialized as opaque
+// base64 JSON and carries the sort it was created for, so following a cursor
+// keeps the original ordering.
+type cursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+	// Prev pages backwards from the position
+	Prev bool `json:"p,omitempty"`
+}
+
+func newCursor(product *model.Product, sort, order string, prev bool) *cursor {
+	var value string
+	switch sort {
+	case "created_at":
+		value = product.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "updated_at":
+		value = product.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case "name":
+		value = product.Name
+	case "price":
+		value = strconv.FormatInt(product.Price.Amount, 10)
+	case "stock":
+		value = strconv.Itoa(product.Stock)
+	case "deleted_at":
+		if product.DeletedAt != nil {
+			value = product.DeletedAt.UTC().Format(time.RFC3339Nano)
+		}
+	case "publish_at":
+		if product.PublishAt != nil {
+			value = product.PublishAt.UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	return &cursor{Sort: sort, Order: order, Value: value, ID: product.ID, Prev: prev}
+}
+
+func (c *cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", model.ErrInvalidQuery)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", model.ErrInvalidQuery)
+	}
+
+	if _, ok := sortColumns[c.Sort]; !ok || (c.Order != model.SortAsc && c.Order != model.SortDesc) || c.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", model.ErrInvalidQuery)
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.FixedZone("CET", 3600))
+	updated := created.Add(time.Hour)
+	deleted := created.Add(2 * time.Hour)
+	product := &model.Product{
+		ID:        "0b6f2c62-9a55-4d3c-9a4e-2f1f3c1b7d11",
+		Name:      "Lamp \"deluxe\" / 50% off",
+		Price:     model.Money{Amount: 1999, Currency: "USD"},
+		Stock:     7,
+		CreatedAt: created,
+		UpdatedAt: updated,
+		DeletedAt: &deleted,
+	}
+
+	tests := []struct {
+		sort, order string
+		prev        bool
+		wantValue   string
+	}{
+		{"created_at", model.SortDesc, false, "2024-03-01T11:30:00.123456789Z"},
+		{"updated_at", model.SortAsc, true, "2024-03-01T12:30:00.123456789Z"},
+		{"name", model.SortAsc, false, "Lamp \"deluxe\" / 50% off"},
+		{"price", model.SortDesc, true, "1999"},
+		{"stock", model.SortAsc, false, "7"},
+		{"deleted_at", model.SortDesc, false, "2024-03-01T13:30:00.123456789Z"},
+		{"publish_at", model.SortAsc, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			c := newCursor(product, tt.sort, tt.order, tt.prev)
+			if c.Value != tt.wantValue {
+				t.Errorf("newCursor().Value = %q, want %q", c.Value, tt.wantValue)
+			}
+
+			decoded, err := decodeCursor(c.encode())
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if *decoded != *c {
+				t.Errorf("decodeCursor() = %+v, want %+v", *decoded, *c)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsMalformed(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"not json", encode("name,asc,a,1")},
+		{"wrong json type", encode(`["name","asc","a","1"]`)},
+		{"unknown sort", encode(`{"s":"password","o":"asc","v":"a","id":"1"}`)},
+		{"unknown order", encode(`{"s":"name","o":"sideways","v":"a","id":"1"}`)},
+		{"missing id", encode(`{"s":"name","o":"asc","v":"a"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := decodeCursor(tt.encoded)
+			if !errors.Is(err, model.ErrInvalidQuery) {
+				t.Fatalf("decodeCursor() = %+v, %v, want %v", c, err, model.ErrInvalidQuery)
+			}
+		})
+	}
+}
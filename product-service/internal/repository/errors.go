@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/lib/pq"
+)
+
+// Postgres SQLSTATE codes the repositories translate into model errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	checkViolation      = "23514"
+)
+
+func hasErrorCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
+
+// translateProductError maps constraint violations of product writes to model errors, or returns nil
+func translateProductError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+
+	switch {
+	case string(pqErr.Code) == foreignKeyViolation:
+		return model.ErrCategoryNotFound
+	case pqErr.Constraint == "products_reserved_within_stock":
+		return model.ErrInsufficientStock
+	case string(pqErr.Code) != uniqueViolation:
+		return nil
+	case pqErr.Constraint == "idx_products_sku":
+		return model.ErrDuplicateSKU
+	case pqErr.Constraint == "idx_products_external_id":
+		return model.ErrDuplicateExternalID
+	case pqErr.Constraint == "idx_products_slug":
+		return model.ErrDuplicateSlug
+	default:
+		return nil
+	}
+}
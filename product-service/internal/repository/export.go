@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+// exportFetchSize is the number of rows fetched from the export cursor at a time
+const exportFetchSize = 500
+
+// Export streams the products matching filter to fn in ID order. Rows are read
+// through a server side cursor in a read only snapshot, so the export is
+// consistent and memory use does not grow with the catalog. An error returned by
+// fn stops the export and is returned.
+func (r *productRepository) Export(filter *model.ProductFilter, fn func(*model.Product) error) error {
+	tx, err := r.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	b := &queryBuilder{}
+	applyProductFilter(b, filter)
+	query := `DECLARE product_export NO SCROLL CURSOR FOR SELECT ` + productColumns +
+		` FROM products` + b.whereClause() + ` ORDER BY id`
+	if _, err := tx.Exec(query, b.args...); err != nil {
+		r.logger.Errorw("Failed to open export cursor", "error", err)
+		return fmt.Errorf("failed to export products: %w", err)
+	}
+
+	for {
+		count, err := r.fetchExport(tx, fn)
+		if err != nil {
+			return err
+		}
+		if count < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+// fetchExport passes the next rows of the export cursor to fn and returns how many there were
+func (r *productRepository) fetchExport(tx *sql.Tx, fn func(*model.Product) error) (int, error) {
+	rows, err := tx.Query(fmt.Sprintf(`FETCH %d FROM product_export`, exportFetchSize))
+	if err != nil {
+		r.logger.Errorw("Failed to fetch exported products", "error", err)
+		return 0, fmt.Errorf("failed to export products: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return count, fmt.Errorf("failed to scan product: %w", err)
+		}
+		if err := fn(product); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("error iterating exported products: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+const (
+	stockBucketIn  = "in_stock"
+	stockBucketOut = "out_of_stock"
+)
+
+// Facets counts products per bucket of every requested facet. search, when not
+// empty, scopes the counts to the same full-text matches as Search.
+func (r *productRepository) Facets(filter *model.ProductFilter, search string, request *model.FacetRequest, priceRanges []model.PriceRange) (model.Facets, error) {
+	facets := make(model.Facets, len(request.Fields))
+
+	for _, field := range request.Fields {
+		scope := *filter
+		if request.Disjunctive {
+			scope = filter.WithoutFacet(field)
+		}
+
+		b := &queryBuilder{}
+		applyProductFilter(b, &scope)
+		if search != "" {
+			applySearch(b, search)
+		}
+
+		var buckets []model.FacetBucket
+		var err error
+		switch field {
+		case model.FacetCategory:
+			buckets, err = r.categoryBuckets(b)
+		case model.FacetPrice:
+			buckets, err = r.priceBuckets(b, priceRanges)
+		case model.FacetStock:
+			buckets, err = r.stockBuckets(b)
+		default:
+			err = fmt.Errorf("%w: unknown facet %q", model.ErrInvalidQuery, field)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		facets[field] = buckets
+	}
+
+	return facets, nil
+}
+
+// categoryBuckets counts products per category they are directly assigned to.
+// Bucket values are category IDs, labels the category names.
+func (r *productRepository) categoryBuckets(b *queryBuilder) ([]model.FacetBucket, error) {
+	b.where("category_id IS NOT NULL")
+	query := `
+        SELECT c.id, c.name, f.count
+        FROM (SELECT category_id, COUNT(*) AS count FROM products` + b.whereClause() + ` GROUP BY category_id) f
+        JOIN categories c ON c.id = f.category_id
+        ORDER BY f.count DESC, c.name`
+
+	rows, err := r.db.Query(query, b.args...)
+	if err != nil {
+		r.logger.Errorw("Failed to count category facet", "error", err)
+		return nil, fmt.Errorf("failed to count category facet: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []model.FacetBucket{}
+	for rows.Next() {
+		var bucket model.FacetBucket
+		if err := rows.Scan(&bucket.Value, &bucket.Label, &bucket.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan category facet: %w", err)
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count category facet: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// priceBuckets counts every configured range in a single scan. Products are
+// counted on their price in the currency of the range bounds.
+func (r *productRepository) priceBuckets(b *queryBuilder, ranges []model.PriceRange) ([]model.FacetBucket, error) {
+	if len(ranges) == 0 {
+		return []model.FacetBucket{}, nil
+	}
+
+	prices := map[string]string{}
+	price := func(currency string) string {
+		if _, ok := prices[currency]; !ok {
+			prices[currency] = priceIn(b, currency)
+		}
+		return prices[currency]
+	}
+
+	counts := make([]string, 0, len(ranges))
+	for _, priceRange := range ranges {
+		var conditions []string
+		if priceRange.From != nil {
+			conditions = append(conditions, price(priceRange.From.Currency)+" >= "+b.arg(priceRange.From.Amount))
+		}
+		if priceRange.To != nil {
+			conditions = append(conditions, price(priceRange.To.Currency)+" < "+b.arg(priceRange.To.Amount))
+		}
+		if len(conditions) == 0 {
+			conditions = append(conditions, "TRUE")
+		}
+		counts = append(counts, "COUNT(*) FILTER (WHERE "+strings.Join(conditions, " AND ")+")")
+	}
+
+	buckets := make([]model.FacetBucket, len(ranges))
+	dest := make([]interface{}, len(ranges))
+	for i, priceRange := range ranges {
+		buckets[i] = model.FacetBucket{
+			Value: priceRangeValue(priceRange),
+			From:  priceRange.From,
+			To:    priceRange.To,
+		}
+		dest[i] = &buckets[i].Count
+	}
+
+	query := `SELECT ` + strings.Join(counts, ", ") + ` FROM products` + b.whereClause()
+	if err := r.db.QueryRow(query, b.args...).Scan(dest...); err != nil {
+		r.logger.Errorw("Failed to count price facet", "error", err)
+		return nil, fmt.Errorf("failed to count price facet: %w", err)
+	}
+
+	return buckets, nil
+}
+
+func (r *productRepository) stockBuckets(b *queryBuilder) ([]model.FacetBucket, error) {
+	query := `SELECT COUNT(*) FILTER (WHERE stock - reserved > 0), COUNT(*) FILTER (WHERE stock - reserved <= 0) FROM products` + b.whereClause()
+
+	in := model.FacetBucket{Value: stockBucketIn}
+	out := model.FacetBucket{Value: stockBucketOut}
+	if err := r.db.QueryRow(query, b.args...).Scan(&in.Count, &out.Count); err != nil {
+		r.logger.Errorw("Failed to count stock facet", "error", err)
+		return nil, fmt.Errorf("failed to count stock facet: %w", err)
+	}
+
+	return []model.FacetBucket{in, out}, nil
+}
+
+// priceRangeValue labels a range the way it is filtered on, e.g. "25.00-50.00" or "250.00-"
+func priceRangeValue(priceRange model.PriceRange) string {
+	format := func(bound *model.Money) string {
+		if bound == nil {
+			return ""
+		}
+		return bound.Decimal()
+	}
+	return format(priceRange.From) + "-" + format(priceRange.To)
+}
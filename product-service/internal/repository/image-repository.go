@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// imageColumns is the column list every image query selects, in scanImage order
+const imageColumns = `id, product_id, position, alt_text, url, content_type, width, height, size_bytes, checksum,
+        variants, created_by, created_at, updated_at, storage_key`
+
+// storedVariant is an image variant as kept in product_images.variants. The
+// blob key is stored for the trigger queueing blobs of deleted images.
+type storedVariant struct {
+	model.ImageVariant
+	Key string `json:"key"`
+}
+
+func scanImage(row rowScanner) (*model.ProductImage, error) {
+	image := &model.ProductImage{}
+	var variants []byte
+	err := row.Scan(
+		&image.ID, &image.ProductID, &image.Position, &image.AltText, &image.URL, &image.ContentType,
+		&image.Width, &image.Height, &image.Size, &image.Checksum, &variants, &image.CreatedBy,
+		&image.CreatedAt, &image.UpdatedAt, &image.StorageKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []storedVariant
+	if err := json.Unmarshal(variants, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode image variants: %w", err)
+	}
+	image.Variants = make([]model.ImageVariant, len(stored))
+	for i, variant := range stored {
+		image.Variants[i] = variant.ImageVariant
+		image.Variants[i].Key = variant.Key
+	}
+	return image, nil
+}
+
+type ImageRepository interface {
+	// Create adds an image to a product that is not in the trash and has fewer
+	// than maxImages images. The image is inserted at position, moving later
+	// images back, or appended when position is nil or past the last image.
+	Create(image *model.ProductImage, position *int, maxImages int) (*model.ProductImage, error)
+	ListByProduct(productID string) ([]*model.ProductImage, error)
+	GetByID(productID, id string) (*model.ProductImage, error)
+	UpdateAltText(productID, id, altText string) (*model.ProductImage, error)
+	// Reorder gives the images of a product the positions of their IDs in ids,
+	// which must list every one of them
+	Reorder(productID string, ids []string) ([]*model.ProductImage, error)
+	// Delete removes an image and closes the gap it leaves. Its blobs are queued
+	// for deletion by the database.
+	Delete(productID, id string) error
+	// DeleteBlobs passes up to limit queued blob keys to remove and forgets the
+	// removed ones. Keys that failed maxAttempts times are given up on.
+	DeleteBlobs(limit, maxAttempts int, remove func(key string) error) (deleted, failed int, err error)
+}
+
+type imageRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewImageRepository(db *sql.DB, logger *zap.SugaredLogger) ImageRepository {
+	return &imageRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *imageRepository) Create(image *model.ProductImage, position *int, maxImages int) (*model.ProductImage, error) {
+	stored := make([]storedVariant, len(image.Variants))
+	for i, variant := range image.Variants {
+		stored[i] = storedVariant{ImageVariant: variant, Key: variant.Key}
+	}
+	variants, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image variants: %w", err)
+	}
+	var createdBy *string
+	if image.CreatedBy != nil && *image.CreatedBy != "" {
+		createdBy = image.CreatedBy
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := touchProduct(tx, image.ProductID, now); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM product_images WHERE product_id = $1`, image.ProductID).Scan(&count); err != nil {
+		r.logger.Errorw("Failed to count images", "error", err, "product_id", image.ProductID)
+		return nil, fmt.Errorf("failed to count images: %w", err)
+	}
+	if count >= maxImages {
+		return nil, fmt.Errorf("%w: at most %d images are allowed", model.ErrTooManyImages, maxImages)
+	}
+
+	at := count
+	if position != nil && *position < count {
+		at = *position
+		query := `UPDATE product_images SET position = position + 1 WHERE product_id = $1 AND position >= $2`
+		if _, err := tx.Exec(query, image.ProductID, at); err != nil {
+			r.logger.Errorw("Failed to move images", "error", err, "product_id", image.ProductID)
+			return nil, fmt.Errorf("failed to move images: %w", err)
+		}
+	}
+
+	query := `
+        INSERT INTO product_images (id, product_id, position, alt_text, storage_key, url, content_type, width, height,
+            size_bytes, checksum, variants, created_by, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $14)
+        RETURNING ` + imageColumns
+
+	created, err := scanImage(tx.QueryRow(
+		query,
+		image.ID, image.ProductID, at, image.AltText, image.StorageKey, image.URL, image.ContentType, image.Width,
+		image.Height, image.Size, image.Checksum, variants, createdBy, now,
+	))
+	if err != nil {
+		r.logger.Errorw("Failed to create image", "error", err, "product_id", image.ProductID)
+		return nil, fmt.Errorf("failed to create image: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create image: %w", err)
+	}
+	return created, nil
+}
+
+func (r *imageRepository) ListByProduct(productID string) ([]*model.ProductImage, error) {
+	return r.list(r.db, productID)
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (r *imageRepository) list(db queryer, productID string) ([]*model.ProductImage, error) {
+	query := `SELECT ` + imageColumns + ` FROM product_images WHERE product_id = $1 ORDER BY position`
+	rows, err := db.Query(query, productID)
+	if err != nil {
+		r.logger.Errorw("Failed to get images", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to get images: %w", err)
+	}
+	defer rows.Close()
+
+	images := []*model.ProductImage{}
+	for rows.Next() {
+		image, err := scanImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan image: %w", err)
+		}
+		images = append(images, image)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating images: %w", err)
+	}
+	return images, nil
+}
+
+func (r *imageRepository) GetByID(productID, id string) (*model.ProductImage, error) {
+	query := `SELECT ` + imageColumns + ` FROM product_images WHERE id = $1 AND product_id = $2`
+	image, err := scanImage(r.db.QueryRow(query, id, productID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrImageNotFound
+		}
+		r.logger.Errorw("Failed to get image", "error", err, "image_id", id)
+		return nil, fmt.Errorf("failed to get image: %w", err)
+	}
+	return image, nil
+}
+
+func (r *imageRepository) UpdateAltText(productID, id, altText string) (*model.ProductImage, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := touchProduct(tx, productID, now); err != nil {
+		return nil, err
+	}
+
+	query := `
+        UPDATE product_images SET alt_text = $1, updated_at = $2
+        WHERE id = $3 AND product_id = $4
+        RETURNING ` + imageColumns
+
+	image, err := scanImage(tx.QueryRow(query, altText, now, id, productID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrImageNotFound
+		}
+		r.logger.Errorw("Failed to update image", "error", err, "image_id", id)
+		return nil, fmt.Errorf("failed to update image: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update image: %w", err)
+	}
+	return image, nil
+}
+
+func (r *imageRepository) Reorder(productID string, ids []string) ([]*model.ProductImage, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := touchProduct(tx, productID, now); err != nil {
+		return nil, err
+	}
+
+	// Positions are only checked for uniqueness at commit, so images can swap
+	query := `
+        UPDATE product_images i SET position = o.ordinality - 1, updated_at = $1
+        FROM unnest($2::text[]) WITH ORDINALITY AS o(id, ordinality)
+        WHERE i.id = o.id AND i.product_id = $3`
+	result, err := tx.Exec(query, now, pq.Array(ids), productID)
+	if err != nil {
+		r.logger.Errorw("Failed to reorder images", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to reorder images: %w", err)
+	}
+	moved, _ := result.RowsAffected()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM product_images WHERE product_id = $1`, productID).Scan(&count); err != nil {
+		r.logger.Errorw("Failed to count images", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to count images: %w", err)
+	}
+	if int(moved) != len(ids) || count != len(ids) {
+		return nil, fmt.Errorf("%w: image_ids must list each of the %d images of the product once", model.ErrInvalidImage, count)
+	}
+
+	images, err := r.list(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to reorder images: %w", err)
+	}
+	return images, nil
+}
+
+func (r *imageRepository) Delete(productID, id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := touchProduct(tx, productID, time.Now()); err != nil {
+		return err
+	}
+
+	var position int
+	err = tx.QueryRow(`DELETE FROM product_images WHERE id = $1 AND product_id = $2 RETURNING position`, id, productID).Scan(&position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrImageNotFound
+		}
+		r.logger.Errorw("Failed to delete image", "error", err, "image_id", id)
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+
+	query := `UPDATE product_images SET position = position - 1 WHERE product_id = $1 AND position > $2`
+	if _, err := tx.Exec(query, productID, position); err != nil {
+		r.logger.Errorw("Failed to move images", "error", err, "product_id", productID)
+		return fmt.Errorf("failed to move images: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+	return nil
+}
+
+func (r *imageRepository) DeleteBlobs(limit, maxAttempts int, remove func(key string) error) (int, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locked blobs are being deleted by another instance
+	rows, err := tx.Query(`
+        SELECT id, storage_key FROM blob_deletions
+        WHERE attempts < $1
+        ORDER BY id
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED`, maxAttempts, limit)
+	if err != nil {
+		r.logger.Errorw("Failed to claim blob deletions", "error", err)
+		return 0, 0, fmt.Errorf("failed to claim blob deletions: %w", err)
+	}
+
+	type blob struct {
+		id  int64
+		key string
+	}
+	blobs := []blob{}
+	for rows.Next() {
+		var b blob
+		if err := rows.Scan(&b.id, &b.key); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan blob deletion: %w", err)
+		}
+		blobs = append(blobs, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("error iterating blob deletions: %w", err)
+	}
+
+	deleted, failed := 0, 0
+	for _, b := range blobs {
+		if err := remove(b.key); err != nil {
+			failed++
+			query := `UPDATE blob_deletions SET attempts = attempts + 1, last_error = $1 WHERE id = $2`
+			if _, err := tx.Exec(query, err.Error(), b.id); err != nil {
+				r.logger.Errorw("Failed to record blob deletion", "error", err, "key", b.key)
+				return 0, 0, fmt.Errorf("failed to record blob deletion: %w", err)
+			}
+			continue
+		}
+
+		deleted++
+		if _, err := tx.Exec(`DELETE FROM blob_deletions WHERE id = $1`, b.id); err != nil {
+			r.logger.Errorw("Failed to record blob deletion", "error", err, "key", b.key)
+			return 0, 0, fmt.Errorf("failed to record blob deletion: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to record blob deletion: %w", err)
+	}
+	return deleted, failed, nil
+}
+
+// touchProduct locks a product that is not in the trash for a change to its
+// images, which moves it to a new version
+func touchProduct(tx *sql.Tx, productID string, now time.Time) error {
+	result, err := tx.Exec(`UPDATE products SET updated_at = $1 WHERE id = $2 AND deleted_at IS NULL`, now, productID)
+	if err != nil {
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return model.ErrProductNotFound
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+const importColumns = `id, status, format, match_key, dry_run, total_rows, processed_rows,
+        created_count, updated_count, failed_count, errors, error, created_by,
+        created_at, updated_at, started_at, finished_at`
+
+// errCurrencyLocked is returned by upsertRow when a matched product has variants
+// and the row changes its currency
+var errCurrencyLocked = errors.New("currency of a product with variants can not change")
+
+type ImportRepository interface {
+	// Create stores a pending job with the uploaded file
+	Create(job *model.ImportJob, payload []byte) error
+	GetByID(id string) (*model.ImportJob, error)
+	// ClaimNext marks the oldest pending job as running and returns it with its
+	// file. Running jobs not updated since staleBefore were abandoned by a worker
+	// and are claimed again. It returns nil when there is nothing to do.
+	ClaimNext(staleBefore time.Time) (*model.ImportJob, []byte, error)
+	// ApplyBatch upserts rows and records the progress of job in one transaction,
+	// keeping at most maxErrors row errors. Nothing but the progress is saved in a
+	// dry run.
+	ApplyBatch(job *model.ImportJob, rows []*model.ImportRow, maxErrors int) error
+	// Finish records the final status of job and drops its file
+	Finish(job *model.ImportJob) error
+}
+
+type importRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewImportRepository(db *sql.DB, logger *zap.SugaredLogger) ImportRepository {
+	return &importRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func scanImportJob(row rowScanner, extra ...interface{}) (*model.ImportJob, error) {
+	job := &model.ImportJob{}
+	var rowErrors []byte
+	dest := append([]interface{}{
+		&job.ID, &job.Status, &job.Format, &job.Key, &job.DryRun, &job.TotalRows, &job.ProcessedRows,
+		&job.Created, &job.Updated, &job.Failed, &rowErrors, &job.Error, &job.CreatedBy,
+		&job.CreatedAt, &job.UpdatedAt, &job.StartedAt, &job.FinishedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rowErrors, &job.Errors); err != nil {
+		return nil, fmt.Errorf("failed to decode import errors: %w", err)
+	}
+	return job, nil
+}
+
+func (r *importRepository) Create(job *model.ImportJob, payload []byte) error {
+	now := time.Now()
+	job.ID = uuid.New().String()
+	job.Status = model.ImportPending
+	job.CreatedAt, job.UpdatedAt = now, now
+
+	query := `
+        INSERT INTO import_jobs (id, status, format, match_key, dry_run, payload, total_rows, created_by, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.Exec(query,
+		job.ID, job.Status, job.Format, job.Key, job.DryRun, payload, job.TotalRows, job.CreatedBy, now, now,
+	)
+	if err != nil {
+		r.logger.Errorw("Failed to create import job", "error", err)
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	r.logger.Infow("Import job created", "import_id", job.ID, "rows", job.TotalRows, "dry_run", job.DryRun)
+	return nil
+}
+
+func (r *importRepository) GetByID(id string) (*model.ImportJob, error) {
+	job, err := scanImportJob(r.db.QueryRow(`SELECT `+importColumns+` FROM import_jobs WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrImportNotFound
+		}
+		r.logger.Errorw("Failed to get import job", "error", err, "import_id", id)
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *importRepository) ClaimNext(staleBefore time.Time) (*model.ImportJob, []byte, error) {
+	query := `
+        UPDATE import_jobs
+        SET status = $1, started_at = COALESCE(started_at, $2), updated_at = $2
+        WHERE id = (
+            SELECT id FROM import_jobs
+            WHERE status = $3 OR (status = $1 AND updated_at < $4)
+            ORDER BY created_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED)
+        RETURNING ` + importColumns + `, payload`
+
+	var payload []byte
+	job, err := scanImportJob(r.db.QueryRow(query, model.ImportRunning, time.Now(), model.ImportPending, staleBefore), &payload)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		r.logger.Errorw("Failed to claim import job", "error", err)
+		return nil, nil, fmt.Errorf("failed to claim import job: %w", err)
+	}
+
+	return job, payload, nil
+}
+
+func (r *importRepository) ApplyBatch(job *model.ImportJob, rows []*model.ImportRow, maxErrors int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var createdBy string
+	if job.CreatedBy != nil {
+		createdBy = *job.CreatedBy
+	}
+	// Without a reason, created products record their stock as initial and
+	// updated ones the change as a correction
+	if err := setStockContext(tx, "", job.ID, createdBy, ""); err != nil {
+		return err
+	}
+	if err := setPriceContext(tx, model.PriceSourceImport, "", createdBy); err != nil {
+		return err
+	}
+
+	if job.DryRun {
+		if _, err := tx.Exec(`SAVEPOINT dry_run`); err != nil {
+			return fmt.Errorf("failed to start dry run: %w", err)
+		}
+	}
+
+	progress := *job
+	progress.Errors = append([]model.ImportRowError(nil), job.Errors...)
+	for _, row := range rows {
+		rowErrors := row.Errors
+		if len(rowErrors) == 0 {
+			created, err := r.upsertRow(tx, job, row)
+			switch {
+			case err == nil && created:
+				progress.Created++
+			case err == nil:
+				progress.Updated++
+			default:
+				message, ok := importRowMessage(err)
+				if !ok {
+					return fmt.Errorf("failed to import row %d: %w", row.Row, err)
+				}
+				rowErrors = []model.ImportRowError{{Row: row.Row, Message: message}}
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			progress.Failed++
+			for _, rowError := range rowErrors {
+				if len(progress.Errors) < maxErrors {
+					progress.Errors = append(progress.Errors, rowError)
+				}
+			}
+		}
+		progress.ProcessedRows++
+	}
+
+	if job.DryRun {
+		if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT dry_run`); err != nil {
+			return fmt.Errorf("failed to roll back dry run: %w", err)
+		}
+	}
+
+	rowErrors, err := json.Marshal(progress.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to encode import errors: %w", err)
+	}
+	progress.UpdatedAt = time.Now()
+
+	query := `
+        UPDATE import_jobs
+        SET processed_rows = $1, created_count = $2, updated_count = $3, failed_count = $4,
+            errors = $5, updated_at = $6
+        WHERE id = $7`
+	_, err = tx.Exec(query,
+		progress.ProcessedRows, progress.Created, progress.Updated, progress.Failed,
+		rowErrors, progress.UpdatedAt, job.ID,
+	)
+	if err != nil {
+		r.logger.Errorw("Failed to record import progress", "error", err, "import_id", job.ID)
+		return fmt.Errorf("failed to record import progress: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import batch: %w", err)
+	}
+
+	*job = progress
+	return nil
+}
+
+// upsertRow creates or updates the product matching the row's key within a
+// savepoint, so a failing row does not abort the batch. A matched product in the
+// trash is restored. The stock of a product with variants is the sum of theirs,
+// so the row's stock is ignored for it.
+func (r *importRepository) upsertRow(tx *sql.Tx, job *model.ImportJob, row *model.ImportRow) (created bool, err error) {
+	if _, err := tx.Exec(`SAVEPOINT import_row`); err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			if _, rollbackErr := tx.Exec(`ROLLBACK TO SAVEPOINT import_row`); rollbackErr != nil {
+				err = rollbackErr
+			}
+		}
+	}()
+
+	req := &row.Product
+	var categoryID *string
+	if row.Category != "" {
+		var id string
+		err := tx.QueryRow(`SELECT id FROM categories WHERE id = $1 OR slug = $1`, row.Category).Scan(&id)
+		if err == sql.ErrNoRows {
+			return false, model.ErrCategoryNotFound
+		}
+		if err != nil {
+			return false, err
+		}
+		categoryID = &id
+	}
+
+	// The key column is matched on, the other key is only overwritten when given
+	conflict, otherKey := "sku", "external_id"
+	if job.Key == model.ImportKeyExternalID {
+		conflict, otherKey = "external_id", "sku"
+	}
+
+	// Only a created product takes the slug; updated ones keep theirs
+	productSlug, err := generateSlug(tx, req.Name)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	query := fmt.Sprintf(`
+        INSERT INTO products (id, sku, external_id, name, slug, description, price, currency, category_id, category, stock, created_by, created_at, updated_at)
+        VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7, $8, $9, (SELECT name FROM categories WHERE id = $9), $10, $11, $12, $12)
+        ON CONFLICT (%[1]s) WHERE %[1]s IS NOT NULL DO UPDATE
+        SET %[2]s = COALESCE(EXCLUDED.%[2]s, products.%[2]s),
+            name = EXCLUDED.name,
+            description = EXCLUDED.description,
+            price = EXCLUDED.price,
+            currency = EXCLUDED.currency,
+            category_id = EXCLUDED.category_id,
+            category = EXCLUDED.category,
+            stock = CASE
+                WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id) THEN products.stock
+                ELSE EXCLUDED.stock
+            END,
+            updated_at = EXCLUDED.updated_at,
+            deleted_at = NULL,
+            deleted_by = NULL
+        WHERE products.currency = EXCLUDED.currency
+           OR NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id)
+        RETURNING id, xmax = 0`, conflict, otherKey)
+
+	var id string
+	err = tx.QueryRow(query,
+		uuid.New().String(), req.SKU, req.ExternalID, req.Name, productSlug, req.Description, req.Price.Amount, req.Price.Currency,
+		categoryID, req.Stock, job.CreatedBy, now,
+	).Scan(&id, &created)
+	if err == sql.ErrNoRows {
+		return false, errCurrencyLocked
+	}
+	if err != nil {
+		if translated := translateProductError(err); translated != nil {
+			return false, translated
+		}
+		return false, err
+	}
+
+	if err := checkRequiredAttributes(tx, "p.id = $1", id); err != nil {
+		return false, err
+	}
+
+	if created || row.ReplacePrices {
+		if err := setPrices(tx, id, req.Prices); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := tx.Exec(`RELEASE SAVEPOINT import_row`); err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+// importRowMessage returns the message recorded for a row that failed with err,
+// or false when err is not caused by the row
+func importRowMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, model.ErrCategoryNotFound):
+		return "category not found", true
+	case errors.Is(err, model.ErrDuplicateSKU), errors.Is(err, model.ErrDuplicateExternalID),
+		errors.Is(err, model.ErrDuplicateSlug), errors.Is(err, model.ErrInvalidAttribute), errors.Is(err, errCurrencyLocked):
+		return err.Error(), true
+	case errors.Is(err, model.ErrInsufficientStock):
+		return "stock is below the units reserved", true
+	case hasErrorCode(err, checkViolation):
+		return "row violates a product constraint", true
+	default:
+		return "", false
+	}
+}
+
+func (r *importRepository) Finish(job *model.ImportJob) error {
+	now := time.Now()
+	query := `
+        UPDATE import_jobs
+        SET status = $1, error = $2, payload = NULL, updated_at = $3, finished_at = $3
+        WHERE id = $4`
+	if _, err := r.db.Exec(query, job.Status, job.Error, now, job.ID); err != nil {
+		r.logger.Errorw("Failed to finish import job", "error", err, "import_id", job.ID)
+		return fmt.Errorf("failed to finish import job: %w", err)
+	}
+
+	job.UpdatedAt, job.FinishedAt = now, &now
+	r.logger.Infow("Import job finished", "import_id", job.ID, "status", job.Status,
+		"created", job.Created, "updated", job.Updated, "failed", job.Failed)
+	return nil
+}
@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+const priceScheduleColumns = `id, product_id, price, currency, starts_at, ends_at, status, previous_price, note,
+        created_by, activated_at, ended_at, created_at, updated_at`
+
+type PriceRepository interface {
+	// History returns a page of the price changes of a product, newest first,
+	// and the before of the next page
+	History(productID string, query *model.PriceHistoryQuery) ([]*model.PriceChange, int64, error)
+	// CreateSchedule schedules a price unless it overlaps a pending or active
+	// schedule of the product
+	CreateSchedule(productID string, req *model.CreatePriceScheduleRequest) (*model.PriceSchedule, error)
+	// ListSchedules returns every schedule of a product by start
+	ListSchedules(productID string) ([]*model.PriceSchedule, error)
+	// CancelSchedule cancels a pending schedule, or ends an active one now
+	CancelSchedule(productID, id, actorID string) (*model.PriceSchedule, error)
+	// StartDue puts in effect up to limit scheduled prices due by now and returns how many started
+	StartDue(now time.Time, limit int) (int, error)
+	// EndDue restores the prices replaced by up to limit active schedules ending
+	// by now and returns how many ended
+	EndDue(now time.Time, limit int) (int, error)
+}
+
+type priceRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewPriceRepository(db *sql.DB, logger *zap.SugaredLogger) PriceRepository {
+	return &priceRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// setPriceContext describes the price changes made in tx for the price history
+// trigger. Empty values fall back to the trigger's defaults.
+func setPriceContext(tx *sql.Tx, source, scheduleID, actorID string) error {
+	query := `SELECT set_config('price.source', $1, true), set_config('price.schedule_id', $2, true),
+        set_config('price.actor_id', $3, true)`
+	if _, err := tx.Exec(query, source, scheduleID, actorID); err != nil {
+		return fmt.Errorf("failed to set price context: %w", err)
+	}
+	return nil
+}
+
+func scanPriceSchedule(row rowScanner) (*model.PriceSchedule, error) {
+	schedule := &model.PriceSchedule{}
+	var previousPrice sql.NullInt64
+	err := row.Scan(
+		&schedule.ID, &schedule.ProductID, &schedule.Price.Amount, &schedule.Price.Currency, &schedule.StartsAt,
+		&schedule.EndsAt, &schedule.Status, &previousPrice, &schedule.Note, &schedule.CreatedBy,
+		&schedule.ActivatedAt, &schedule.EndedAt, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if previousPrice.Valid {
+		schedule.PreviousPrice = &model.Money{Amount: previousPrice.Int64, Currency: schedule.Price.Currency}
+	}
+	return schedule, nil
+}
+
+func (r *priceRepository) History(productID string, query *model.PriceHistoryQuery) ([]*model.PriceChange, int64, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, productID).Scan(&exists)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to check product existence: %w", err)
+	}
+	if !exists {
+		return nil, 0, model.ErrProductNotFound
+	}
+
+	b := &queryBuilder{}
+	b.where("product_id = " + b.arg(productID))
+	if query.Before > 0 {
+		b.where("id < " + b.arg(query.Before))
+	}
+
+	rows, err := r.db.Query(`
+        SELECT id, product_id, price, currency, previous_price, previous_currency, source, schedule_id, actor_id, changed_at
+        FROM price_history`+b.whereClause()+` ORDER BY id DESC LIMIT `+b.arg(query.Limit+1), b.args...)
+	if err != nil {
+		r.logger.Errorw("Failed to get price history", "error", err, "product_id", productID)
+		return nil, 0, fmt.Errorf("failed to get price history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []*model.PriceChange{}
+	for rows.Next() {
+		change := &model.PriceChange{}
+		var previousPrice sql.NullInt64
+		var previousCurrency sql.NullString
+		err := rows.Scan(
+			&change.ID, &change.ProductID, &change.Price.Amount, &change.Price.Currency, &previousPrice,
+			&previousCurrency, &change.Source, &change.ScheduleID, &change.ActorID, &change.ChangedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan price change: %w", err)
+		}
+		if previousPrice.Valid {
+			change.PreviousPrice = &model.Money{Amount: previousPrice.Int64, Currency: previousCurrency.String}
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating price history: %w", err)
+	}
+
+	var nextBefore int64
+	if len(changes) > query.Limit {
+		changes = changes[:query.Limit]
+		nextBefore = changes[query.Limit-1].ID
+	}
+	return changes, nextBefore, nil
+}
+
+func (r *priceRepository) CreateSchedule(productID string, req *model.CreatePriceScheduleRequest) (*model.PriceSchedule, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the product serialises the overlap check with other schedules
+	var currency string
+	err = tx.QueryRow(`SELECT currency FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, productID).Scan(&currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to lock product: %w", err)
+	}
+	if req.Price.Currency != currency {
+		return nil, fmt.Errorf("%w: scheduled prices must be in the product currency %s", model.ErrInvalidPrice, currency)
+	}
+
+	var overlaps bool
+	query := `
+        SELECT EXISTS(
+            SELECT 1 FROM price_schedules
+            WHERE product_id = $1 AND status IN ($2, $3)
+              AND starts_at < COALESCE($5, 'infinity'::timestamptz)
+              AND COALESCE(ends_at, 'infinity'::timestamptz) > $4)`
+	err = tx.QueryRow(query, productID, model.ScheduleScheduled, model.ScheduleActive, req.StartsAt, req.EndsAt).Scan(&overlaps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check price schedule overlap: %w", err)
+	}
+	if overlaps {
+		return nil, model.ErrScheduleOverlap
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+	query = `
+        INSERT INTO price_schedules (id, product_id, price, currency, starts_at, ends_at, status, created_by, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $9)
+        RETURNING ` + priceScheduleColumns
+	schedule, err := scanPriceSchedule(tx.QueryRow(query,
+		id, productID, req.Price.Amount, req.Price.Currency, req.StartsAt, req.EndsAt, model.ScheduleScheduled, req.CreatedBy, now,
+	))
+	if err != nil {
+		r.logger.Errorw("Failed to create price schedule", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to create price schedule: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create price schedule: %w", err)
+	}
+
+	r.logger.Infow("Price scheduled", "product_id", productID, "schedule_id", id, "price", req.Price.String(),
+		"starts_at", req.StartsAt, "ends_at", req.EndsAt)
+	return schedule, nil
+}
+
+func (r *priceRepository) ListSchedules(productID string) ([]*model.PriceSchedule, error) {
+	rows, err := r.db.Query(`
+        SELECT `+priceScheduleColumns+` FROM price_schedules
+        WHERE product_id = $1 ORDER BY starts_at, created_at`, productID)
+	if err != nil {
+		r.logger.Errorw("Failed to get price schedules", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to get price schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []*model.PriceSchedule{}
+	for rows.Next() {
+		schedule, err := scanPriceSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *priceRepository) CancelSchedule(productID, id, actorID string) (*model.PriceSchedule, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	schedule, err := scanPriceSchedule(tx.QueryRow(
+		`SELECT `+priceScheduleColumns+` FROM price_schedules WHERE id = $1 AND product_id = $2 FOR UPDATE`, id, productID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to lock price schedule: %w", err)
+	}
+
+	switch schedule.Status {
+	case model.ScheduleScheduled:
+		err = closeSchedule(tx, schedule, model.ScheduleCancelled, "")
+	case model.ScheduleActive:
+		err = endSchedule(tx, schedule, model.ScheduleCancelled, actorID)
+	default:
+		return nil, model.ErrScheduleEnded
+	}
+	if err != nil {
+		r.logger.Errorw("Failed to cancel price schedule", "error", err, "schedule_id", id)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to cancel price schedule: %w", err)
+	}
+
+	r.logger.Infow("Price schedule cancelled", "product_id", productID, "schedule_id", id)
+	return schedule, nil
+}
+
+func (r *priceRepository) StartDue(now time.Time, limit int) (int, error) {
+	return r.processDue(limit, `status = $1 AND starts_at <= $2`, model.ScheduleScheduled, now,
+		func(tx *sql.Tx, schedule *model.PriceSchedule) error {
+			return startSchedule(tx, schedule, now)
+		})
+}
+
+func (r *priceRepository) EndDue(now time.Time, limit int) (int, error) {
+	return r.processDue(limit, `status = $1 AND ends_at <= $2`, model.ScheduleActive, now,
+		func(tx *sql.Tx, schedule *model.PriceSchedule) error {
+			return endSchedule(tx, schedule, model.ScheduleCompleted, "")
+		})
+}
+
+// processDue passes up to limit schedules matching condition to fn, each in its
+// own transaction, skipping schedules locked by another instance
+func (r *priceRepository) processDue(limit int, condition, status string, now time.Time, fn func(*sql.Tx, *model.PriceSchedule) error) (int, error) {
+	query := `
+        SELECT ` + priceScheduleColumns + ` FROM price_schedules
+        WHERE ` + condition + `
+        ORDER BY starts_at
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED`
+
+	processed := 0
+	for processed < limit {
+		tx, err := r.db.Begin()
+		if err != nil {
+			return processed, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		schedule, err := scanPriceSchedule(tx.QueryRow(query, status, now))
+		if err == sql.ErrNoRows {
+			tx.Rollback()
+			break
+		}
+		if err == nil {
+			err = fn(tx, schedule)
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			tx.Rollback()
+			r.logger.Errorw("Failed to process price schedule", "error", err, "status", status)
+			return processed, fmt.Errorf("failed to process price schedule: %w", err)
+		}
+
+		r.logger.Infow("Price schedule processed", "schedule_id", schedule.ID, "product_id", schedule.ProductID,
+			"status", schedule.Status)
+		processed++
+	}
+	return processed, nil
+}
+
+// startSchedule puts the price of schedule in effect and remembers the price it
+// replaces. A schedule whose product changed currency, or that ended before it
+// could start, is closed without touching the price.
+func startSchedule(tx *sql.Tx, schedule *model.PriceSchedule, now time.Time) error {
+	var price int64
+	var currency string
+	err := tx.QueryRow(`SELECT price, currency FROM products WHERE id = $1 FOR UPDATE`, schedule.ProductID).Scan(&price, &currency)
+	if err != nil {
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	switch {
+	case currency != schedule.Price.Currency:
+		return closeSchedule(tx, schedule, model.ScheduleCancelled, "the product currency changed to "+currency)
+	case schedule.EndsAt != nil && !schedule.EndsAt.After(now):
+		return closeSchedule(tx, schedule, model.ScheduleCompleted, "ended before it could start")
+	}
+
+	var actorID string
+	if schedule.CreatedBy != nil {
+		actorID = *schedule.CreatedBy
+	}
+	if err := setPriceContext(tx, model.PriceSourceScheduleStart, schedule.ID, actorID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE products SET price = $1, updated_at = $2 WHERE id = $3`, schedule.Price.Amount, now, schedule.ProductID); err != nil {
+		return fmt.Errorf("failed to start scheduled price: %w", err)
+	}
+
+	query := `UPDATE price_schedules SET status = $1, previous_price = $2, activated_at = $3, updated_at = $3 WHERE id = $4`
+	if _, err := tx.Exec(query, model.ScheduleActive, price, now, schedule.ID); err != nil {
+		return fmt.Errorf("failed to update price schedule: %w", err)
+	}
+
+	schedule.Status = model.ScheduleActive
+	schedule.PreviousPrice = &model.Money{Amount: price, Currency: currency}
+	schedule.ActivatedAt, schedule.UpdatedAt = &now, now
+	return nil
+}
+
+// endSchedule restores the price an active schedule replaced, unless the price
+// was changed since the schedule started, and closes it with status
+func endSchedule(tx *sql.Tx, schedule *model.PriceSchedule, status, actorID string) error {
+	var price int64
+	var currency string
+	err := tx.QueryRow(`SELECT price, currency FROM products WHERE id = $1 FOR UPDATE`, schedule.ProductID).Scan(&price, &currency)
+	if err != nil {
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	if schedule.PreviousPrice == nil || price != schedule.Price.Amount || currency != schedule.Price.Currency {
+		return closeSchedule(tx, schedule, status, "the price was changed while the schedule was active and was kept")
+	}
+
+	if err := setPriceContext(tx, model.PriceSourceScheduleEnd, schedule.ID, actorID); err != nil {
+		return err
+	}
+	query := `UPDATE products SET price = $1, updated_at = $2 WHERE id = $3`
+	if _, err := tx.Exec(query, schedule.PreviousPrice.Amount, time.Now(), schedule.ProductID); err != nil {
+		return fmt.Errorf("failed to restore price: %w", err)
+	}
+	return closeSchedule(tx, schedule, status, "")
+}
+
+func closeSchedule(tx *sql.Tx, schedule *model.PriceSchedule, status, note string) error {
+	now := time.Now()
+	query := `UPDATE price_schedules SET status = $1, note = NULLIF($2, ''), ended_at = $3, updated_at = $3 WHERE id = $4`
+	if _, err := tx.Exec(query, status, note, now, schedule.ID); err != nil {
+		return fmt.Errorf("failed to update price schedule: %w", err)
+	}
+
+	schedule.Status = status
+	if note != "" {
+		schedule.Note = &note
+	}
+	schedule.EndedAt, schedule.UpdatedAt = &now, now
+	return nil
+}
@@ -2,21 +2,111 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// productColumns is the column list every product query selects, in scanProduct order.
+// The price list, the variant summary and the images are aggregated as JSON.
+const productColumns = `id, sku, external_id, name, slug, description, price, currency,
+        (SELECT COALESCE(json_agg(json_build_object('amount', pp.amount, 'currency', pp.currency) ORDER BY pp.currency), '[]')
+         FROM product_prices pp WHERE pp.product_id = products.id),
+        COALESCE(category, ''), category_id, stock, stock - reserved, reorder_threshold, created_by, variant_options,
+        (SELECT json_build_object(
+            'count', COUNT(*),
+            'min_price', json_build_object('amount', COALESCE(MIN(COALESCE(v.price, products.price)), 0), 'currency', products.currency),
+            'max_price', json_build_object('amount', COALESCE(MAX(COALESCE(v.price, products.price)), 0), 'currency', products.currency))
+         FROM product_variants v WHERE v.product_id = products.id),
+        (SELECT COALESCE(json_agg(to_jsonb(i) - 'storage_key' ORDER BY i.position), '[]')
+         FROM product_images i WHERE i.product_id = products.id),
+        attributes, meta_title, meta_description, canonical_url, status, publish_at, unpublish_at, version, deleted_at, deleted_by, created_at, updated_at`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct scans productColumns followed by any extra selected columns
+func scanProduct(row rowScanner, extra ...interface{}) (*model.Product, error) {
+	product := &model.Product{}
+	var prices, variantOptions, variantSummary, images, attributes []byte
+	dest := append([]interface{}{
+		&product.ID, &product.SKU, &product.ExternalID, &product.Name, &product.Slug, &product.Description, &product.Price.Amount, &product.Price.Currency,
+		&prices, &product.Category, &product.CategoryID, &product.Stock, &product.Available, &product.ReorderThreshold, &product.CreatedBy,
+		&variantOptions, &variantSummary, &images, &attributes,
+		&product.SEO.MetaTitle, &product.SEO.MetaDescription, &product.SEO.CanonicalURL, &product.Status, &product.PublishAt, &product.UnpublishAt,
+		&product.Version, &product.DeletedAt, &product.DeletedBy, &product.CreatedAt, &product.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(prices, &product.Prices); err != nil {
+		return nil, fmt.Errorf("failed to decode prices: %w", err)
+	}
+
+	if err := json.Unmarshal(variantOptions, &product.VariantOptions); err != nil {
+		return nil, fmt.Errorf("failed to decode variant options: %w", err)
+	}
+
+	var summary model.VariantSummary
+	if err := json.Unmarshal(variantSummary, &summary); err != nil {
+		return nil, fmt.Errorf("failed to decode variant summary: %w", err)
+	}
+	if summary.Count > 0 {
+		product.Variants = &summary
+	}
+
+	if err := json.Unmarshal(images, &product.Images); err != nil {
+		return nil, fmt.Errorf("failed to decode images: %w", err)
+	}
+
+	if err := json.Unmarshal(attributes, &product.Attributes); err != nil {
+		return nil, fmt.Errorf("failed to decode attributes: %w", err)
+	}
+
+	return product, nil
+}
+
 type ProductRepository interface {
 	Create(product *model.CreateProductRequest) (*model.Product, error)
 	GetByID(id string) (*model.Product, error)
-	GetAll() ([]*model.Product, error)
-	Update(id string, product *model.UpdateProductRequest) (*model.Product, error)
-	Delete(id string) error
+	// GetBySlug returns the product with slug, or the product that had it, in
+	// which case redirected is set
+	GetBySlug(slug string) (product *model.Product, redirected bool, err error)
+	// GetByIDs returns the products of ids that are not in the trash, in no particular order
+	GetByIDs(ids []string) ([]*model.Product, error)
+	List(query *model.ProductListQuery) (*model.ProductPage, error)
+	Search(query *model.ProductSearchQuery) (*model.SearchPage, error)
+	Facets(filter *model.ProductFilter, search string, request *model.FacetRequest, priceRanges []model.PriceRange) (model.Facets, error)
+	// Export streams every product matching filter to fn in ID order
+	Export(filter *model.ProductFilter, fn func(*model.Product) error) error
+	// Update replaces every editable field of a product if it matches precondition.
+	// Stock is not editable: a req with a different stock fails with ErrStockReadOnly.
+	Update(id string, product *model.UpdateProductRequest, precondition *model.Precondition) (*model.Product, error)
+	// Delete moves a product to the trash
+	Delete(id string, precondition *model.Precondition, deletedBy string) error
+	// Restore takes a product out of the trash
+	Restore(id string) (*model.Product, error)
+	// SetStatus moves a product to the status of req at now, if its current status
+	// allows it and it matches precondition
+	SetStatus(id string, req *model.StatusRequest, now time.Time, precondition *model.Precondition) (*model.Product, error)
+	// PublishDue makes up to limit scheduled products whose publish_at has passed active
+	PublishDue(now time.Time, limit int) (int, error)
+	// UnpublishDue archives up to limit active products whose unpublish_at has passed
+	UnpublishDue(now time.Time, limit int) (int, error)
+	// Purge permanently deletes up to limit products that were moved to the trash before
+	Purge(before time.Time, limit int) (int64, error)
 	Exists(id string) (bool, error)
+	// EraseUserReferences clears every reference to userID in one transaction and
+	// returns how many rows changed
+	EraseUserReferences(userID string) (int64, error)
 }
 
 type productRepository struct {
@@ -32,34 +122,71 @@ func NewProductRepository(db *sql.DB, logger *zap.SugaredLogger) ProductReposito
 }
 
 func (r *productRepository) Create(req *model.CreateProductRequest) (*model.Product, error) {
-	product := &model.Product{
-		ID:          uuid.New().String(),
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Category:    req.Category,
-		Stock:       req.Stock,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	now := time.Now()
+	var createdBy, categoryID *string
+	if req.CreatedBy != "" {
+		createdBy = &req.CreatedBy
+	}
+	if req.CategoryID != "" {
+		categoryID = &req.CategoryID
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setStockContext(tx, model.StockReasonInitial, "", req.CreatedBy, ""); err != nil {
+		return nil, err
+	}
+	if err := setPriceContext(tx, model.PriceSourceInitial, "", req.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	attributes, err := attributesJSON(req.Attributes)
+	if err != nil {
+		return nil, err
 	}
 
+	productSlug := req.Slug
+	if productSlug == "" {
+		productSlug, err = generateSlug(tx, req.Name)
+	} else {
+		err = claimSlug(tx, productSlug, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
 	query := `
-        INSERT INTO products (id, name, description, price, category, stock, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-        RETURNING id, name, description, price, category, stock, created_at, updated_at
-    `
+        INSERT INTO products (id, sku, external_id, name, slug, description, price, currency, category_id, category, stock, reorder_threshold, attributes,
+            meta_title, meta_description, canonical_url, status, publish_at, unpublish_at, created_by, created_at, updated_at)
+        VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7, $8, $9, (SELECT name FROM categories WHERE id = $9), $10, $11, $12,
+            $13, $14, $15, $16, $17, $18, $19, $20, $21)`
 
-	err := r.db.QueryRow(
+	_, err = tx.Exec(
 		query,
-		product.ID, product.Name, product.Description, product.Price,
-		product.Category, product.Stock, product.CreatedAt, product.UpdatedAt,
-	).Scan(
-		&product.ID, &product.Name, &product.Description, &product.Price,
-		&product.Category, &product.Stock, &product.CreatedAt, &product.UpdatedAt,
+		id, req.SKU, req.ExternalID, req.Name, productSlug, req.Description, req.Price.Amount, req.Price.Currency,
+		categoryID, req.Stock, req.ReorderThreshold, attributes, req.SEO.MetaTitle, req.SEO.MetaDescription, req.SEO.CanonicalURL,
+		req.Status, req.PublishAt, req.UnpublishAt, createdBy, now, now,
 	)
+	if err != nil {
+		if err := translateProductError(err); err != nil {
+			return nil, err
+		}
+		r.logger.Errorw("Failed to create product", "error", err, "product", req)
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
 
+	if err := setPrices(tx, id, req.Prices); err != nil {
+		r.logger.Errorw("Failed to set product prices", "error", err, "product_id", id)
+		return nil, err
+	}
+
+	product, err := r.commit(tx, id)
 	if err != nil {
-		r.logger.Errorw("Failed to create product", "error", err, "product", product)
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 
@@ -67,20 +194,64 @@ func (r *productRepository) Create(req *model.CreateProductRequest) (*model.Prod
 	return product, nil
 }
 
+// commit reads the product back within tx and commits
+func (r *productRepository) commit(tx *sql.Tx, id string) (*model.Product, error) {
+	product, err := scanProduct(tx.QueryRow(`SELECT `+productColumns+` FROM products WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// setPrices replaces the price list of a product
+func setPrices(tx *sql.Tx, productID string, prices []model.Money) error {
+	if _, err := tx.Exec(`DELETE FROM product_prices WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to clear prices: %w", err)
+	}
+
+	for _, price := range prices {
+		query := `INSERT INTO product_prices (product_id, currency, amount) VALUES ($1, $2, $3)`
+		if _, err := tx.Exec(query, productID, price.Currency, price.Amount); err != nil {
+			return fmt.Errorf("failed to set %s price: %w", price.Currency, err)
+		}
+	}
+
+	return nil
+}
+
+// attributesJSON encodes attribute values for the attributes column
+func attributesJSON(attributes model.Attributes) ([]byte, error) {
+	if attributes == nil {
+		return []byte("{}"), nil
+	}
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attributes: %w", err)
+	}
+	return data, nil
+}
+
+// moneyAmount returns the amount of m, or nil to store NULL
+func moneyAmount(m *model.Money) *int64 {
+	if m == nil {
+		return nil
+	}
+	return &m.Amount
+}
+
 func (r *productRepository) GetByID(id string) (*model.Product, error) {
-	query := `SELECT id, name, description, price, category, stock, created_at, updated_at 
-              FROM products WHERE id = $1`
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = $1 AND deleted_at IS NULL`
 
-	product := &model.Product{}
-	err := r.db.QueryRow(query, id).Scan(
-		&product.ID, &product.Name, &product.Description, &product.Price,
-		&product.Category, &product.Stock, &product.CreatedAt, &product.UpdatedAt,
-	)
+	product, err := scanProduct(r.db.QueryRow(query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Warnw("Product not found", "product_id", id)
-			return nil, fmt.Errorf("product not found")
+			return nil, model.ErrProductNotFound
 		}
 		r.logger.Errorw("Failed to get product", "error", err, "product_id", id)
 		return nil, fmt.Errorf("failed to get product: %w", err)
@@ -89,24 +260,79 @@ func (r *productRepository) GetByID(id string) (*model.Product, error) {
 	return product, nil
 }
 
-func (r *productRepository) GetAll() ([]*model.Product, error) {
-	query := `SELECT id, name, description, price, category, stock, created_at, updated_at 
-              FROM products ORDER BY created_at DESC`
+func (r *productRepository) GetByIDs(ids []string) ([]*model.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = ANY($1) AND deleted_at IS NULL`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, pq.Array(ids))
 	if err != nil {
 		r.logger.Errorw("Failed to get products", "error", err)
 		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
 	defer rows.Close()
 
-	var products []*model.Product
+	products := []*model.Product{}
 	for rows.Next() {
-		product := &model.Product{}
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description, &product.Price,
-			&product.Category, &product.Stock, &product.CreatedAt, &product.UpdatedAt,
-		)
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+	return products, nil
+}
+
+func (r *productRepository) List(query *model.ProductListQuery) (*model.ProductPage, error) {
+	filter := &queryBuilder{}
+	applyProductFilter(filter, &query.Filter)
+
+	total, err := r.count(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sort, order := query.Sort, query.Order
+	var position *cursor
+	if query.Cursor != "" {
+		if position, err = decodeCursor(query.Cursor); err != nil {
+			return nil, err
+		}
+		sort, order = position.Sort, position.Order
+	}
+	column := sortColumns[sort]
+
+	// Paging backwards scans in the opposite direction and reverses the rows
+	backward := position != nil && position.Prev
+	descending := (order == model.SortDesc) != backward
+	comparison, direction := ">", "ASC"
+	if descending {
+		comparison, direction = "<", "DESC"
+	}
+
+	b := filter.clone()
+	if position != nil {
+		b.where(fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			column.name, comparison, b.arg(position.Value), column.cast, b.arg(position.ID)))
+	}
+
+	sqlQuery := `SELECT ` + productColumns + ` FROM products` + b.whereClause() +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column.name, direction, direction, b.arg(query.Limit+1))
+	if position == nil && query.Page > 1 {
+		sqlQuery += " OFFSET " + b.arg((query.Page-1)*query.Limit)
+	}
+
+	rows, err := r.db.Query(sqlQuery, b.args...)
+	if err != nil {
+		r.logger.Errorw("Failed to get products", "error", err)
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []*model.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
 		if err != nil {
 			r.logger.Errorw("Failed to scan product", "error", err)
 			return nil, fmt.Errorf("failed to scan product: %w", err)
@@ -119,42 +345,122 @@ func (r *productRepository) GetAll() ([]*model.Product, error) {
 		return nil, fmt.Errorf("error iterating products: %w", err)
 	}
 
-	r.logger.Infow("Retrieved products successfully", "count", len(products))
-	return products, nil
+	hasMore := len(products) > query.Limit
+	if hasMore {
+		products = products[:query.Limit]
+	}
+	if backward {
+		for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+			products[i], products[j] = products[j], products[i]
+		}
+	}
+
+	page := &model.ProductPage{
+		Products: products,
+		Total:    total,
+		Limit:    query.Limit,
+	}
+	if position == nil {
+		page.Page = query.Page
+	}
+
+	if len(products) > 0 {
+		// Coming back from a later page there is always a next one
+		if hasMore || backward {
+			page.NextCursor = newCursor(products[len(products)-1], sort, order, false).encode()
+		}
+		if (backward && hasMore) || (!backward && (position != nil || query.Page > 1)) {
+			page.PrevCursor = newCursor(products[0], sort, order, true).encode()
+		}
+	}
+
+	r.logger.Infow("Retrieved products successfully", "count", len(products), "total", total)
+	return page, nil
 }
 
-func (r *productRepository) Update(id string, req *model.UpdateProductRequest) (*model.Product, error) {
-	// First check if product exists
-	exists, err := r.Exists(id)
+// count returns how many products match the conditions in b
+func (r *productRepository) count(b *queryBuilder) (int, error) {
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM products`+b.whereClause(), b.args...).Scan(&total); err != nil {
+		r.logger.Errorw("Failed to count products", "error", err)
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return total, nil
+}
+
+func (r *productRepository) Update(id string, req *model.UpdateProductRequest, precondition *model.Precondition) (*model.Product, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockVersion(tx, id, precondition); err != nil {
 		return nil, err
 	}
-	if !exists {
-		return nil, fmt.Errorf("product not found")
+	// Adjustments and reservations change stock without a new version, so it is
+	// compared under the same lock
+	if req.Stock != nil {
+		var stock int
+		if err := tx.QueryRow(`SELECT stock FROM products WHERE id = $1`, id).Scan(&stock); err != nil {
+			return nil, fmt.Errorf("failed to get product stock: %w", err)
+		}
+		if *req.Stock != stock {
+			return nil, model.ErrStockReadOnly
+		}
+	}
+	if err := setPriceContext(tx, model.PriceSourceManual, "", req.UpdatedBy); err != nil {
+		return nil, err
+	}
+	attributes, err := attributesJSON(req.Attributes)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
-        UPDATE products 
-        SET name = COALESCE($1, name),
-            description = COALESCE($2, description),
-            price = COALESCE($3, price),
-            category = COALESCE($4, category),
-            stock = COALESCE($5, stock),
-            updated_at = $6
-        WHERE id = $7
-        RETURNING id, name, description, price, category, stock, created_at, updated_at
-    `
+        UPDATE products
+        SET sku = $1,
+            external_id = $2,
+            name = $3,
+            description = $4,
+            price = $5,
+            currency = $6,
+            category_id = $7,
+            category = (SELECT name FROM categories WHERE id = $7),
+            reorder_threshold = $8,
+            attributes = $9,
+            meta_title = $10,
+            meta_description = $11,
+            canonical_url = $12,
+            updated_at = $13
+        WHERE id = $14`
 
-	product := &model.Product{}
-	err = r.db.QueryRow(
+	_, err = tx.Exec(
 		query,
-		req.Name, req.Description, req.Price, req.Category, req.Stock,
+		req.SKU, req.ExternalID, req.Name, req.Description, req.Price.Amount, req.Price.Currency,
+		req.CategoryID, req.ReorderThreshold, attributes, req.SEO.MetaTitle, req.SEO.MetaDescription, req.SEO.CanonicalURL,
 		time.Now(), id,
-	).Scan(
-		&product.ID, &product.Name, &product.Description, &product.Price,
-		&product.Category, &product.Stock, &product.CreatedAt, &product.UpdatedAt,
 	)
+	if err != nil {
+		if err := translateProductError(err); err != nil {
+			return nil, err
+		}
+		r.logger.Errorw("Failed to update product", "error", err, "product_id", id)
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
 
+	if err := setPrices(tx, id, req.Prices); err != nil {
+		r.logger.Errorw("Failed to set product prices", "error", err, "product_id", id)
+		return nil, err
+	}
+
+	if req.Slug != "" {
+		if err := changeSlug(tx, id, req.Slug); err != nil {
+			return nil, err
+		}
+	}
+
+	product, err := r.commit(tx, id)
 	if err != nil {
 		r.logger.Errorw("Failed to update product", "error", err, "product_id", id)
 		return nil, fmt.Errorf("failed to update product: %w", err)
@@ -164,28 +470,164 @@ func (r *productRepository) Update(id string, req *model.UpdateProductRequest) (
 	return product, nil
 }
 
-func (r *productRepository) Delete(id string) error {
-	exists, err := r.Exists(id)
+func (r *productRepository) Delete(id string, precondition *model.Precondition, deletedBy string) error {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	if !exists {
-		return fmt.Errorf("product not found")
+	defer tx.Rollback()
+
+	if err := lockVersion(tx, id, precondition); err != nil {
+		return err
 	}
 
-	query := `DELETE FROM products WHERE id = $1`
-	result, err := r.db.Exec(query, id)
-	if err != nil {
+	query := `UPDATE products SET deleted_at = $1, deleted_by = NULLIF($2, '') WHERE id = $3`
+	if _, err := tx.Exec(query, time.Now(), deletedBy, id); err != nil {
 		r.logger.Errorw("Failed to delete product", "error", err, "product_id", id)
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
-	r.logger.Infow("Product deleted successfully", "product_id", id)
+	r.logger.Infow("Product moved to trash", "product_id", id, "deleted_by", deletedBy)
+	return nil
+}
+
+func (r *productRepository) Restore(id string) (*model.Product, error) {
+	query := `
+        UPDATE products
+        SET deleted_at = NULL, deleted_by = NULL, updated_at = $1
+        WHERE id = $2 AND deleted_at IS NOT NULL
+        RETURNING ` + productColumns
+
+	product, err := scanProduct(r.db.QueryRow(query, time.Now(), id))
+	if err == sql.ErrNoRows {
+		exists, err := r.Exists(id)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, model.ErrProductNotDeleted
+		}
+		return nil, model.ErrProductNotFound
+	}
+	if err != nil {
+		r.logger.Errorw("Failed to restore product", "error", err, "product_id", id)
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	r.logger.Infow("Product restored successfully", "product_id", id)
+	return product, nil
+}
+
+func (r *productRepository) Purge(before time.Time, limit int) (int64, error) {
+	query := `
+        DELETE FROM products
+        WHERE id IN (
+            SELECT id FROM products
+            WHERE deleted_at < $1
+            ORDER BY deleted_at
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED)`
+
+	result, err := r.db.Exec(query, before, limit)
+	if err != nil {
+		r.logger.Errorw("Failed to purge deleted products", "error", err)
+		return 0, fmt.Errorf("failed to purge deleted products: %w", err)
+	}
+
+	purged, _ := result.RowsAffected()
+	return purged, nil
+}
+
+func (r *productRepository) SetStatus(id string, req *model.StatusRequest, now time.Time, precondition *model.Precondition) (*model.Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var version int64
+	var status string
+	var publishedAt *time.Time
+	query := `SELECT version, status, publish_at FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(query, id).Scan(&version, &status, &publishedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to lock product: %w", err)
+	}
+	if !precondition.Matches(version) {
+		return nil, model.ErrVersionMismatch
+	}
+	if !model.CanTransition(status, req.Status) {
+		return nil, fmt.Errorf("%w: a %s product can not become %s", model.ErrStatusTransition, status, req.Status)
+	}
+
+	publishAt, unpublishAt := req.Schedule(status, publishedAt, now)
+	query = `UPDATE products SET status = $1, publish_at = $2, unpublish_at = $3, updated_at = $4 WHERE id = $5`
+	if _, err := tx.Exec(query, req.Status, publishAt, unpublishAt, now, id); err != nil {
+		r.logger.Errorw("Failed to set product status", "error", err, "product_id", id, "status", req.Status)
+		return nil, fmt.Errorf("failed to set product status: %w", err)
+	}
+
+	product, err := r.commit(tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product status: %w", err)
+	}
+
+	r.logger.Infow("Product status changed", "product_id", id, "from", status, "to", req.Status)
+	return product, nil
+}
+
+func (r *productRepository) PublishDue(now time.Time, limit int) (int, error) {
+	return r.transitionDue(model.StatusScheduled, model.StatusActive, "publish_at", now, limit)
+}
+
+func (r *productRepository) UnpublishDue(now time.Time, limit int) (int, error) {
+	return r.transitionDue(model.StatusActive, model.StatusArchived, "unpublish_at", now, limit)
+}
+
+// transitionDue moves up to limit live products in status from whose column has
+// passed to status to, skipping products locked by another writer
+func (r *productRepository) transitionDue(from, to, column string, now time.Time, limit int) (int, error) {
+	query := `
+        UPDATE products SET status = $1, updated_at = $2
+        WHERE id IN (
+            SELECT id FROM products
+            WHERE status = $3 AND ` + column + ` <= $2 AND deleted_at IS NULL
+            ORDER BY ` + column + `
+            LIMIT $4
+            FOR UPDATE SKIP LOCKED)`
+
+	result, err := r.db.Exec(query, to, now, from, limit)
+	if err != nil {
+		r.logger.Errorw("Failed to transition due products", "error", err, "from", from, "to", to)
+		return 0, fmt.Errorf("failed to transition due products: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to transition due products: %w", err)
+	}
+	return int(count), nil
+}
+
+// lockVersion locks a live product for the rest of tx and checks it matches precondition
+func lockVersion(tx *sql.Tx, id string, precondition *model.Precondition) error {
+	var version int64
+	if err := tx.QueryRow(`SELECT version FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return model.ErrProductNotFound
+		}
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	if !precondition.Matches(version) {
+		return model.ErrVersionMismatch
+	}
 	return nil
 }
 
@@ -198,3 +640,43 @@ func (r *productRepository) Exists(id string) (bool, error) {
 	}
 	return exists, nil
 }
+
+// userReferences are the columns that record which user created or changed something
+var userReferences = []struct{ table, column string }{
+	{"products", "created_by"},
+	{"products", "deleted_by"},
+	{"import_jobs", "created_by"},
+	{"stock_movements", "actor_id"},
+	{"reservations", "created_by"},
+	{"stock_alerts", "acknowledged_by"},
+	{"price_history", "actor_id"},
+	{"price_schedules", "created_by"},
+	{"promotions", "created_by"},
+	{"coupon_redemptions", "redeemed_by"},
+	{"product_images", "created_by"},
+}
+
+func (r *productRepository) EraseUserReferences(userID string) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int64
+	for _, ref := range userReferences {
+		query := fmt.Sprintf(`UPDATE %s SET %s = NULL WHERE %s = $1`, ref.table, ref.column, ref.column)
+		result, err := tx.Exec(query, userID)
+		if err != nil {
+			r.logger.Errorw("Failed to erase user references", "error", err, "user_id", userID, "table", ref.table, "column", ref.column)
+			return 0, fmt.Errorf("failed to erase user references in %s.%s: %w", ref.table, ref.column, err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		count += rowsAffected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to erase user references: %w", err)
+	}
+	return count, nil
+}
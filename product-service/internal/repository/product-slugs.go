@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/pkg/slug"
+)
+
+// defaultSlug is the base slug of products whose name has no letters or digits
+const defaultSlug = "product"
+
+// maxSlugSuffix is the room left after a generated base slug for its numeric suffix
+const maxSlugSuffix = 8
+
+// lockSlug serializes the writers of a slug for the rest of tx, so a slug is
+// checked and taken by one of them at a time
+func lockSlug(tx *sql.Tx, value string) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('product_slug:' || $1))`, value); err != nil {
+		return fmt.Errorf("failed to lock slug: %w", err)
+	}
+	return nil
+}
+
+// generateSlug returns a slug for a product named name no product or redirect
+// uses: the slug of the name, else the lowest numbered one from -2 on
+func generateSlug(tx *sql.Tx, name string) (string, error) {
+	base := slug.Make(name)
+	if len(base) > slug.MaxLength-maxSlugSuffix {
+		base = strings.TrimRight(base[:slug.MaxLength-maxSlugSuffix], "-")
+	}
+	if base == "" {
+		base = defaultSlug
+	}
+	if err := lockSlug(tx, base); err != nil {
+		return "", err
+	}
+
+	// Slugs only hold letters, digits and hyphens, so base has no LIKE wildcards
+	query := `
+        SELECT slug FROM products WHERE slug = $1 OR slug LIKE $2
+        UNION
+        SELECT slug FROM product_slug_redirects WHERE slug = $1 OR slug LIKE $2`
+	rows, err := tx.Query(query, base, base+"-%")
+	if err != nil {
+		return "", fmt.Errorf("failed to find taken slugs: %w", err)
+	}
+	defer rows.Close()
+
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return "", fmt.Errorf("failed to find taken slugs: %w", err)
+		}
+		taken[value] = true
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to find taken slugs: %w", err)
+	}
+
+	candidate := base
+	for n := 2; taken[candidate]; n++ {
+		candidate = base + "-" + strconv.Itoa(n)
+	}
+	return candidate, nil
+}
+
+// claimSlug checks no product other than productID has or had value, and takes
+// value back from the redirects of productID. productID is empty for new products.
+func claimSlug(tx *sql.Tx, value, productID string) error {
+	if err := lockSlug(tx, value); err != nil {
+		return err
+	}
+
+	var owner string
+	query := `
+        SELECT id FROM products WHERE slug = $1
+        UNION ALL
+        SELECT product_id FROM product_slug_redirects WHERE slug = $1
+        LIMIT 1`
+	err := tx.QueryRow(query, value).Scan(&owner)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check slug: %w", err)
+	}
+	if err == nil && owner != productID {
+		return model.ErrDuplicateSlug
+	}
+
+	if _, err := tx.Exec(`DELETE FROM product_slug_redirects WHERE slug = $1`, value); err != nil {
+		return fmt.Errorf("failed to reclaim slug: %w", err)
+	}
+	return nil
+}
+
+// changeSlug moves a product to value and redirects its current slug there.
+// Nothing changes when value is its current slug.
+func changeSlug(tx *sql.Tx, productID, value string) error {
+	var current string
+	if err := tx.QueryRow(`SELECT slug FROM products WHERE id = $1`, productID).Scan(&current); err != nil {
+		return fmt.Errorf("failed to get slug: %w", err)
+	}
+	if value == current {
+		return nil
+	}
+
+	if err := claimSlug(tx, value, productID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO product_slug_redirects (slug, product_id) VALUES ($1, $2)`, current, productID); err != nil {
+		return fmt.Errorf("failed to redirect slug: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE products SET slug = $1 WHERE id = $2`, value, productID); err != nil {
+		if err := translateProductError(err); err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to change slug: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetBySlug(value string) (*model.Product, bool, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE slug = $1 AND deleted_at IS NULL`
+	product, err := scanProduct(r.db.QueryRow(query, value))
+	if err == nil {
+		return product, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Errorw("Failed to get product by slug", "error", err, "slug", value)
+		return nil, false, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	query = `
+        SELECT ` + productColumns + ` FROM products
+        WHERE id = (SELECT product_id FROM product_slug_redirects WHERE slug = $1) AND deleted_at IS NULL`
+	product, err = scanProduct(r.db.QueryRow(query, value))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, model.ErrProductNotFound
+		}
+		r.logger.Errorw("Failed to get product by former slug", "error", err, "slug", value)
+		return nil, false, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, true, nil
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// promotionColumns is the column list every promotion query selects, in scanPromotion order
+const promotionColumns = `id, name, description, type, percent, amount, currency, buy_quantity, get_quantity,
+        product_id, category_id, coupon_code, usage_limit, usage_count, starts_at, ends_at, active, created_by,
+        created_at, updated_at`
+
+type PromotionRepository interface {
+	Create(req *model.PromotionRequest) (*model.Promotion, error)
+	GetByID(id string) (*model.Promotion, error)
+	// List returns every promotion, newest first
+	List() ([]*model.Promotion, error)
+	Update(id string, req *model.PromotionRequest) (*model.Promotion, error)
+	Delete(id string) error
+	// ForQuote returns the automatic promotions in effect at now and the
+	// promotions of codes whatever their state, oldest first
+	ForQuote(now time.Time, codes []string) ([]*model.Promotion, error)
+	// CategoryPaths returns the category of every product and the ancestors of
+	// that category, which promotions may target the product through
+	CategoryPaths(productIDs []string) (map[string][]string, error)
+	// Redeem uses a coupon up once for an order
+	Redeem(req *model.RedeemCouponRequest, now time.Time) (*model.Promotion, error)
+}
+
+type promotionRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewPromotionRepository(db *sql.DB, logger *zap.SugaredLogger) PromotionRepository {
+	return &promotionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func scanPromotion(row rowScanner) (*model.Promotion, error) {
+	promotion := &model.Promotion{}
+	var amount sql.NullInt64
+	var currency sql.NullString
+	err := row.Scan(
+		&promotion.ID, &promotion.Name, &promotion.Description, &promotion.Type, &promotion.Percent, &amount, &currency,
+		&promotion.BuyQuantity, &promotion.GetQuantity, &promotion.ProductID, &promotion.CategoryID, &promotion.CouponCode,
+		&promotion.UsageLimit, &promotion.UsageCount, &promotion.StartsAt, &promotion.EndsAt, &promotion.Active,
+		&promotion.CreatedBy, &promotion.CreatedAt, &promotion.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount.Valid {
+		promotion.Amount = &model.Money{Amount: amount.Int64, Currency: currency.String}
+	}
+	return promotion, nil
+}
+
+// promotionValues are the columns a promotion request writes, in the order of
+// the INSERT and UPDATE statements
+func promotionValues(req *model.PromotionRequest) []interface{} {
+	var amount *int64
+	var currency *string
+	if req.Amount != nil {
+		amount, currency = &req.Amount.Amount, &req.Amount.Currency
+	}
+	return []interface{}{
+		req.Name, req.Description, req.Type, req.Percent, amount, currency, req.BuyQuantity, req.GetQuantity,
+		nullString(req.ProductID), nullString(req.CategoryID), nullString(req.CouponCode), req.UsageLimit,
+		req.StartsAt, req.EndsAt, req.IsActive(),
+	}
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// translatePromotionError maps constraint violations of promotion writes to model errors, or returns nil
+func translatePromotionError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+
+	switch {
+	case pqErr.Constraint == "promotions_product_id_fkey":
+		return model.ErrProductNotFound
+	case pqErr.Constraint == "promotions_category_id_fkey":
+		return model.ErrCategoryNotFound
+	case pqErr.Constraint == "idx_promotions_coupon_code":
+		return model.ErrDuplicateCoupon
+	case pqErr.Constraint == "promotions_usage_within_limit":
+		return fmt.Errorf("%w: usage_limit is below the usage count", model.ErrInvalidPromotion)
+	default:
+		return nil
+	}
+}
+
+func (r *promotionRepository) Create(req *model.PromotionRequest) (*model.Promotion, error) {
+	id := uuid.New().String()
+	query := `
+        INSERT INTO promotions (name, description, type, percent, amount, currency, buy_quantity, get_quantity,
+            product_id, category_id, coupon_code, usage_limit, starts_at, ends_at, active,
+            id, created_by, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NULLIF($17, ''), $18, $18)
+        RETURNING ` + promotionColumns
+	args := append(promotionValues(req), id, req.CreatedBy, time.Now())
+
+	promotion, err := scanPromotion(r.db.QueryRow(query, args...))
+	if err != nil {
+		if translated := translatePromotionError(err); translated != nil {
+			return nil, translated
+		}
+		r.logger.Errorw("Failed to create promotion", "error", err)
+		return nil, fmt.Errorf("failed to create promotion: %w", err)
+	}
+
+	r.logger.Infow("Promotion created", "promotion_id", id, "type", req.Type)
+	return promotion, nil
+}
+
+func (r *promotionRepository) GetByID(id string) (*model.Promotion, error) {
+	promotion, err := scanPromotion(r.db.QueryRow(`SELECT `+promotionColumns+` FROM promotions WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrPromotionNotFound
+		}
+		r.logger.Errorw("Failed to get promotion", "error", err, "promotion_id", id)
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+	return promotion, nil
+}
+
+func (r *promotionRepository) List() ([]*model.Promotion, error) {
+	return r.query(`SELECT ` + promotionColumns + ` FROM promotions ORDER BY created_at DESC, id DESC`)
+}
+
+func (r *promotionRepository) query(query string, args ...interface{}) ([]*model.Promotion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Errorw("Failed to list promotions", "error", err)
+		return nil, fmt.Errorf("failed to list promotions: %w", err)
+	}
+	defer rows.Close()
+
+	promotions := []*model.Promotion{}
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan promotion: %w", err)
+		}
+		promotions = append(promotions, promotion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+func (r *promotionRepository) Update(id string, req *model.PromotionRequest) (*model.Promotion, error) {
+	query := `
+        UPDATE promotions
+        SET name = $1, description = $2, type = $3, percent = $4, amount = $5, currency = $6, buy_quantity = $7,
+            get_quantity = $8, product_id = $9, category_id = $10, coupon_code = $11, usage_limit = $12,
+            starts_at = $13, ends_at = $14, active = $15, updated_at = $16
+        WHERE id = $17
+        RETURNING ` + promotionColumns
+	args := append(promotionValues(req), time.Now(), id)
+
+	promotion, err := scanPromotion(r.db.QueryRow(query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrPromotionNotFound
+		}
+		if translated := translatePromotionError(err); translated != nil {
+			return nil, translated
+		}
+		r.logger.Errorw("Failed to update promotion", "error", err, "promotion_id", id)
+		return nil, fmt.Errorf("failed to update promotion: %w", err)
+	}
+
+	r.logger.Infow("Promotion updated", "promotion_id", id)
+	return promotion, nil
+}
+
+func (r *promotionRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM promotions WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Errorw("Failed to delete promotion", "error", err, "promotion_id", id)
+		return fmt.Errorf("failed to delete promotion: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return model.ErrPromotionNotFound
+	}
+
+	r.logger.Infow("Promotion deleted", "promotion_id", id)
+	return nil
+}
+
+func (r *promotionRepository) ForQuote(now time.Time, codes []string) ([]*model.Promotion, error) {
+	return r.query(`
+        SELECT `+promotionColumns+` FROM promotions
+        WHERE (coupon_code IS NULL AND active
+               AND (starts_at IS NULL OR starts_at <= $1) AND (ends_at IS NULL OR ends_at > $1))
+           OR coupon_code = ANY($2)
+        ORDER BY created_at, id`, now, pq.Array(codes))
+}
+
+func (r *promotionRepository) CategoryPaths(productIDs []string) (map[string][]string, error) {
+	rows, err := r.db.Query(`
+        WITH RECURSIVE path AS (
+            SELECT p.id AS product_id, c.id, c.parent_id
+            FROM products p JOIN categories c ON c.id = p.category_id
+            WHERE p.id = ANY($1)
+            UNION
+            SELECT path.product_id, c.id, c.parent_id
+            FROM categories c JOIN path ON c.id = path.parent_id
+        )
+        SELECT product_id, id FROM path`, pq.Array(productIDs))
+	if err != nil {
+		r.logger.Errorw("Failed to get product category paths", "error", err)
+		return nil, fmt.Errorf("failed to get product category paths: %w", err)
+	}
+	defer rows.Close()
+
+	paths := make(map[string][]string, len(productIDs))
+	for rows.Next() {
+		var productID, categoryID string
+		if err := rows.Scan(&productID, &categoryID); err != nil {
+			return nil, fmt.Errorf("failed to scan product category: %w", err)
+		}
+		paths[productID] = append(paths[productID], categoryID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product categories: %w", err)
+	}
+	return paths, nil
+}
+
+func (r *promotionRepository) Redeem(req *model.RedeemCouponRequest, now time.Time) (*model.Promotion, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the promotion serialises redemptions against the usage limit
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE coupon_code = $1 FOR UPDATE`
+	promotion, err := scanPromotion(tx.QueryRow(query, req.Code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrCouponNotFound
+		}
+		return nil, fmt.Errorf("failed to lock promotion: %w", err)
+	}
+
+	var redeemed bool
+	query = `SELECT EXISTS(SELECT 1 FROM coupon_redemptions WHERE promotion_id = $1 AND reference_id = $2)`
+	if err := tx.QueryRow(query, promotion.ID, req.ReferenceID).Scan(&redeemed); err != nil {
+		return nil, fmt.Errorf("failed to check coupon redemption: %w", err)
+	}
+	if redeemed {
+		return promotion, nil
+	}
+
+	switch promotion.Availability(now) {
+	case "":
+	case model.CouponExhausted:
+		return nil, model.ErrCouponExhausted
+	default:
+		return nil, model.ErrCouponUnavailable
+	}
+
+	query = `INSERT INTO coupon_redemptions (promotion_id, reference_id, redeemed_by, redeemed_at) VALUES ($1, $2, NULLIF($3, ''), $4)`
+	if _, err := tx.Exec(query, promotion.ID, req.ReferenceID, req.RedeemedBy, now); err != nil {
+		r.logger.Errorw("Failed to record coupon redemption", "error", err, "promotion_id", promotion.ID)
+		return nil, fmt.Errorf("failed to record coupon redemption: %w", err)
+	}
+
+	id := promotion.ID
+	query = `UPDATE promotions SET usage_count = usage_count + 1, updated_at = $1 WHERE id = $2 RETURNING ` + promotionColumns
+	promotion, err = scanPromotion(tx.QueryRow(query, now, id))
+	if err != nil {
+		r.logger.Errorw("Failed to count coupon redemption", "error", err, "promotion_id", id)
+		return nil, fmt.Errorf("failed to count coupon redemption: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to redeem coupon: %w", err)
+	}
+
+	r.logger.Infow("Coupon redeemed", "promotion_id", promotion.ID, "reference_id", req.ReferenceID,
+		"usage_count", promotion.UsageCount)
+	return promotion, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/lib/pq"
+)
+
+// queryBuilder collects WHERE conditions and their positional arguments
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg appends value and returns its placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// whereClause returns the conditions joined with AND, or an empty string
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+func (b *queryBuilder) clone() *queryBuilder {
+	return &queryBuilder{
+		conditions: append([]string(nil), b.conditions...),
+		args:       append([]interface{}(nil), b.args...),
+	}
+}
+
+// applyProductFilter adds the conditions for filter. Products in the trash are
+// only matched when the filter asks for them, and products that are not active
+// when it names their status.
+func applyProductFilter(b *queryBuilder, filter *model.ProductFilter) {
+	if filter.Deleted {
+		b.where("deleted_at IS NOT NULL")
+	} else {
+		b.where("deleted_at IS NULL")
+	}
+	if len(filter.Statuses) > 0 {
+		b.where("status = ANY(" + b.arg(pq.Array(filter.Statuses)) + ")")
+	} else if !filter.Deleted {
+		b.where("status = " + b.arg(model.StatusActive))
+	}
+	if len(filter.Categories) > 0 {
+		categories := b.arg(pq.Array(filter.Categories))
+		if filter.IncludeDescendants {
+			b.where(`category_id IN (
+                WITH RECURSIVE tree AS (
+                    SELECT id FROM categories WHERE id = ANY(` + categories + `) OR slug = ANY(` + categories + `)
+                    UNION
+                    SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+                )
+                SELECT id FROM tree)`)
+		} else {
+			b.where("category_id IN (SELECT id FROM categories WHERE id = ANY(" + categories + ") OR slug = ANY(" + categories + "))")
+		}
+	}
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		price := priceIn(b, filter.Currency)
+		if filter.MinPrice != nil {
+			b.where(price + " >= " + b.arg(*filter.MinPrice))
+		}
+		if filter.MaxPrice != nil {
+			b.where(price + " <= " + b.arg(*filter.MaxPrice))
+		}
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			b.where("stock - reserved > 0")
+		} else {
+			b.where("stock - reserved <= 0")
+		}
+	}
+	if filter.CreatedAfter != nil {
+		b.where("created_at >= " + b.arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		b.where("created_at < " + b.arg(*filter.CreatedBefore))
+	}
+	for _, attribute := range filter.Attributes {
+		applyAttributeFilter(b, &attribute)
+	}
+}
+
+// applyAttributeFilter adds the condition of a resolved attribute filter.
+// Equality is tested by containment, which the GIN index on attributes serves.
+func applyAttributeFilter(b *queryBuilder, filter *model.AttributeFilter) {
+	switch filter.Operator {
+	case model.AttributeEqual, model.AttributeNotEqual:
+		matches := make([]string, len(filter.Values))
+		for i, value := range filter.Values {
+			document, _ := json.Marshal(map[string]interface{}{filter.Code: value})
+			matches[i] = "products.attributes @> " + b.arg(string(document)) + "::jsonb"
+		}
+		if filter.Operator == model.AttributeEqual {
+			b.where("(" + strings.Join(matches, " OR ") + ")")
+		} else {
+			b.where("products.attributes ? " + b.arg(filter.Code) + " AND NOT (" + strings.Join(matches, " OR ") + ")")
+		}
+	case model.AttributeGreater, model.AttributeGreaterEqual, model.AttributeLess, model.AttributeLessEqual:
+		code := b.arg(filter.Code)
+		// The CASE keeps values that are not numbers from being cast
+		number := "CASE WHEN jsonb_typeof(products.attributes -> " + code + ") = 'number' THEN (products.attributes ->> " + code + ")::numeric END"
+		b.where(number + " " + filter.Operator + " " + b.arg(filter.Values[0]))
+	}
+}
+
+// priceIn returns an expression for the price of a product in currency: its
+// fixed price list entry, else its own price when in that currency, else NULL
+func priceIn(b *queryBuilder, currency string) string {
+	placeholder := b.arg(currency)
+	return `COALESCE(
+            (SELECT pp.amount FROM product_prices pp WHERE pp.product_id = products.id AND pp.currency = ` + placeholder + `),
+            CASE WHEN products.currency = ` + placeholder + ` THEN products.price END)`
+}
@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+const reservationColumns = `id, status, reference_id, created_by, expires_at, closed_at, created_at, updated_at`
+
+type ReservationRepository interface {
+	// Create reserves every item of req until expiresAt, or nothing when any of
+	// them is short of available stock
+	Create(req *model.CreateReservationRequest, expiresAt time.Time) (*model.Reservation, error)
+	GetByID(id string) (*model.Reservation, error)
+	// Commit turns the reserved units into sales recorded in the stock ledger. An
+	// active reservation past its expiry is expired instead.
+	Commit(id, actorID string) (*model.Reservation, error)
+	// Cancel releases the reserved units
+	Cancel(id string) (*model.Reservation, error)
+	// Expire releases at most limit active reservations that expired before now
+	// and returns how many were released
+	Expire(now time.Time, limit int) (int, error)
+}
+
+type reservationRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewReservationRepository(db *sql.DB, logger *zap.SugaredLogger) ReservationRepository {
+	return &reservationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func scanReservation(row rowScanner) (*model.Reservation, error) {
+	reservation := &model.Reservation{}
+	err := row.Scan(
+		&reservation.ID, &reservation.Status, &reservation.ReferenceID, &reservation.CreatedBy,
+		&reservation.ExpiresAt, &reservation.ClosedAt, &reservation.CreatedAt, &reservation.UpdatedAt,
+	)
+	return reservation, err
+}
+
+func (r *reservationRepository) Create(req *model.CreateReservationRequest, expiresAt time.Time) (*model.Reservation, error) {
+	// Products are locked in ID order so concurrent reservations can not deadlock
+	items := append([]*model.ReservationItem(nil), req.Items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ProductID < items[j].ProductID
+	})
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	id := uuid.New().String()
+	query := `
+        INSERT INTO reservations (id, status, reference_id, created_by, expires_at, created_at, updated_at)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $6)`
+	if _, err := tx.Exec(query, id, model.ReservationActive, req.ReferenceID, req.CreatedBy, expiresAt, now); err != nil {
+		r.logger.Errorw("Failed to create reservation", "error", err)
+		return nil, fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	for _, item := range items {
+		if err := reserveItem(tx, item); err != nil {
+			return nil, err
+		}
+
+		query := `INSERT INTO reservation_items (reservation_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)`
+		if _, err := tx.Exec(query, id, item.ProductID, item.VariantID, item.Quantity); err != nil {
+			r.logger.Errorw("Failed to create reservation item", "error", err, "reservation_id", id)
+			return nil, fmt.Errorf("failed to create reservation item: %w", err)
+		}
+	}
+
+	reservation, err := r.get(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	r.logger.Infow("Reservation created", "reservation_id", id, "items", len(items), "expires_at", expiresAt)
+	return reservation, nil
+}
+
+// reserveItem holds the quantity of item unless less of it is available. The
+// product is locked for the rest of the transaction; only active products can
+// be reserved.
+func reserveItem(tx *sql.Tx, item *model.ReservationItem) error {
+	var hasVariants bool
+	query := `
+        SELECT EXISTS(SELECT 1 FROM product_variants WHERE product_id = products.id)
+        FROM products WHERE id = $1 AND deleted_at IS NULL AND status = $2 FOR UPDATE`
+	if err := tx.QueryRow(query, item.ProductID, model.StatusActive).Scan(&hasVariants); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %s", model.ErrProductNotFound, item.ProductID)
+		}
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	switch {
+	case hasVariants && item.VariantID == nil:
+		return fmt.Errorf("%w: %s", model.ErrVariantRequired, item.ProductID)
+	case !hasVariants && item.VariantID != nil:
+		return fmt.Errorf("%w: %s", model.ErrVariantNotFound, *item.VariantID)
+	}
+
+	if !hasVariants {
+		query := `UPDATE products SET reserved = reserved + $1, updated_at = $2 WHERE id = $3 AND stock - reserved >= $1`
+		result, err := tx.Exec(query, item.Quantity, time.Now(), item.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to reserve stock: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return fmt.Errorf("%w: product %s", model.ErrInsufficientStock, item.ProductID)
+		}
+		return nil
+	}
+
+	var available int
+	query = `SELECT stock - reserved FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE`
+	if err := tx.QueryRow(query, *item.VariantID, item.ProductID).Scan(&available); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %s", model.ErrVariantNotFound, *item.VariantID)
+		}
+		return fmt.Errorf("failed to lock variant: %w", err)
+	}
+	if available < item.Quantity {
+		return fmt.Errorf("%w: variant %s", model.ErrInsufficientStock, *item.VariantID)
+	}
+
+	query = `UPDATE product_variants SET reserved = reserved + $1, updated_at = $2 WHERE id = $3`
+	if _, err := tx.Exec(query, item.Quantity, time.Now(), *item.VariantID); err != nil {
+		return fmt.Errorf("failed to reserve variant stock: %w", err)
+	}
+	return syncProductStock(tx, item.ProductID)
+}
+
+func (r *reservationRepository) GetByID(id string) (*model.Reservation, error) {
+	// The reservation and its items are read from one snapshot
+	tx, err := r.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reservation, err := r.get(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return reservation, tx.Commit()
+}
+
+func (r *reservationRepository) get(tx *sql.Tx, id string) (*model.Reservation, error) {
+	reservation, err := scanReservation(tx.QueryRow(`SELECT `+reservationColumns+` FROM reservations WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrReservationNotFound
+		}
+		r.logger.Errorw("Failed to get reservation", "error", err, "reservation_id", id)
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	if reservation.Items, err = reservationItems(tx, id); err != nil {
+		r.logger.Errorw("Failed to get reservation items", "error", err, "reservation_id", id)
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// reservationItems returns the items of a reservation in the order products are locked in
+func reservationItems(tx *sql.Tx, id string) ([]*model.ReservationItem, error) {
+	rows, err := tx.Query(`
+        SELECT product_id, variant_id, quantity FROM reservation_items
+        WHERE reservation_id = $1 ORDER BY product_id, variant_id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*model.ReservationItem{}
+	for rows.Next() {
+		item := &model.ReservationItem{}
+		if err := rows.Scan(&item.ProductID, &item.VariantID, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan reservation item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reservation items: %w", err)
+	}
+	return items, nil
+}
+
+func (r *reservationRepository) Commit(id, actorID string) (*model.Reservation, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reservation, err := r.lock(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reservation.ExpiresAt.After(time.Now()) {
+		if err := r.close(tx, reservation, model.ReservationExpired); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to expire reservation: %w", err)
+		}
+		r.logger.Infow("Reservation expired before commit", "reservation_id", id)
+		return nil, model.ErrReservationExpired
+	}
+
+	for _, item := range reservation.Items {
+		var variantID string
+		if item.VariantID != nil {
+			variantID = *item.VariantID
+		}
+		if err := setStockContext(tx, model.StockReasonSale, id, actorID, variantID); err != nil {
+			return nil, err
+		}
+		if err := sellItem(tx, item); err != nil {
+			r.logger.Errorw("Failed to commit reservation item", "error", err, "reservation_id", id,
+				"product_id", item.ProductID)
+			return nil, err
+		}
+	}
+
+	if err := setReservationStatus(tx, reservation, model.ReservationCommitted); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reservation: %w", err)
+	}
+
+	r.logger.Infow("Reservation committed", "reservation_id", id, "items", len(reservation.Items))
+	return reservation, nil
+}
+
+// sellItem removes the reserved units of item from the stock
+func sellItem(tx *sql.Tx, item *model.ReservationItem) error {
+	if item.VariantID == nil {
+		query := `UPDATE products SET stock = stock - $1, reserved = GREATEST(reserved - $1, 0), updated_at = $2 WHERE id = $3`
+		if _, err := tx.Exec(query, item.Quantity, time.Now(), item.ProductID); err != nil {
+			return fmt.Errorf("failed to commit stock: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+        UPDATE product_variants SET stock = stock - $1, reserved = GREATEST(reserved - $1, 0), updated_at = $2
+        WHERE id = $3 AND product_id = $4`
+	if _, err := tx.Exec(query, item.Quantity, time.Now(), *item.VariantID, item.ProductID); err != nil {
+		return fmt.Errorf("failed to commit variant stock: %w", err)
+	}
+	return syncProductStock(tx, item.ProductID)
+}
+
+func (r *reservationRepository) Cancel(id string) (*model.Reservation, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reservation, err := r.lock(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.close(tx, reservation, model.ReservationCancelled); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to cancel reservation: %w", err)
+	}
+
+	r.logger.Infow("Reservation cancelled", "reservation_id", id)
+	return reservation, nil
+}
+
+func (r *reservationRepository) Expire(now time.Time, limit int) (int, error) {
+	expired := 0
+	for expired < limit {
+		released, err := r.expireNext(now)
+		if err != nil {
+			return expired, err
+		}
+		if !released {
+			break
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// expireNext releases the oldest expired reservation in its own transaction,
+// skipping reservations being committed or cancelled meanwhile. It returns
+// false when there is none left.
+func (r *reservationRepository) expireNext(now time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        SELECT ` + reservationColumns + ` FROM reservations
+        WHERE status = $1 AND expires_at <= $2
+        ORDER BY expires_at
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED`
+	reservation, err := scanReservation(tx.QueryRow(query, model.ReservationActive, now))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Errorw("Failed to claim expired reservation", "error", err)
+		return false, fmt.Errorf("failed to claim expired reservation: %w", err)
+	}
+
+	if reservation.Items, err = reservationItems(tx, reservation.ID); err != nil {
+		return false, err
+	}
+	if err := r.close(tx, reservation, model.ReservationExpired); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to expire reservation: %w", err)
+	}
+	return true, nil
+}
+
+// lock locks an active reservation for the rest of the transaction
+func (r *reservationRepository) lock(tx *sql.Tx, id string) (*model.Reservation, error) {
+	var status string
+	if err := tx.QueryRow(`SELECT status FROM reservations WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("failed to lock reservation: %w", err)
+	}
+	if status != model.ReservationActive {
+		return nil, fmt.Errorf("%w: it is %s", model.ErrReservationNotActive, status)
+	}
+	return r.get(tx, id)
+}
+
+// close releases the units held by an active reservation and records its final status
+func (r *reservationRepository) close(tx *sql.Tx, reservation *model.Reservation, status string) error {
+	for _, item := range reservation.Items {
+		if err := releaseItem(tx, item); err != nil {
+			r.logger.Errorw("Failed to release reservation item", "error", err, "reservation_id", reservation.ID,
+				"product_id", item.ProductID)
+			return err
+		}
+	}
+	return setReservationStatus(tx, reservation, status)
+}
+
+// releaseItem makes the reserved units of item available again. Units of
+// variants deleted meanwhile are gone with the variant.
+func releaseItem(tx *sql.Tx, item *model.ReservationItem) error {
+	if item.VariantID == nil {
+		query := `UPDATE products SET reserved = GREATEST(reserved - $1, 0), updated_at = $2 WHERE id = $3`
+		if _, err := tx.Exec(query, item.Quantity, time.Now(), item.ProductID); err != nil {
+			return fmt.Errorf("failed to release stock: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+        UPDATE product_variants SET reserved = GREATEST(reserved - $1, 0), updated_at = $2
+        WHERE id = $3 AND product_id = $4`
+	if _, err := tx.Exec(query, item.Quantity, time.Now(), *item.VariantID, item.ProductID); err != nil {
+		return fmt.Errorf("failed to release variant stock: %w", err)
+	}
+	return syncProductStock(tx, item.ProductID)
+}
+
+func setReservationStatus(tx *sql.Tx, reservation *model.Reservation, status string) error {
+	now := time.Now()
+	query := `UPDATE reservations SET status = $1, closed_at = $2, updated_at = $2 WHERE id = $3`
+	if _, err := tx.Exec(query, status, now, reservation.ID); err != nil {
+		return fmt.Errorf("failed to update reservation: %w", err)
+	}
+
+	reservation.Status = status
+	reservation.ClosedAt, reservation.UpdatedAt = &now, now
+	return nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+// searchConfig is the text search configuration search_vector is built with
+const searchConfig = "english"
+
+// Matches are delimited with private use characters, so the text can be HTML
+// escaped before they are replaced with <mark> tags. They are removed from the
+// product text first.
+const (
+	markStart = "\uE000"
+	markStop  = "\uE001"
+)
+
+// headlineOptions mark matches and keep snippets short
+const headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop + ", MaxWords=35, MinWords=15, ShortWord=3, MaxFragments=2"
+
+var markReplacer = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// highlight HTML escapes a headline and marks its matches with <mark> tags
+func highlight(headline string) string {
+	return markReplacer.Replace(html.EscapeString(headline))
+}
+
+// typoWeight scales trigram similarity against the full-text rank so exact
+// matches always come first and near misses still surface
+const typoWeight = 0.3
+
+// prefixTSQuery turns search terms into a tsquery matching every term as a
+// prefix, so partially typed words match while the user is typing
+func prefixTSQuery(query string) string {
+	terms := model.SearchTerms(query)
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// applySearch restricts b to products matching query, either through the
+// full-text index or, for misspellings, by trigram word similarity on the name.
+// It returns the tsquery and rank expressions for the caller to select.
+func applySearch(b *queryBuilder, query string) (tsQuery, rank string) {
+	tsQuery = fmt.Sprintf("to_tsquery('%s', %s)", searchConfig, b.arg(prefixTSQuery(query)))
+	raw := b.arg(query)
+
+	b.where(fmt.Sprintf("(search_vector @@ %s OR %s <%% name)", tsQuery, raw))
+	rank = fmt.Sprintf("(ts_rank_cd(search_vector, %s) + %g * word_similarity(%s, name))", tsQuery, typoWeight, raw)
+	return tsQuery, rank
+}
+
+func (r *productRepository) Search(query *model.ProductSearchQuery) (*model.SearchPage, error) {
+	b := &queryBuilder{}
+	applyProductFilter(b, &query.Filter)
+	tsQuery, rank := applySearch(b, query.Query)
+
+	total, err := r.count(b)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy := "rank DESC, id"
+	if query.Sort != model.SortRelevance {
+		orderBy = fmt.Sprintf("%s %s, rank DESC, id", sortColumns[query.Sort].name, strings.ToUpper(query.Order))
+	}
+
+	sqlQuery := fmt.Sprintf(`
+        SELECT %s,
+            %s AS rank,
+            ts_headline('%s', translate(name, '%s', ''), %s, '%s'),
+            ts_headline('%s', translate(coalesce(description, ''), '%s', ''), %s, '%s')
+        FROM products%s
+        ORDER BY %s
+        LIMIT %s OFFSET %s`,
+		productColumns, rank,
+		searchConfig, markStart+markStop, tsQuery, headlineOptions,
+		searchConfig, markStart+markStop, tsQuery, headlineOptions,
+		b.whereClause(), orderBy,
+		b.arg(query.Limit), b.arg((query.Page-1)*query.Limit),
+	)
+
+	rows, err := r.db.Query(sqlQuery, b.args...)
+	if err != nil {
+		r.logger.Errorw("Failed to search products", "error", err, "query", query.Query)
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*model.SearchResult{}
+	for rows.Next() {
+		result := &model.SearchResult{}
+		result.Product, err = scanProduct(rows, &result.Rank, &result.Highlights.Name, &result.Highlights.Description)
+		if err != nil {
+			r.logger.Errorw("Failed to scan search result", "error", err)
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Highlights.Name = highlight(result.Highlights.Name)
+		result.Highlights.Description = highlight(result.Highlights.Description)
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Errorw("Error iterating search results", "error", err)
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	r.logger.Infow("Searched products successfully", "query", query.Query, "count", len(results), "total", total)
+	return &model.SearchPage{
+		Results: results,
+		Total:   total,
+		Page:    query.Page,
+		Limit:   query.Limit,
+	}, nil
+}
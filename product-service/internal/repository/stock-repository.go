@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+const stockMovementColumns = `id, product_id, variant_id, delta, reason, reference_id, actor_id, stock_after, created_at`
+
+type StockRepository interface {
+	// Adjust applies a delta to the stock of a product, or of one of its
+	// variants, and returns the recorded movement. Stock never goes below zero
+	// or below the units held by reservations.
+	Adjust(productID string, req *model.StockAdjustmentRequest) (*model.StockMovement, error)
+	// History returns a page of the ledger of a product, newest first
+	History(productID string, query *model.StockMovementQuery) (*model.StockHistory, error)
+}
+
+type stockRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewStockRepository(db *sql.DB, logger *zap.SugaredLogger) StockRepository {
+	return &stockRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// setStockContext describes the stock changes made in tx for the ledger trigger.
+// Empty values fall back to the trigger's defaults.
+func setStockContext(tx *sql.Tx, reason, referenceID, actorID, variantID string) error {
+	query := `SELECT set_config('stock.reason', $1, true), set_config('stock.reference_id', $2, true),
+        set_config('stock.actor_id', $3, true), set_config('stock.variant_id', $4, true)`
+	if _, err := tx.Exec(query, reason, referenceID, actorID, variantID); err != nil {
+		return fmt.Errorf("failed to set stock context: %w", err)
+	}
+	return nil
+}
+
+func scanStockMovement(row rowScanner) (*model.StockMovement, error) {
+	movement := &model.StockMovement{}
+	err := row.Scan(
+		&movement.ID, &movement.ProductID, &movement.VariantID, &movement.Delta, &movement.Reason,
+		&movement.ReferenceID, &movement.ActorID, &movement.StockAfter, &movement.CreatedAt,
+	)
+	return movement, err
+}
+
+func (r *stockRepository) Adjust(productID string, req *model.StockAdjustmentRequest) (*model.StockMovement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the product serialises adjustments, so the newest movement is ours
+	var hasVariants bool
+	query := `
+        SELECT EXISTS(SELECT 1 FROM product_variants WHERE product_id = products.id)
+        FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(query, productID).Scan(&hasVariants); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	switch {
+	case hasVariants && req.VariantID == "":
+		return nil, model.ErrVariantRequired
+	case !hasVariants && req.VariantID != "":
+		return nil, model.ErrVariantNotFound
+	}
+
+	if err := setStockContext(tx, req.Reason, req.ReferenceID, req.ActorID, req.VariantID); err != nil {
+		return nil, err
+	}
+
+	if hasVariants {
+		err = adjustVariantStock(tx, productID, req.VariantID, req.Delta)
+	} else {
+		err = adjustProductStock(tx, productID, req.Delta)
+	}
+	if err != nil {
+		if err != model.ErrInsufficientStock && err != model.ErrVariantNotFound {
+			r.logger.Errorw("Failed to adjust stock", "error", err, "product_id", productID)
+		}
+		return nil, err
+	}
+
+	movement, err := scanStockMovement(tx.QueryRow(
+		`SELECT `+stockMovementColumns+` FROM stock_movements WHERE product_id = $1 ORDER BY id DESC LIMIT 1`, productID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stock movement: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+
+	r.logger.Infow("Stock adjusted", "product_id", productID, "variant_id", req.VariantID,
+		"delta", req.Delta, "reason", req.Reason, "stock", movement.StockAfter)
+	return movement, nil
+}
+
+// adjustProductStock applies delta to a product sold without variants unless it
+// would take its stock below zero or below its reserved units
+func adjustProductStock(tx *sql.Tx, productID string, delta int) error {
+	query := `UPDATE products SET stock = stock + $1, updated_at = $2 WHERE id = $3 AND stock + $1 >= reserved`
+	result, err := tx.Exec(query, delta, time.Now(), productID)
+	if err != nil {
+		return fmt.Errorf("failed to adjust stock: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return model.ErrInsufficientStock
+	}
+	return nil
+}
+
+// adjustVariantStock applies delta to a variant unless it would take its stock
+// below zero or below its reserved units, then recomputes the product stock
+func adjustVariantStock(tx *sql.Tx, productID, variantID string, delta int) error {
+	query := `
+        UPDATE product_variants SET stock = stock + $1, updated_at = $2
+        WHERE id = $3 AND product_id = $4
+        RETURNING stock`
+	var stock int
+	err := tx.QueryRow(query, delta, time.Now(), variantID, productID).Scan(&stock)
+	if err == sql.ErrNoRows {
+		return model.ErrVariantNotFound
+	}
+	if hasErrorCode(err, checkViolation) {
+		return model.ErrInsufficientStock
+	}
+	if err != nil {
+		return fmt.Errorf("failed to adjust variant stock: %w", err)
+	}
+
+	return syncProductStock(tx, productID)
+}
+
+func (r *stockRepository) History(productID string, query *model.StockMovementQuery) (*model.StockHistory, error) {
+	history := &model.StockHistory{Movements: []*model.StockMovement{}}
+	err := r.db.QueryRow(`
+        SELECT stock, (SELECT COALESCE(SUM(delta), 0) FROM stock_movements WHERE product_id = products.id)
+        FROM products WHERE id = $1 AND deleted_at IS NULL`, productID,
+	).Scan(&history.Stock, &history.LedgerStock)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrProductNotFound
+		}
+		r.logger.Errorw("Failed to get product stock", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to get product stock: %w", err)
+	}
+
+	b := &queryBuilder{}
+	b.where("product_id = " + b.arg(productID))
+	if query.VariantID != "" {
+		b.where("variant_id = " + b.arg(query.VariantID))
+	}
+	if query.Before > 0 {
+		b.where("id < " + b.arg(query.Before))
+	}
+
+	rows, err := r.db.Query(`SELECT `+stockMovementColumns+` FROM stock_movements`+b.whereClause()+
+		` ORDER BY id DESC LIMIT `+b.arg(query.Limit+1), b.args...)
+	if err != nil {
+		r.logger.Errorw("Failed to get stock movements", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		movement, err := scanStockMovement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		history.Movements = append(history.Movements, movement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock movements: %w", err)
+	}
+
+	if len(history.Movements) > query.Limit {
+		history.Movements = history.Movements[:query.Limit]
+		history.NextBefore = history.Movements[query.Limit-1].ID
+	}
+
+	return history, nil
+}
@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// variantColumns selects variants joined to their product as p, in scanVariant order
+const variantColumns = `v.id, v.product_id, v.sku, v.options, v.price, COALESCE(v.price, p.price), p.currency,
+        v.stock, v.stock - v.reserved, v.barcode, v.created_at, v.updated_at`
+
+func scanVariant(row rowScanner) (*model.ProductVariant, error) {
+	variant := &model.ProductVariant{}
+	var options []byte
+	var price sql.NullInt64
+	err := row.Scan(
+		&variant.ID, &variant.ProductID, &variant.SKU, &options, &price,
+		&variant.EffectivePrice.Amount, &variant.EffectivePrice.Currency,
+		&variant.Stock, &variant.Available, &variant.Barcode, &variant.CreatedAt, &variant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if price.Valid {
+		variant.Price = &model.Money{Amount: price.Int64, Currency: variant.EffectivePrice.Currency}
+	}
+
+	if err := json.Unmarshal(options, &variant.Options); err != nil {
+		return nil, fmt.Errorf("failed to decode variant options: %w", err)
+	}
+	return variant, nil
+}
+
+type VariantRepository interface {
+	ListByProduct(productID string) ([]*model.ProductVariant, error)
+	GetByID(productID, id string) (*model.ProductVariant, error)
+	// GetByIDs returns the variants of ids whatever their product, in no particular order
+	GetByIDs(ids []string) ([]*model.ProductVariant, error)
+	Create(productID string, req *model.VariantRequest) (*model.ProductVariant, error)
+	// Update replaces a variant but its stock, failing with ErrStockReadOnly when
+	// req has a different stock
+	Update(productID, id string, req *model.VariantRequest) (*model.ProductVariant, error)
+	// Delete removes a variant, recording the stock it takes off the product for actorID
+	Delete(productID, id, actorID string) error
+	// SetOptions replaces the option definitions of a product
+	SetOptions(productID string, options []model.VariantOption) error
+}
+
+type variantRepository struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+func NewVariantRepository(db *sql.DB, logger *zap.SugaredLogger) VariantRepository {
+	return &variantRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *variantRepository) ListByProduct(productID string) ([]*model.ProductVariant, error) {
+	query := `
+        SELECT ` + variantColumns + `
+        FROM product_variants v JOIN products p ON p.id = v.product_id
+        WHERE v.product_id = $1
+        ORDER BY v.created_at, v.id`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		r.logger.Errorw("Failed to get variants", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to get variants: %w", err)
+	}
+	return r.scanVariants(rows)
+}
+
+func (r *variantRepository) GetByIDs(ids []string) ([]*model.ProductVariant, error) {
+	query := `
+        SELECT ` + variantColumns + `
+        FROM product_variants v JOIN products p ON p.id = v.product_id
+        WHERE v.id = ANY($1)`
+
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		r.logger.Errorw("Failed to get variants", "error", err)
+		return nil, fmt.Errorf("failed to get variants: %w", err)
+	}
+	return r.scanVariants(rows)
+}
+
+func (r *variantRepository) scanVariants(rows *sql.Rows) ([]*model.ProductVariant, error) {
+	defer rows.Close()
+
+	variants := []*model.ProductVariant{}
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan variant: %w", err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+func (r *variantRepository) GetByID(productID, id string) (*model.ProductVariant, error) {
+	return r.get(r.db, productID, id)
+}
+
+func (r *variantRepository) Create(productID string, req *model.VariantRequest) (*model.ProductVariant, error) {
+	options, err := json.Marshal(variantOptions(req.Options))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stock := 0
+	if req.Stock != nil {
+		stock = *req.Stock
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+	query := `
+        INSERT INTO product_variants (id, product_id, sku, options, price, stock, barcode, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $8)`
+
+	if _, err := tx.Exec(query, id, productID, req.SKU, options, moneyAmount(req.Price), stock, req.Barcode, now); err != nil {
+		if err := translateVariantError(err); err != nil {
+			return nil, err
+		}
+		r.logger.Errorw("Failed to create variant", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to create variant: %w", err)
+	}
+
+	variant, err := r.finish(tx, productID, id, model.StockReasonInitial, req.ActorID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Infow("Variant created successfully", "product_id", productID, "variant_id", id, "sku", req.SKU)
+	return variant, nil
+}
+
+func (r *variantRepository) Update(productID, id string, req *model.VariantRequest) (*model.ProductVariant, error) {
+	options, err := json.Marshal(variantOptions(req.Options))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Stock only changes through adjustments and reservations, the lock keeps it
+	// from changing between the check and the update
+	var stock int
+	err = tx.QueryRow(`SELECT stock FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE`, id, productID).Scan(&stock)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrVariantNotFound
+		}
+		return nil, fmt.Errorf("failed to lock variant: %w", err)
+	}
+	if req.Stock != nil && *req.Stock != stock {
+		return nil, model.ErrStockReadOnly
+	}
+
+	query := `
+        UPDATE product_variants
+        SET sku = $1, options = $2, price = $3, barcode = NULLIF($4, ''), updated_at = $5
+        WHERE id = $6 AND product_id = $7`
+
+	if _, err := tx.Exec(query, req.SKU, options, moneyAmount(req.Price), req.Barcode, time.Now(), id, productID); err != nil {
+		if err := translateVariantError(err); err != nil {
+			return nil, err
+		}
+		r.logger.Errorw("Failed to update variant", "error", err, "variant_id", id)
+		return nil, fmt.Errorf("failed to update variant: %w", err)
+	}
+
+	variant, err := r.get(tx, productID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update variant: %w", err)
+	}
+
+	r.logger.Infow("Variant updated successfully", "product_id", productID, "variant_id", id)
+	return variant, nil
+}
+
+func (r *variantRepository) Delete(productID, id, actorID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM product_variants WHERE id = $1 AND product_id = $2`, id, productID)
+	if err != nil {
+		r.logger.Errorw("Failed to delete variant", "error", err, "variant_id", id)
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return model.ErrVariantNotFound
+	}
+
+	if err := setStockContext(tx, model.StockReasonCorrection, "", actorID, id); err != nil {
+		return err
+	}
+	if err := syncProductStock(tx, productID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+
+	r.logger.Infow("Variant deleted successfully", "product_id", productID, "variant_id", id)
+	return nil
+}
+
+func (r *variantRepository) SetOptions(productID string, options []model.VariantOption) error {
+	if options == nil {
+		options = []model.VariantOption{}
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`UPDATE products SET variant_options = $1, updated_at = $2 WHERE id = $3`, data, time.Now(), productID)
+	if err != nil {
+		r.logger.Errorw("Failed to set variant options", "error", err, "product_id", productID)
+		return fmt.Errorf("failed to set variant options: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return model.ErrProductNotFound
+	}
+
+	return nil
+}
+
+// finish recomputes the product stock, recording a change for reason by actorID,
+// commits and returns the variant
+func (r *variantRepository) finish(tx *sql.Tx, productID, id, reason, actorID string) (*model.ProductVariant, error) {
+	if err := setStockContext(tx, reason, "", actorID, id); err != nil {
+		return nil, err
+	}
+	if err := syncProductStock(tx, productID); err != nil {
+		return nil, err
+	}
+
+	variant, err := r.get(tx, productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to save variant: %w", err)
+	}
+	return variant, nil
+}
+
+// queryRower is implemented by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func (r *variantRepository) get(db queryRower, productID, id string) (*model.ProductVariant, error) {
+	query := `
+        SELECT ` + variantColumns + `
+        FROM product_variants v JOIN products p ON p.id = v.product_id
+        WHERE v.id = $1 AND v.product_id = $2`
+
+	variant, err := scanVariant(db.QueryRow(query, id, productID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrVariantNotFound
+		}
+		r.logger.Errorw("Failed to get variant", "error", err, "variant_id", id)
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+	return variant, nil
+}
+
+// syncProductStock keeps the stock and reserved units of a product sold in
+// variants at their totals
+func syncProductStock(tx *sql.Tx, productID string) error {
+	query := `
+        UPDATE products
+        SET (stock, reserved) = (
+                SELECT COALESCE(SUM(stock), 0), COALESCE(SUM(reserved), 0)
+                FROM product_variants WHERE product_id = $1),
+            updated_at = NOW()
+        WHERE id = $1`
+
+	if _, err := tx.Exec(query, productID); err != nil {
+		return fmt.Errorf("failed to update product stock: %w", err)
+	}
+	return nil
+}
+
+func variantOptions(options map[string]string) map[string]string {
+	if options == nil {
+		return map[string]string{}
+	}
+	return options
+}
+
+// translateVariantError maps constraint violations to model errors, or returns nil
+func translateVariantError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+
+	switch {
+	case string(pqErr.Code) == foreignKeyViolation:
+		return model.ErrProductNotFound
+	case pqErr.Constraint == "product_variants_reserved_within_stock":
+		return model.ErrInsufficientStock
+	case string(pqErr.Code) != uniqueViolation:
+		return nil
+	case pqErr.Constraint == "idx_product_variants_sku":
+		return model.ErrDuplicateSKU
+	case pqErr.Constraint == "idx_product_variants_barcode":
+		return model.ErrDuplicateBarcode
+	default:
+		return model.ErrDuplicateVariant
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type AlertConfig struct {
+	Interval time.Duration
+	// Cooldown is how long after an alert resolves that the stock of its product
+	// falling again reopens it instead of raising a new alert
+	Cooldown    time.Duration
+	BatchSize   int
+	MaxAttempts int
+}
+
+// AlertEvaluator raises and resolves low-stock alerts as stock changes and
+// delivers new alerts through every notifier with at-least-once semantics
+type AlertEvaluator struct {
+	repo      repository.AlertRepository
+	notifiers []Notifier
+	config    *AlertConfig
+	logger    *zap.SugaredLogger
+}
+
+func NewAlertEvaluator(repo repository.AlertRepository, notifiers []Notifier, config *AlertConfig, logger *zap.SugaredLogger) *AlertEvaluator {
+	return &AlertEvaluator{
+		repo:      repo,
+		notifiers: notifiers,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// Run evaluates stock and delivers alerts every interval until ctx is done
+func (e *AlertEvaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.evaluate()
+			e.deliver(ctx)
+		}
+	}
+}
+
+func (e *AlertEvaluator) evaluate() {
+	raised, resolved, err := e.repo.Evaluate(time.Now(), e.config.Cooldown)
+	if err != nil {
+		e.logger.Errorw("Failed to evaluate stock alerts", "error", err)
+		return
+	}
+	if raised > 0 || resolved > 0 {
+		e.logger.Infow("Stock alerts evaluated", "raised", raised, "resolved", resolved)
+	}
+}
+
+func (e *AlertEvaluator) deliver(ctx context.Context) {
+	for ctx.Err() == nil {
+		delivered, failed, err := e.repo.Deliver(e.config.BatchSize, e.config.MaxAttempts, func(alert *model.StockAlert) error {
+			return e.notify(ctx, alert)
+		})
+		if err != nil {
+			e.logger.Errorw("Failed to deliver stock alerts", "error", err)
+			return
+		}
+		if delivered > 0 || failed > 0 {
+			e.logger.Infow("Stock alerts delivered", "delivered", delivered, "failed", failed)
+		}
+		// Failed alerts are retried on the next tick
+		if delivered+failed < e.config.BatchSize || failed > 0 {
+			return
+		}
+	}
+}
+
+// notify passes alert to every notifier, stopping at the first failure
+func (e *AlertEvaluator) notify(ctx context.Context, alert *model.StockAlert) error {
+	for _, notifier := range e.notifiers {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			e.logger.Warnw("Stock alert delivery failed", "error", err, "alert_id", alert.ID,
+				"notifier", notifier.Name(), "attempt", alert.Attempts+1)
+			return fmt.Errorf("%s: %w", notifier.Name(), err)
+		}
+	}
+	return nil
+}
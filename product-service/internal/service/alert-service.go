@@ -0,0 +1,47 @@
+package service
+
+import (
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type AlertService interface {
+	// ListAlerts returns a page of alerts, newest first, and the before of the next page
+	ListAlerts(query *model.StockAlertQuery) ([]*model.StockAlert, int64, error)
+	GetAlert(id int64) (*model.StockAlert, error)
+	AcknowledgeAlert(id int64, actorID string) (*model.StockAlert, error)
+}
+
+type alertService struct {
+	repo   repository.AlertRepository
+	logger *zap.SugaredLogger
+}
+
+func NewAlertService(repo repository.AlertRepository, logger *zap.SugaredLogger) AlertService {
+	return &alertService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *alertService) ListAlerts(query *model.StockAlertQuery) ([]*model.StockAlert, int64, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(query)
+}
+
+func (s *alertService) GetAlert(id int64) (*model.StockAlert, error) {
+	if id <= 0 {
+		return nil, model.ErrInvalidAlertID
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *alertService) AcknowledgeAlert(id int64, actorID string) (*model.StockAlert, error) {
+	if id <= 0 {
+		return nil, model.ErrInvalidAlertID
+	}
+	return s.repo.Acknowledge(id, actorID)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type AttributeService interface {
+	CreateAttribute(req *model.AttributeRequest) (*model.AttributeDefinition, error)
+	GetAttribute(id string) (*model.AttributeDefinition, error)
+	GetAllAttributes() ([]*model.AttributeDefinition, error)
+	UpdateAttribute(id string, req *model.AttributeRequest) (*model.AttributeDefinition, error)
+	// DeleteAttribute removes an attribute no product has a value of
+	DeleteAttribute(id string) error
+	// GetCategoryAttributes returns the attributes that apply to the products of
+	// a category, including those assigned to its ancestors
+	GetCategoryAttributes(categoryID string) ([]*model.CategoryAttribute, error)
+	// SetCategoryAttributes replaces the attributes assigned to a category and
+	// returns those that now apply to it
+	SetCategoryAttributes(categoryID string, req *model.SetCategoryAttributesRequest) ([]*model.CategoryAttribute, error)
+}
+
+type attributeService struct {
+	repo   repository.AttributeRepository
+	logger *zap.SugaredLogger
+}
+
+func NewAttributeService(repo repository.AttributeRepository, logger *zap.SugaredLogger) AttributeService {
+	return &attributeService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *attributeService) CreateAttribute(req *model.AttributeRequest) (*model.AttributeDefinition, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for create attribute request", "error", err)
+		return nil, err
+	}
+
+	attribute, err := s.repo.Create(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Attribute created", "attribute_id", attribute.ID, "code", attribute.Code)
+	return attribute, nil
+}
+
+func (s *attributeService) GetAttribute(id string) (*model.AttributeDefinition, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *attributeService) GetAllAttributes() ([]*model.AttributeDefinition, error) {
+	return s.repo.List()
+}
+
+func (s *attributeService) UpdateAttribute(id string, req *model.AttributeRequest) (*model.AttributeDefinition, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for update attribute request", "error", err, "attribute_id", id)
+		return nil, err
+	}
+
+	attribute, err := s.repo.Update(id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Attribute updated", "attribute_id", id, "code", attribute.Code)
+	return attribute, nil
+}
+
+func (s *attributeService) DeleteAttribute(id string) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	s.logger.Infow("Attribute deleted", "attribute_id", id)
+	return nil
+}
+
+func (s *attributeService) GetCategoryAttributes(categoryID string) ([]*model.CategoryAttribute, error) {
+	return s.repo.CategoryAttributes(categoryID)
+}
+
+func (s *attributeService) SetCategoryAttributes(categoryID string, req *model.SetCategoryAttributesRequest) ([]*model.CategoryAttribute, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for category attributes request", "error", err, "category_id", categoryID)
+		return nil, err
+	}
+
+	if err := s.repo.SetCategoryAttributes(categoryID, req.Attributes); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Category attributes set", "category_id", categoryID, "attributes", len(req.Attributes))
+	return s.repo.CategoryAttributes(categoryID)
+}
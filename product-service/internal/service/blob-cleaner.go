@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"github.com/leandrowiemesfilho/product-service/internal/storage"
+	"go.uber.org/zap"
+)
+
+type BlobCleanerConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+}
+
+// BlobCleaner deletes the files of deleted images from blob storage. The
+// database queues them as image rows are deleted, so files of purged products
+// are removed too.
+type BlobCleaner struct {
+	repo   repository.ImageRepository
+	store  storage.BlobStore
+	config *BlobCleanerConfig
+	logger *zap.SugaredLogger
+}
+
+func NewBlobCleaner(repo repository.ImageRepository, store storage.BlobStore, config *BlobCleanerConfig, logger *zap.SugaredLogger) *BlobCleaner {
+	return &BlobCleaner{
+		repo:   repo,
+		store:  store,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run deletes queued blobs every interval until ctx is done
+func (c *BlobCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.clean(ctx)
+		}
+	}
+}
+
+func (c *BlobCleaner) clean(ctx context.Context) {
+	for ctx.Err() == nil {
+		deleted, failed, err := c.repo.DeleteBlobs(c.config.BatchSize, c.config.MaxAttempts, func(key string) error {
+			if err := c.store.Delete(ctx, key); err != nil {
+				c.logger.Warnw("Failed to delete blob", "error", err, "key", key)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			c.logger.Errorw("Failed to delete blobs", "error", err)
+			return
+		}
+		if deleted > 0 || failed > 0 {
+			c.logger.Infow("Blobs deleted", "deleted", deleted, "failed", failed)
+		}
+		// Failed blobs are retried on the next tick
+		if deleted+failed < c.config.BatchSize || failed > 0 {
+			return
+		}
+	}
+}
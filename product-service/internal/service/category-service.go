@@ -0,0 +1,157 @@
+package service
+
+import (
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"github.com/leandrowiemesfilho/product-service/pkg/slug"
+	"go.uber.org/zap"
+)
+
+type CategoryService interface {
+	CreateCategory(req *model.CreateCategoryRequest) (*model.Category, error)
+	// GetCategory returns the category with its subcategories nested
+	GetCategory(id string) (*model.Category, error)
+	// GetCategoryTree returns the root categories with their subcategories nested
+	GetCategoryTree() ([]*model.Category, error)
+	GetAllCategories() ([]*model.Category, error)
+	UpdateCategory(id string, req *model.UpdateCategoryRequest) (*model.Category, error)
+	MoveCategory(id string, req *model.MoveCategoryRequest) (*model.Category, error)
+	DeleteCategory(id string, reassignTo string) error
+}
+
+type categoryService struct {
+	repo   repository.CategoryRepository
+	logger *zap.SugaredLogger
+}
+
+func NewCategoryService(repo repository.CategoryRepository, logger *zap.SugaredLogger) CategoryService {
+	return &categoryService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *categoryService) CreateCategory(req *model.CreateCategoryRequest) (*model.Category, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for create category request", "error", err)
+		return nil, err
+	}
+
+	if req.Slug == "" {
+		req.Slug = slug.Make(req.Name)
+	}
+	if !slug.Valid(req.Slug) {
+		return nil, model.ErrInvalidSlug
+	}
+
+	category, err := s.repo.Create(req)
+	if err != nil {
+		s.logger.Errorw("Failed to create category in repository", "error", err)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) GetCategory(id string) (*model.Category, error) {
+	categories, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, category := range buildCategoryTree(categories).all {
+		if category.ID == id {
+			return category, nil
+		}
+	}
+
+	return nil, model.ErrCategoryNotFound
+}
+
+func (s *categoryService) GetCategoryTree() ([]*model.Category, error) {
+	categories, err := s.repo.GetAll()
+	if err != nil {
+		s.logger.Errorw("Failed to get categories from repository", "error", err)
+		return nil, err
+	}
+
+	return buildCategoryTree(categories).roots, nil
+}
+
+func (s *categoryService) GetAllCategories() ([]*model.Category, error) {
+	categories, err := s.repo.GetAll()
+	if err != nil {
+		s.logger.Errorw("Failed to get categories from repository", "error", err)
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (s *categoryService) UpdateCategory(id string, req *model.UpdateCategoryRequest) (*model.Category, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for update category request", "error", err, "category_id", id)
+		return nil, err
+	}
+
+	if req.Slug != nil && !slug.Valid(*req.Slug) {
+		return nil, model.ErrInvalidSlug
+	}
+
+	category, err := s.repo.Update(id, req)
+	if err != nil {
+		s.logger.Errorw("Failed to update category in repository", "error", err, "category_id", id)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) MoveCategory(id string, req *model.MoveCategoryRequest) (*model.Category, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	category, err := s.repo.Move(id, req.ParentID)
+	if err != nil {
+		s.logger.Errorw("Failed to move category in repository", "error", err, "category_id", id)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) DeleteCategory(id string, reassignTo string) error {
+	if err := s.repo.Delete(id, reassignTo); err != nil {
+		s.logger.Errorw("Failed to delete category in repository", "error", err, "category_id", id)
+		return err
+	}
+
+	return nil
+}
+
+type categoryTree struct {
+	roots []*model.Category
+	all   []*model.Category
+}
+
+// buildCategoryTree nests categories below their parents, keeping their order
+func buildCategoryTree(categories []*model.Category) categoryTree {
+	byID := make(map[string]*model.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	tree := categoryTree{roots: []*model.Category{}, all: categories}
+	for _, category := range categories {
+		if category.ParentID == nil {
+			tree.roots = append(tree.roots, category)
+			continue
+		}
+		if parent, ok := byID[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, category)
+		}
+	}
+
+	return tree
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+// ExchangeRates converts money between currencies with a local rate table. Every
+// rate is the number of units of a currency one unit of the base currency buys.
+type ExchangeRates struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// NewExchangeRates builds the rate table. Currency codes are case insensitive;
+// the base currency always has a rate of 1.
+func NewExchangeRates(base string, rates map[string]float64) (*ExchangeRates, error) {
+	base = strings.ToUpper(base)
+	if _, err := model.CurrencyExponent(base); err != nil {
+		return nil, err
+	}
+
+	e := &ExchangeRates{base: base, rates: map[string]*big.Rat{base: big.NewRat(1, 1)}}
+	for currency, rate := range rates {
+		currency = strings.ToUpper(currency)
+		if _, err := model.CurrencyExponent(currency); err != nil {
+			return nil, err
+		}
+
+		// Parse the decimal representation so 0.1 is exactly one tenth
+		value, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+		if !ok || value.Sign() <= 0 {
+			return nil, fmt.Errorf("exchange rate of %s must be positive", currency)
+		}
+		if currency == base && value.Cmp(big.NewRat(1, 1)) != 0 {
+			return nil, fmt.Errorf("exchange rate of the base currency %s must be 1", base)
+		}
+		e.rates[currency] = value
+	}
+
+	return e, nil
+}
+
+func (e *ExchangeRates) Base() string {
+	return e.base
+}
+
+// Supports reports whether money can be converted to and from currency
+func (e *ExchangeRates) Supports(currency string) bool {
+	_, ok := e.rates[currency]
+	return ok
+}
+
+// Convert converts m to currency, rounding half away from zero to the minor unit
+func (e *ExchangeRates) Convert(m model.Money, currency string) (model.Money, error) {
+	if m.Currency == currency {
+		return m, nil
+	}
+
+	from, ok := e.rates[m.Currency]
+	if !ok {
+		return model.Money{}, fmt.Errorf("%w: %s", model.ErrNoExchangeRate, m.Currency)
+	}
+	to, ok := e.rates[currency]
+	if !ok {
+		return model.Money{}, fmt.Errorf("%w: %s", model.ErrNoExchangeRate, currency)
+	}
+
+	fromExponent, _ := model.CurrencyExponent(m.Currency)
+	toExponent, _ := model.CurrencyExponent(currency)
+
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, to)
+	value.Quo(value, from)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExponent-fromExponent))), nil))
+	if toExponent > fromExponent {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	amount, ok := round(value)
+	if !ok {
+		return model.Money{}, fmt.Errorf("%w: %s is out of range in %s", model.ErrInvalidPrice, m, currency)
+	}
+
+	return model.Money{Amount: amount, Currency: currency}, nil
+}
+
+// PriceIn returns price in currency: price itself or its fixed price in that
+// currency when there is one, else price converted. converted reports the latter.
+func (e *ExchangeRates) PriceIn(price model.Money, fixed []model.Money, currency string) (model.Money, bool, error) {
+	if price.Currency == currency {
+		return price, false, nil
+	}
+	for _, p := range fixed {
+		if p.Currency == currency {
+			return p, false, nil
+		}
+	}
+
+	converted, err := e.Convert(price, currency)
+	if err != nil {
+		return model.Money{}, false, err
+	}
+	return converted, true, nil
+}
+
+// round rounds half away from zero and reports whether the result fits an int64
+func round(value *big.Rat) (int64, bool) {
+	num := new(big.Int).Abs(value.Num())
+	quotient, remainder := new(big.Int).QuoRem(num, value.Denom(), new(big.Int))
+	if remainder.Mul(remainder, big.NewInt(2)).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient.Int64(), quotient.IsInt64()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
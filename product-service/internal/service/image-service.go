@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/leandrowiemesfilho/product-service/internal/imaging"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"github.com/leandrowiemesfilho/product-service/internal/storage"
+	"go.uber.org/zap"
+)
+
+// ImageConfig limits image uploads. Thumbnails are rendered at every one of
+// ThumbnailSizes smaller than the original.
+type ImageConfig struct {
+	MaxSize        int64
+	MaxPixels      int
+	MaxPerProduct  int
+	ThumbnailSizes []int
+	JPEGQuality    int
+}
+
+type ImageService interface {
+	// UploadImage checks the upload is a JPEG, PNG or GIF within the limits,
+	// stores it with its thumbnails and adds it to the product
+	UploadImage(ctx context.Context, upload *model.ImageUpload) (*model.ProductImage, error)
+	GetImages(productID string) ([]*model.ProductImage, error)
+	GetImage(productID, id string) (*model.ProductImage, error)
+	UpdateImage(productID, id string, req *model.UpdateImageRequest) (*model.ProductImage, error)
+	ReorderImages(productID string, req *model.ReorderImagesRequest) ([]*model.ProductImage, error)
+	// DeleteImage removes an image from its product; its files are deleted from
+	// storage in the background
+	DeleteImage(productID, id string) error
+}
+
+type imageService struct {
+	productRepo repository.ProductRepository
+	imageRepo   repository.ImageRepository
+	store       storage.BlobStore
+	config      *ImageConfig
+	logger      *zap.SugaredLogger
+}
+
+func NewImageService(productRepo repository.ProductRepository, imageRepo repository.ImageRepository, store storage.BlobStore,
+	config *ImageConfig, logger *zap.SugaredLogger) ImageService {
+	return &imageService{
+		productRepo: productRepo,
+		imageRepo:   imageRepo,
+		store:       store,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+func (s *imageService) UploadImage(ctx context.Context, upload *model.ImageUpload) (*model.ProductImage, error) {
+	if err := upload.Validate(); err != nil {
+		return nil, err
+	}
+	if int64(len(upload.Data)) > s.config.MaxSize {
+		return nil, fmt.Errorf("%w: files may be at most %d bytes", model.ErrImageTooLarge, s.config.MaxSize)
+	}
+
+	// Decoding is costly, so uploads to missing products are turned away first
+	if _, err := s.productRepo.GetByID(upload.ProductID); err != nil {
+		return nil, err
+	}
+
+	source, err := imaging.Decode(upload.Data, s.config.MaxPixels)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrTooManyPixels):
+			return nil, fmt.Errorf("%w: %v", model.ErrImageTooLarge, err)
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			return nil, fmt.Errorf("%w: %v", model.ErrUnsupportedImage, err)
+		}
+		return nil, err
+	}
+	thumbnails, err := source.Thumbnails(s.config.ThumbnailSizes, s.config.JPEGQuality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render thumbnails: %w", err)
+	}
+
+	// Keys are unique to the upload, so a URL always serves the same bytes
+	id := uuid.New().String()
+	prefix := "products/" + upload.ProductID + "/" + id + "/"
+	checksum := sha256.Sum256(upload.Data)
+	image := &model.ProductImage{
+		ID:          id,
+		ProductID:   upload.ProductID,
+		AltText:     upload.AltText,
+		StorageKey:  prefix + "original." + source.Extension,
+		ContentType: source.ContentType,
+		Width:       source.Width,
+		Height:      source.Height,
+		Size:        int64(len(upload.Data)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+		Variants:    make([]model.ImageVariant, 0, len(thumbnails)),
+		CreatedBy:   &upload.CreatedBy,
+	}
+	image.URL = s.store.URL(image.StorageKey)
+
+	stored := make([]string, 0, len(thumbnails)+1)
+	if err := s.store.Put(ctx, image.StorageKey, upload.Data, image.ContentType); err != nil {
+		s.logger.Errorw("Failed to store image", "error", err, "key", image.StorageKey)
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	stored = append(stored, image.StorageKey)
+
+	for _, thumbnail := range thumbnails {
+		key := prefix + strconv.Itoa(thumbnail.Size) + "." + thumbnail.Extension
+		if err := s.store.Put(ctx, key, thumbnail.Data, thumbnail.ContentType); err != nil {
+			s.logger.Errorw("Failed to store thumbnail", "error", err, "key", key)
+			s.discard(stored)
+			return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+		}
+		stored = append(stored, key)
+
+		image.Variants = append(image.Variants, model.ImageVariant{
+			Size:        thumbnail.Size,
+			URL:         s.store.URL(key),
+			ContentType: thumbnail.ContentType,
+			Width:       thumbnail.Width,
+			Height:      thumbnail.Height,
+			Bytes:       int64(len(thumbnail.Data)),
+			Key:         key,
+		})
+	}
+
+	created, err := s.imageRepo.Create(image, upload.Position, s.config.MaxPerProduct)
+	if err != nil {
+		s.discard(stored)
+		return nil, err
+	}
+
+	s.logger.Infow("Image uploaded", "product_id", created.ProductID, "image_id", created.ID,
+		"content_type", created.ContentType, "size", created.Size, "thumbnails", len(created.Variants))
+	return created, nil
+}
+
+// discard deletes the blobs of an upload that was not saved. Blobs that fail to
+// delete are only logged; they are not referenced by any image.
+func (s *imageService) discard(keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(context.Background(), key); err != nil {
+			s.logger.Warnw("Failed to delete blob of failed upload", "error", err, "key", key)
+		}
+	}
+}
+
+func (s *imageService) GetImages(productID string) ([]*model.ProductImage, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.imageRepo.ListByProduct(productID)
+}
+
+func (s *imageService) GetImage(productID, id string) (*model.ProductImage, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.imageRepo.GetByID(productID, id)
+}
+
+func (s *imageService) UpdateImage(productID, id string, req *model.UpdateImageRequest) (*model.ProductImage, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.imageRepo.UpdateAltText(productID, id, req.AltText)
+}
+
+func (s *imageService) ReorderImages(productID string, req *model.ReorderImagesRequest) ([]*model.ProductImage, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.Reorder(productID, req.ImageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Images reordered", "product_id", productID, "images", len(images))
+	return images, nil
+}
+
+func (s *imageService) DeleteImage(productID, id string) error {
+	if err := s.imageRepo.Delete(productID, id); err != nil {
+		return err
+	}
+
+	s.logger.Infow("Image deleted", "product_id", productID, "image_id", id)
+	return nil
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type ImportService interface {
+	// Submit checks the structure of an import file and queues it. Rows are
+	// validated and upserted asynchronously by the ImportWorker.
+	Submit(req *model.ImportRequest, payload []byte) (*model.ImportJob, error)
+	GetImport(id string) (*model.ImportJob, error)
+}
+
+type ImportConfig struct {
+	// Currency is used for CSV prices without a currency column
+	Currency string
+	// BatchSize is the number of rows upserted per transaction
+	BatchSize int
+	// MaxErrors is the number of row errors kept for the error report
+	MaxErrors int
+}
+
+type importService struct {
+	repo   repository.ImportRepository
+	config *ImportConfig
+	logger *zap.SugaredLogger
+}
+
+func NewImportService(repo repository.ImportRepository, config *ImportConfig, logger *zap.SugaredLogger) ImportService {
+	return &importService{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+func (s *importService) Submit(req *model.ImportRequest, payload []byte) (*model.ImportJob, error) {
+	if req.Key != model.ImportKeySKU && req.Key != model.ImportKeyExternalID {
+		return nil, fmt.Errorf("%w: key must be %s or %s", model.ErrInvalidImport, model.ImportKeySKU, model.ImportKeyExternalID)
+	}
+
+	rows, err := parseImport(payload, req.Format, req.Key, s.config.Currency)
+	if err != nil {
+		s.logger.Warnw("Rejected import file", "error", err, "format", req.Format)
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: file has no rows", model.ErrInvalidImport)
+	}
+
+	job := &model.ImportJob{
+		Format:    req.Format,
+		Key:       req.Key,
+		DryRun:    req.DryRun,
+		TotalRows: len(rows),
+	}
+	if req.CreatedBy != "" {
+		job.CreatedBy = &req.CreatedBy
+	}
+
+	if err := s.repo.Create(job, payload); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *importService) GetImport(id string) (*model.ImportJob, error) {
+	if id == "" {
+		return nil, model.ErrInvalidID
+	}
+	return s.repo.GetByID(id)
+}
+
+// parseImport parses and validates every row of an import file. Only a file that
+// can not be read as a whole is an error; invalid rows carry their errors.
+func parseImport(payload []byte, format, key, currency string) ([]*model.ImportRow, error) {
+	var rows []*model.ImportRow
+	var err error
+	switch format {
+	case model.ImportFormatCSV:
+		rows, err = parseCSV(payload, key, currency)
+	case model.ImportFormatNDJSON:
+		rows = parseNDJSON(payload)
+	default:
+		return nil, fmt.Errorf("%w: format must be %s or %s", model.ErrInvalidImport, model.ImportFormatCSV, model.ImportFormatNDJSON)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if len(row.Errors) == 0 {
+			row.Errors = validateImportRow(row, key)
+		}
+	}
+	return rows, nil
+}
+
+// csvColumns are the columns a CSV import may have. Category is a category ID or
+// slug and prices a list of "<amount> <currency>" separated by semicolons.
+var csvColumns = map[string]bool{
+	"sku": true, "external_id": true, "name": true, "description": true, "price": true,
+	"currency": true, "prices": true, "category": true, "stock": true,
+}
+
+func parseCSV(payload []byte, key, currency string) ([]*model.ImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(payload, []byte("\ufeff"))))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", model.ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidImport, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !csvColumns[name] {
+			return nil, fmt.Errorf("%w: unknown column %q", model.ErrInvalidImport, name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate column %q", model.ErrInvalidImport, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{key, "name", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", model.ErrInvalidImport, name)
+		}
+	}
+
+	var rows []*model.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidImport, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, csvRow(line, record, columns, currency))
+	}
+
+	return rows, nil
+}
+
+func csvRow(line int, record []string, columns map[string]int, currency string) *model.ImportRow {
+	value := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := &model.ImportRow{Row: line, Category: value("category")}
+	fail := func(field string, err error) {
+		row.Errors = append(row.Errors, model.ImportRowError{Row: line, Field: field, Message: err.Error()})
+	}
+
+	if code := strings.ToUpper(value("currency")); code != "" {
+		currency = code
+	}
+	price, err := model.ParseMoney(value("price"), currency)
+	if errors.Is(err, model.ErrUnknownCurrency) {
+		fail("currency", err)
+	} else if err != nil {
+		fail("price", err)
+	}
+
+	stock := 0
+	if s := value("stock"); s != "" {
+		if stock, err = strconv.Atoi(s); err != nil {
+			fail("stock", fmt.Errorf("%q is not an integer", s))
+		}
+	}
+
+	var prices []model.Money
+	if _, ok := columns["prices"]; ok {
+		row.ReplacePrices = true
+		prices = []model.Money{}
+		for _, entry := range strings.Split(value("prices"), ";") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			fields := strings.Fields(entry)
+			if len(fields) != 2 {
+				fail("prices", fmt.Errorf("%q is not an amount followed by a currency", entry))
+				continue
+			}
+			p, err := model.ParseMoney(fields[0], strings.ToUpper(fields[1]))
+			if err != nil {
+				fail("prices", err)
+				continue
+			}
+			prices = append(prices, p)
+		}
+	}
+
+	row.Product = model.CreateProductRequest{
+		SKU:         value("sku"),
+		ExternalID:  value("external_id"),
+		Name:        value("name"),
+		Description: value("description"),
+		Price:       price,
+		Prices:      prices,
+		Stock:       stock,
+	}
+	return row
+}
+
+// parseNDJSON decodes one product per line in the format of the create request.
+// Category may be given as category_id or, as an ID or slug, as category.
+func parseNDJSON(payload []byte) []*model.ImportRow {
+	var rows []*model.ImportRow
+	for i, line := range bytes.Split(payload, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var product struct {
+			model.CreateProductRequest
+			Category string `json:"category"`
+		}
+		row := &model.ImportRow{Row: i + 1}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&product); err != nil {
+			row.Errors = []model.ImportRowError{{Row: row.Row, Message: err.Error()}}
+		}
+
+		row.Product = product.CreateProductRequest
+		row.Category = product.Category
+		if row.Category == "" {
+			row.Category = product.CategoryID
+		}
+		row.ReplacePrices = product.Prices != nil
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// importFields maps request fields to the names used in import files
+var importFields = map[string]string{
+	"SKU":         "sku",
+	"ExternalID":  "external_id",
+	"Name":        "name",
+	"Description": "description",
+	"CategoryID":  "category",
+	"Stock":       "stock",
+}
+
+// validateImportRow applies the rules of the create request to a row, which must
+// also have a value for the key products are matched on
+func validateImportRow(row *model.ImportRow, key string) []model.ImportRowError {
+	var rowErrors []model.ImportRowError
+	keyValue := row.Product.SKU
+	if key == model.ImportKeyExternalID {
+		keyValue = row.Product.ExternalID
+	}
+	if keyValue == "" {
+		rowErrors = append(rowErrors, model.ImportRowError{Row: row.Row, Field: key, Message: "is required to match products"})
+	}
+
+	err := row.Product.Validate()
+	var validationErrors validator.ValidationErrors
+	switch {
+	case err == nil:
+	case errors.As(err, &validationErrors):
+		for _, fieldError := range validationErrors {
+			field, ok := importFields[fieldError.Field()]
+			if !ok {
+				field = strings.ToLower(fieldError.Field())
+			}
+			rowErrors = append(rowErrors, model.ImportRowError{Row: row.Row, Field: field, Message: ruleMessage(fieldError)})
+		}
+	case errors.Is(err, model.ErrUnknownCurrency):
+		rowErrors = append(rowErrors, model.ImportRowError{Row: row.Row, Field: "currency", Message: err.Error()})
+	default:
+		rowErrors = append(rowErrors, model.ImportRowError{Row: row.Row, Field: "price", Message: err.Error()})
+	}
+
+	return rowErrors
+}
+
+// ruleMessage describes a failed validation rule
+func ruleMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fieldError.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fieldError.Param())
+	case "gte":
+		return fmt.Sprintf("must be at least %s", fieldError.Param())
+	default:
+		return fmt.Sprintf("failed the %s rule", fieldError.Tag())
+	}
+}
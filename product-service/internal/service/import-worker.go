@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type ImportWorkerConfig struct {
+	Interval time.Duration
+	// StaleAfter is how long a running job may go without progress before it is
+	// considered abandoned and claimed again
+	StaleAfter time.Duration
+}
+
+// ImportWorker processes queued import jobs one batch at a time. Every batch is
+// committed together with the job's progress, so a job abandoned by a stopped
+// worker resumes after the last committed batch.
+type ImportWorker struct {
+	repo         repository.ImportRepository
+	importConfig *ImportConfig
+	config       *ImportWorkerConfig
+	logger       *zap.SugaredLogger
+}
+
+func NewImportWorker(repo repository.ImportRepository, importConfig *ImportConfig, config *ImportWorkerConfig, logger *zap.SugaredLogger) *ImportWorker {
+	return &ImportWorker{
+		repo:         repo,
+		importConfig: importConfig,
+		config:       config,
+		logger:       logger,
+	}
+}
+
+// Run processes queued jobs every interval until ctx is done
+func (w *ImportWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.processQueue(ctx)
+		}
+	}
+}
+
+func (w *ImportWorker) processQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, payload, err := w.repo.ClaimNext(time.Now().Add(-w.config.StaleAfter))
+		if err != nil || job == nil {
+			return
+		}
+		w.process(ctx, job, payload)
+	}
+}
+
+func (w *ImportWorker) process(ctx context.Context, job *model.ImportJob, payload []byte) {
+	w.logger.Infow("Processing import job", "import_id", job.ID, "rows", job.TotalRows, "resume_at", job.ProcessedRows)
+
+	rows, err := parseImport(payload, job.Format, job.Key, w.importConfig.Currency)
+	if err != nil {
+		w.fail(job, err.Error())
+		return
+	}
+
+	for start := job.ProcessedRows; start < len(rows); start += w.importConfig.BatchSize {
+		// Stopping between batches leaves the job to be resumed
+		if ctx.Err() != nil {
+			return
+		}
+
+		end := min(start+w.importConfig.BatchSize, len(rows))
+		if err := w.repo.ApplyBatch(job, rows[start:end], w.importConfig.MaxErrors); err != nil {
+			w.logger.Errorw("Failed to import batch", "error", err, "import_id", job.ID, "row", rows[start].Row)
+			w.fail(job, fmt.Sprintf("import stopped at row %d after an internal error", rows[start].Row))
+			return
+		}
+	}
+
+	job.Status = model.ImportCompleted
+	w.repo.Finish(job)
+}
+
+func (w *ImportWorker) fail(job *model.ImportJob, message string) {
+	job.Status = model.ImportFailed
+	job.Error = &message
+	w.repo.Finish(job)
+}
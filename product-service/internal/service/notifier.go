@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+// AlertSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of a webhook
+// body keyed with the webhook secret, so receivers can reject forged alerts
+const AlertSignatureHeader = "X-Alert-Signature"
+
+// Notifier delivers stock alerts through one channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert *model.StockAlert) error
+}
+
+// NotifierConfig selects the channels alerts are delivered through, any of log,
+// webhook and email
+type NotifierConfig struct {
+	Channels       []string
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+	EmailFrom      string
+	EmailTo        []string
+}
+
+// NewNotifiers builds the notifier of every configured channel
+func NewNotifiers(config *NotifierConfig, logger *zap.SugaredLogger) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(config.Channels))
+	for _, channel := range config.Channels {
+		switch channel {
+		case "log":
+			notifiers = append(notifiers, &logNotifier{logger: logger})
+		case "webhook":
+			if config.WebhookURL == "" {
+				return nil, fmt.Errorf("webhook notifier requires a url")
+			}
+			notifiers = append(notifiers, &webhookNotifier{
+				url:    config.WebhookURL,
+				secret: config.WebhookSecret,
+				client: &http.Client{Timeout: config.WebhookTimeout},
+			})
+		case "email":
+			if config.EmailFrom == "" || len(config.EmailTo) == 0 {
+				return nil, fmt.Errorf("email notifier requires from and to addresses")
+			}
+			notifiers = append(notifiers, &emailNotifier{
+				from:   config.EmailFrom,
+				to:     config.EmailTo,
+				mailer: &stubMailer{logger: logger},
+			})
+		default:
+			return nil, fmt.Errorf("unknown notifier %q", channel)
+		}
+	}
+	return notifiers, nil
+}
+
+// logNotifier writes alerts to the service log
+type logNotifier struct {
+	logger *zap.SugaredLogger
+}
+
+func (n *logNotifier) Name() string {
+	return "log"
+}
+
+func (n *logNotifier) Notify(ctx context.Context, alert *model.StockAlert) error {
+	n.logger.Warnw("Product stock is low", "alert_id", alert.ID, "product_id", alert.ProductID,
+		"product_name", alert.ProductName, "stock", alert.Stock, "threshold", alert.Threshold)
+	return nil
+}
+
+// webhookNotifier posts alerts as JSON, signed when a secret is configured
+type webhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (n *webhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, alert *model.StockAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set(AlertSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Mailer sends a plain text email
+type Mailer interface {
+	Send(ctx context.Context, from string, to []string, subject, body string) error
+}
+
+// emailNotifier mails alerts to a fixed list of recipients
+type emailNotifier struct {
+	from   string
+	to     []string
+	mailer Mailer
+}
+
+func (n *emailNotifier) Name() string {
+	return "email"
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, alert *model.StockAlert) error {
+	subject := fmt.Sprintf("Low stock: %s", alert.ProductName)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s has %d units in stock, at or below its reorder threshold of %d.\n\n",
+		alert.ProductName, alert.Stock, alert.Threshold)
+	fmt.Fprintf(&body, "Product: %s\n", alert.ProductID)
+	if alert.ProductSKU != nil {
+		fmt.Fprintf(&body, "SKU: %s\n", *alert.ProductSKU)
+	}
+	fmt.Fprintf(&body, "Alert: %d\n", alert.ID)
+
+	return n.mailer.Send(ctx, n.from, n.to, subject, body.String())
+}
+
+// stubMailer stands in for a mail provider by logging the emails it is given
+type stubMailer struct {
+	logger *zap.SugaredLogger
+}
+
+func (m *stubMailer) Send(ctx context.Context, from string, to []string, subject, body string) error {
+	m.logger.Infow("Email sent", "from", from, "to", to, "subject", subject, "body", body)
+	return nil
+}
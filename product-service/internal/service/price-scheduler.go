@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type PriceSchedulerConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// PriceScheduler starts scheduled prices when they are due and restores the
+// prices they replaced when they end
+type PriceScheduler struct {
+	repo   repository.PriceRepository
+	config *PriceSchedulerConfig
+	logger *zap.SugaredLogger
+}
+
+func NewPriceScheduler(repo repository.PriceRepository, config *PriceSchedulerConfig, logger *zap.SugaredLogger) *PriceScheduler {
+	return &PriceScheduler{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run processes due schedules every interval until ctx is done
+func (s *PriceScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx)
+		}
+	}
+}
+
+// run ends schedules before starting others, so a schedule starting as another
+// ends replaces the restored price
+func (s *PriceScheduler) run(ctx context.Context) {
+	now := time.Now()
+
+	for ctx.Err() == nil {
+		ended, err := s.repo.EndDue(now, s.config.BatchSize)
+		if err != nil {
+			s.logger.Errorw("Failed to end price schedules", "error", err)
+			return
+		}
+		if ended < s.config.BatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		started, err := s.repo.StartDue(now, s.config.BatchSize)
+		if err != nil {
+			s.logger.Errorw("Failed to start price schedules", "error", err)
+			return
+		}
+		if started < s.config.BatchSize {
+			break
+		}
+	}
+}
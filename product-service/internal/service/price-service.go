@@ -0,0 +1,75 @@
+package service
+
+import (
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type PriceService interface {
+	// GetPriceHistory returns a page of the price changes of a product, newest
+	// first, and the before of the next page
+	GetPriceHistory(productID string, query *model.PriceHistoryQuery) ([]*model.PriceChange, int64, error)
+	SchedulePrice(productID string, req *model.CreatePriceScheduleRequest) (*model.PriceSchedule, error)
+	GetPriceSchedules(productID string) ([]*model.PriceSchedule, error)
+	CancelPriceSchedule(productID, id, actorID string) (*model.PriceSchedule, error)
+}
+
+type priceService struct {
+	repo        repository.PriceRepository
+	productRepo repository.ProductRepository
+	logger      *zap.SugaredLogger
+}
+
+func NewPriceService(repo repository.PriceRepository, productRepo repository.ProductRepository, logger *zap.SugaredLogger) PriceService {
+	return &priceService{
+		repo:        repo,
+		productRepo: productRepo,
+		logger:      logger,
+	}
+}
+
+func (s *priceService) GetPriceHistory(productID string, query *model.PriceHistoryQuery) ([]*model.PriceChange, int64, error) {
+	if productID == "" {
+		return nil, 0, model.ErrInvalidID
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.History(productID, query)
+}
+
+func (s *priceService) SchedulePrice(productID string, req *model.CreatePriceScheduleRequest) (*model.PriceSchedule, error) {
+	if productID == "" {
+		return nil, model.ErrInvalidID
+	}
+	if err := req.Validate(time.Now()); err != nil {
+		s.logger.Warnw("Validation failed for price schedule", "error", err, "product_id", productID)
+		return nil, err
+	}
+	return s.repo.CreateSchedule(productID, req)
+}
+
+func (s *priceService) GetPriceSchedules(productID string) ([]*model.PriceSchedule, error) {
+	if productID == "" {
+		return nil, model.ErrInvalidID
+	}
+
+	exists, err := s.productRepo.Exists(productID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, model.ErrProductNotFound
+	}
+	return s.repo.ListSchedules(productID)
+}
+
+func (s *priceService) CancelPriceSchedule(productID, id, actorID string) (*model.PriceSchedule, error) {
+	if productID == "" {
+		return nil, model.ErrInvalidID
+	}
+	return s.repo.CancelSchedule(productID, id, actorID)
+}
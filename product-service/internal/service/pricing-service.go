@@ -0,0 +1,286 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type PricingService interface {
+	// Quote prices items with the promotions in effect now
+	Quote(req *model.QuoteRequest) (*model.Quote, error)
+}
+
+type pricingService struct {
+	promotionRepo repository.PromotionRepository
+	productRepo   repository.ProductRepository
+	variantRepo   repository.VariantRepository
+	config        *CatalogConfig
+	logger        *zap.SugaredLogger
+}
+
+func NewPricingService(promotionRepo repository.PromotionRepository, productRepo repository.ProductRepository,
+	variantRepo repository.VariantRepository, config *CatalogConfig, logger *zap.SugaredLogger) PricingService {
+	return &pricingService{
+		promotionRepo: promotionRepo,
+		productRepo:   productRepo,
+		variantRepo:   variantRepo,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+func (s *pricingService) Quote(req *model.QuoteRequest) (*model.Quote, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for quote", "error", err)
+		return nil, err
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = s.config.Currency
+	}
+	if _, err := model.CurrencyExponent(currency); err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidQuote, err)
+	}
+	if !s.config.Rates.Supports(currency) {
+		return nil, fmt.Errorf("%w: no exchange rate for %s", model.ErrInvalidQuote, currency)
+	}
+
+	lines, err := s.priceLines(req.Items, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	paths, err := s.promotionRepo.CategoryPaths(productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	promotions, err := s.promotionRepo.ForQuote(now, req.Coupons)
+	if err != nil {
+		return nil, err
+	}
+	eligible, rejected := s.checkCoupons(promotions, req.Coupons, now)
+
+	quote := &model.Quote{
+		Currency:   currency,
+		Lines:      lines,
+		Promotions: []*model.AppliedPromotion{},
+		Subtotal:   model.Money{Currency: currency},
+		Discount:   model.Money{Currency: currency},
+		Total:      model.Money{Currency: currency},
+	}
+	applied := make(map[string]*model.AppliedPromotion)
+	for _, line := range lines {
+		if promotion, discount := s.bestPromotion(line, eligible, paths[line.ProductID]); promotion != nil {
+			line.Discount.Amount = discount
+			line.PromotionID = &promotion.ID
+
+			if applied[promotion.ID] == nil {
+				applied[promotion.ID] = &model.AppliedPromotion{
+					ID:         promotion.ID,
+					Name:       promotion.Name,
+					Type:       promotion.Type,
+					CouponCode: promotion.CouponCode,
+					Discount:   model.Money{Currency: currency},
+				}
+				quote.Promotions = append(quote.Promotions, applied[promotion.ID])
+			}
+			applied[promotion.ID].Discount.Amount += discount
+		}
+		line.Total.Amount = line.Subtotal.Amount - line.Discount.Amount
+
+		quote.Subtotal.Amount += line.Subtotal.Amount
+		quote.Discount.Amount += line.Discount.Amount
+		quote.Total.Amount += line.Total.Amount
+	}
+
+	// Valid coupons that lost to a better promotion on every line did not apply
+	for _, promotion := range eligible {
+		if promotion.CouponCode != nil && applied[promotion.ID] == nil {
+			rejected = append(rejected, &model.RejectedCoupon{Code: *promotion.CouponCode, Reason: model.CouponNotApplicable})
+		}
+	}
+	quote.RejectedCoupons = rejected
+	return quote, nil
+}
+
+// priceLines prices every item at its unit price in currency
+func (s *pricingService) priceLines(items []model.QuoteItem, currency string) ([]*model.QuoteLine, error) {
+	productIDs := make([]string, 0, len(items))
+	variantIDs := []string{}
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+		if item.VariantID != nil {
+			variantIDs = append(variantIDs, *item.VariantID)
+		}
+	}
+
+	products, err := s.productRepo.GetByIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*model.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	variants := make(map[string]*model.ProductVariant, len(variantIDs))
+	if len(variantIDs) > 0 {
+		found, err := s.variantRepo.GetByIDs(variantIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, variant := range found {
+			variants[variant.ID] = variant
+		}
+	}
+
+	lines := make([]*model.QuoteLine, 0, len(items))
+	for _, item := range items {
+		// Products that are not published can not be bought
+		product := byID[item.ProductID]
+		if product == nil || product.Status != model.StatusActive {
+			return nil, fmt.Errorf("%w: %s", model.ErrProductNotFound, item.ProductID)
+		}
+
+		// A variant price overrides the product price and has no fixed prices
+		// in other currencies
+		price, fixed := product.Price, product.Prices
+		if item.VariantID != nil {
+			variant := variants[*item.VariantID]
+			if variant == nil || variant.ProductID != product.ID {
+				return nil, fmt.Errorf("%w: %s", model.ErrVariantNotFound, *item.VariantID)
+			}
+			if variant.Price != nil {
+				price, fixed = *variant.Price, nil
+			}
+		}
+
+		unitPrice, converted, err := s.config.Rates.PriceIn(price, fixed, currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidQuote, err)
+		}
+
+		quantity := int64(item.Quantity)
+		lines = append(lines, &model.QuoteLine{
+			ProductID: product.ID,
+			VariantID: item.VariantID,
+			Name:      product.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: unitPrice,
+			Converted: converted,
+			Subtotal:  model.Money{Amount: unitPrice.Amount * quantity, Currency: currency},
+			Discount:  model.Money{Currency: currency},
+			Total:     model.Money{Currency: currency},
+		})
+	}
+	return lines, nil
+}
+
+// checkCoupons splits promotions into those a quote may use, the automatic ones
+// and those of usable coupons, and the coupons of codes that can not be used
+func (s *pricingService) checkCoupons(promotions []*model.Promotion, codes []string, now time.Time) ([]*model.Promotion, []*model.RejectedCoupon) {
+	byCode := make(map[string]*model.Promotion, len(codes))
+	for _, promotion := range promotions {
+		if promotion.CouponCode != nil {
+			byCode[*promotion.CouponCode] = promotion
+		}
+	}
+
+	rejected := []*model.RejectedCoupon{}
+	for _, code := range codes {
+		promotion := byCode[code]
+		if promotion == nil {
+			rejected = append(rejected, &model.RejectedCoupon{Code: code, Reason: model.CouponUnknown})
+			continue
+		}
+		if reason := promotion.Availability(now); reason != "" {
+			rejected = append(rejected, &model.RejectedCoupon{Code: code, Reason: reason})
+			delete(byCode, code)
+		}
+	}
+
+	eligible := make([]*model.Promotion, 0, len(promotions))
+	for _, promotion := range promotions {
+		if promotion.CouponCode == nil || byCode[*promotion.CouponCode] != nil {
+			eligible = append(eligible, promotion)
+		}
+	}
+	return eligible, rejected
+}
+
+// bestPromotion returns the promotion of promotions that takes the most off line,
+// the oldest on a tie, and its discount. categories are the category of the
+// product and its ancestors.
+func (s *pricingService) bestPromotion(line *model.QuoteLine, promotions []*model.Promotion, categories []string) (*model.Promotion, int64) {
+	var best *model.Promotion
+	var bestDiscount int64
+	for _, promotion := range promotions {
+		if !targets(promotion, line.ProductID, categories) {
+			continue
+		}
+
+		discount, err := s.discount(promotion, line)
+		if err != nil {
+			s.logger.Warnw("Failed to apply promotion", "error", err, "promotion_id", promotion.ID, "product_id", line.ProductID)
+			continue
+		}
+		if discount > bestDiscount {
+			best, bestDiscount = promotion, discount
+		}
+	}
+	return best, bestDiscount
+}
+
+// targets reports whether promotion applies to the product with categories
+func targets(promotion *model.Promotion, productID string, categories []string) bool {
+	switch {
+	case promotion.ProductID != nil:
+		return *promotion.ProductID == productID
+	case promotion.CategoryID != nil:
+		for _, category := range categories {
+			if category == *promotion.CategoryID {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// discount returns how much promotion takes off line, at most its subtotal.
+// Fixed amounts in another currency than the line are converted.
+func (s *pricingService) discount(promotion *model.Promotion, line *model.QuoteLine) (int64, error) {
+	unit, quantity := line.UnitPrice.Amount, int64(line.Quantity)
+
+	var discount int64
+	switch promotion.Type {
+	case model.PromotionPercentage:
+		// Split the subtotal so multiplying by the percentage can not overflow
+		subtotal, percent := line.Subtotal.Amount, int64(*promotion.Percent)
+		discount = subtotal/100*percent + (subtotal%100*percent+50)/100
+	case model.PromotionFixed:
+		amount, err := s.config.Rates.Convert(*promotion.Amount, line.UnitPrice.Currency)
+		if err != nil {
+			return 0, err
+		}
+		discount = min(amount.Amount, unit) * quantity
+	case model.PromotionBuyXGetY:
+		buy, get := int64(*promotion.BuyQuantity), int64(*promotion.GetQuantity)
+		discount = quantity / (buy + get) * get * unit
+	default:
+		return 0, fmt.Errorf("unknown promotion type %q", promotion.Type)
+	}
+	return min(discount, line.Subtotal.Amount), nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"go.uber.org/zap"
+)
+
+func newTestPricingService(t *testing.T) *pricingService {
+	t.Helper()
+	rates, err := NewExchangeRates("USD", map[string]float64{"EUR": 0.5, "JPY": 150})
+	if err != nil {
+		t.Fatalf("NewExchangeRates: %v", err)
+	}
+	return &pricingService{
+		config: &CatalogConfig{Currency: "USD", Rates: rates},
+		logger: zap.NewNop().Sugar(),
+	}
+}
+
+func quoteLine(productID string, unit int64, currency string, quantity int) *model.QuoteLine {
+	return &model.QuoteLine{
+		ProductID: productID,
+		Quantity:  quantity,
+		UnitPrice: model.Money{Amount: unit, Currency: currency},
+		Subtotal:  model.Money{Amount: unit * int64(quantity), Currency: currency},
+	}
+}
+
+func percentage(id string, percent int) *model.Promotion {
+	return &model.Promotion{ID: id, Type: model.PromotionPercentage, Percent: &percent}
+}
+
+func fixed(id string, amount int64, currency string) *model.Promotion {
+	return &model.Promotion{ID: id, Type: model.PromotionFixed, Amount: &model.Money{Amount: amount, Currency: currency}}
+}
+
+func buyXGetY(id string, buy, get int) *model.Promotion {
+	return &model.Promotion{ID: id, Type: model.PromotionBuyXGetY, BuyQuantity: &buy, GetQuantity: &get}
+}
+
+func TestPricingServiceDiscount(t *testing.T) {
+	s := newTestPricingService(t)
+
+	tests := []struct {
+		name      string
+		promotion *model.Promotion
+		line      *model.QuoteLine
+		want      int64
+		wantErr   bool
+	}{
+		{"percentage", percentage("p", 10), quoteLine("a", 1999, "USD", 3), 600, false},
+		{"percentage rounds half up", percentage("p", 50), quoteLine("a", 1, "USD", 1), 1, false},
+		{"percentage rounds down", percentage("p", 15), quoteLine("a", 999, "USD", 1), 150, false},
+		{"percentage of everything", percentage("p", 100), quoteLine("a", 1999, "USD", 2), 3998, false},
+		{"percentage does not overflow", percentage("p", 50), quoteLine("a", math.MaxInt64, "USD", 1), 4611686018427387904, false},
+		{"fixed per unit", fixed("f", 500, "USD"), quoteLine("a", 1999, "USD", 3), 1500, false},
+		{"fixed at most the unit price", fixed("f", 5000, "USD"), quoteLine("a", 1999, "USD", 2), 3998, false},
+		{"fixed converted", fixed("f", 1000, "USD"), quoteLine("a", 1999, "EUR", 1), 500, false},
+		{"fixed converted to another exponent", fixed("f", 1000, "USD"), quoteLine("a", 3000, "JPY", 1), 1500, false},
+		{"fixed without exchange rate", fixed("f", 1000, "GBP"), quoteLine("a", 1999, "USD", 1), 0, true},
+		{"buy x get y", buyXGetY("b", 2, 1), quoteLine("a", 1000, "USD", 7), 2000, false},
+		{"buy x get y below threshold", buyXGetY("b", 2, 1), quoteLine("a", 1000, "USD", 2), 0, false},
+		{"buy x get y exact multiple", buyXGetY("b", 1, 1), quoteLine("a", 1000, "USD", 4), 2000, false},
+		{"unknown type", &model.Promotion{ID: "u", Type: "bogus"}, quoteLine("a", 1000, "USD", 1), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.discount(tt.promotion, tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("discount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("discount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPricingServiceBestPromotion(t *testing.T) {
+	s := newTestPricingService(t)
+
+	product, category := "product", "category"
+	forProduct := percentage("for product", 20)
+	forProduct.ProductID = &product
+	forOther := percentage("for other product", 90)
+	other := "other"
+	forOther.ProductID = &other
+	forCategory := fixed("for category", 300, "USD")
+	forCategory.CategoryID = &category
+
+	tests := []struct {
+		name         string
+		line         *model.QuoteLine
+		promotions   []*model.Promotion
+		categories   []string
+		wantID       string
+		wantDiscount int64
+	}{
+		{"no promotions", quoteLine(product, 1000, "USD", 1), nil, nil, "", 0},
+		{"largest discount", quoteLine(product, 1000, "USD", 1), []*model.Promotion{percentage("small", 5), percentage("large", 30), percentage("medium", 10)}, nil, "large", 300},
+		{"tie keeps the earlier", quoteLine(product, 1000, "USD", 1), []*model.Promotion{percentage("first", 10), fixed("second", 100, "USD")}, nil, "first", 100},
+		{"other product skipped", quoteLine(product, 1000, "USD", 1), []*model.Promotion{forOther, forProduct}, nil, "for product", 200},
+		{"category in path", quoteLine(product, 1000, "USD", 2), []*model.Promotion{forProduct, forCategory}, []string{"root", category}, "for category", 600},
+		{"category not in path", quoteLine(product, 1000, "USD", 2), []*model.Promotion{forCategory}, []string{"root"}, "", 0},
+		{"failing promotion skipped", quoteLine(product, 1000, "USD", 1), []*model.Promotion{fixed("no rate", 900, "GBP"), percentage("works", 10)}, nil, "works", 100},
+		{"zero discount is not applied", quoteLine(product, 1000, "USD", 1), []*model.Promotion{buyXGetY("b", 2, 1)}, nil, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best, discount := s.bestPromotion(tt.line, tt.promotions, tt.categories)
+			var id string
+			if best != nil {
+				id = best.ID
+			}
+			if id != tt.wantID || discount != tt.wantDiscount {
+				t.Errorf("bestPromotion() = %q, %d, want %q, %d", id, discount, tt.wantID, tt.wantDiscount)
+			}
+		})
+	}
+}
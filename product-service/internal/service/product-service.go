@@ -1,28 +1,81 @@
 package service
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/leandrowiemesfilho/product-service/internal/model"
 	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"github.com/leandrowiemesfilho/product-service/pkg/slug"
 	"go.uber.org/zap"
 )
 
 type ProductService interface {
 	CreateProduct(req *model.CreateProductRequest) (*model.Product, error)
-	GetProduct(id string) (*model.Product, error)
-	GetAllProducts() ([]*model.Product, error)
-	UpdateProduct(id string, req *model.UpdateProductRequest) (*model.Product, error)
-	DeleteProduct(id string) error
+	// GetProduct returns a product, with its display price in currency when not
+	// empty. Products that are not active are only returned with unpublished.
+	GetProduct(id, currency string, unpublished bool) (*model.Product, error)
+	// GetProductBySlug is GetProduct by current or former slug. Redirected is set
+	// for a former slug.
+	GetProductBySlug(slug, currency string, unpublished bool) (product *model.Product, redirected bool, err error)
+	GetAllProducts(query *model.ProductListQuery) (*model.ProductPage, error)
+	SearchProducts(query *model.ProductSearchQuery) (*model.SearchPage, error)
+	// ExportProducts streams the products matching query to fn without loading
+	// the catalog into memory
+	ExportProducts(query *model.ExportQuery, fn func(*model.Product) error) error
+	// UpdateProduct replaces a product with the complete representation in req.
+	// Writes fail with ErrVersionMismatch when the product does not match precondition.
+	UpdateProduct(id string, req *model.UpdateProductRequest, precondition *model.Precondition) (*model.Product, error)
+	// PatchProduct applies a merge patch or JSON patch to a product
+	PatchProduct(id string, patch *model.Patch, precondition *model.Precondition) (*model.Product, error)
+	// DeleteProduct moves a product to the trash
+	DeleteProduct(id string, precondition *model.Precondition, deletedBy string) error
+	// RestoreProduct takes a product out of the trash
+	RestoreProduct(id string) (*model.Product, error)
+	// GetTrash lists the products in the trash, most recently deleted first
+	GetTrash(query *model.ProductListQuery) (*model.ProductPage, error)
+	// GetDrafts lists the draft products
+	GetDrafts(query *model.ProductListQuery) (*model.ProductPage, error)
+	// GetScheduled lists the scheduled products, next to be published first
+	GetScheduled(query *model.ProductListQuery) (*model.ProductPage, error)
+	// SetStatus moves a product through its lifecycle
+	SetStatus(id string, req *model.StatusRequest, precondition *model.Precondition) (*model.Product, error)
+	// EraseUserData removes references to an erased user from their products
+	EraseUserData(userID string) error
+	// DefaultCurrency is the currency prices are filtered in unless another is requested
+	DefaultCurrency() string
 }
 
 type productService struct {
-	repo   repository.ProductRepository
-	logger *zap.SugaredLogger
+	repo          repository.ProductRepository
+	attributeRepo repository.AttributeRepository
+	config        *CatalogConfig
+	logger        *zap.SugaredLogger
+}
+
+type CatalogConfig struct {
+	// Currency is the catalog's default currency
+	Currency string
+	// PriceRanges are the buckets of the price facet, in display order
+	PriceRanges []model.PriceRange
+	// Rates convert prices to requested display currencies
+	Rates *ExchangeRates
+	// RequireIfMatch rejects product writes without an If-Match precondition
+	RequireIfMatch bool
 }
 
-func NewProductService(repo repository.ProductRepository, logger *zap.SugaredLogger) ProductService {
+func NewProductService(repo repository.ProductRepository, attributeRepo repository.AttributeRepository, config *CatalogConfig,
+	logger *zap.SugaredLogger) ProductService {
 	return &productService{
-		repo:   repo,
-		logger: logger,
+		repo:          repo,
+		attributeRepo: attributeRepo,
+		config:        config,
+		logger:        logger,
 	}
 }
 
@@ -31,6 +84,28 @@ func (s *productService) CreateProduct(req *model.CreateProductRequest) (*model.
 		s.logger.Warnw("Validation failed for create product request", "error", err)
 		return nil, err
 	}
+	if req.Slug != "" && !slug.Valid(req.Slug) {
+		return nil, model.ErrInvalidSlug
+	}
+	if err := s.checkAttributes(req.CategoryID, req.Attributes); err != nil {
+		s.logger.Warnw("Invalid attributes in create product request", "error", err)
+		return nil, err
+	}
+
+	// New products start from draft, so they may only be created in the statuses a draft can reach
+	status := &model.StatusRequest{Status: req.Status, PublishAt: req.PublishAt, UnpublishAt: req.UnpublishAt}
+	if status.Status == "" {
+		status.Status = model.StatusDraft
+	}
+	now := time.Now()
+	if err := status.Validate(now); err != nil {
+		return nil, err
+	}
+	if status.Status == model.StatusArchived {
+		return nil, fmt.Errorf("%w: products can not be created archived", model.ErrStatusTransition)
+	}
+	req.Status = status.Status
+	req.PublishAt, req.UnpublishAt = status.Schedule(model.StatusDraft, nil, now)
 
 	product, err := s.repo.Create(req)
 	if err != nil {
@@ -42,57 +117,397 @@ func (s *productService) CreateProduct(req *model.CreateProductRequest) (*model.
 	return product, nil
 }
 
-func (s *productService) GetProduct(id string) (*model.Product, error) {
+func (s *productService) GetProduct(id, currency string, unpublished bool) (*model.Product, error) {
 	if id == "" {
 		return nil, model.ErrInvalidID
 	}
+	if err := s.checkDisplayCurrency(currency); err != nil {
+		return nil, err
+	}
 
 	product, err := s.repo.GetByID(id)
 	if err != nil {
 		s.logger.Errorw("Failed to get product from repository", "error", err, "product_id", id)
 		return nil, err
 	}
+	if product.Status != model.StatusActive && !unpublished {
+		return nil, model.ErrProductNotFound
+	}
 
+	s.setDisplayPrice(product, currency)
 	return product, nil
 }
 
-func (s *productService) GetAllProducts() ([]*model.Product, error) {
-	products, err := s.repo.GetAll()
+func (s *productService) GetProductBySlug(value, currency string, unpublished bool) (*model.Product, bool, error) {
+	if !slug.Valid(value) {
+		return nil, false, model.ErrProductNotFound
+	}
+	if err := s.checkDisplayCurrency(currency); err != nil {
+		return nil, false, err
+	}
+
+	product, redirected, err := s.repo.GetBySlug(value)
+	if err != nil {
+		return nil, false, err
+	}
+	if product.Status != model.StatusActive && !unpublished {
+		return nil, false, model.ErrProductNotFound
+	}
+
+	s.setDisplayPrice(product, currency)
+	return product, redirected, nil
+}
+
+func (s *productService) DefaultCurrency() string {
+	return s.config.Currency
+}
+
+// checkDisplayCurrency rejects display currencies prices can not be shown in
+func (s *productService) checkDisplayCurrency(currency string) error {
+	if currency == "" {
+		return nil
+	}
+	if _, err := model.CurrencyExponent(currency); err != nil {
+		return fmt.Errorf("%w: %v", model.ErrInvalidQuery, err)
+	}
+	if !s.config.Rates.Supports(currency) {
+		return fmt.Errorf("%w: no exchange rate for %s", model.ErrInvalidQuery, currency)
+	}
+	return nil
+}
+
+// setDisplayPrice sets the price of product in currency: its fixed price in that
+// currency when it has one, else its price converted at the configured rate
+func (s *productService) setDisplayPrice(product *model.Product, currency string) {
+	if currency == "" {
+		return
+	}
+
+	price, converted, err := s.config.Rates.PriceIn(product.Price, product.Prices, currency)
+	if err != nil {
+		s.logger.Warnw("Failed to convert product price", "error", err, "product_id", product.ID, "currency", currency)
+		return
+	}
+	product.DisplayPrice = &model.DisplayPrice{Money: price, Converted: converted}
+}
+
+func (s *productService) GetAllProducts(query *model.ProductListQuery) (*model.ProductPage, error) {
+	if err := query.Normalize(); err != nil {
+		s.logger.Warnw("Invalid product list query", "error", err)
+		return nil, err
+	}
+	if err := s.checkDisplayCurrency(query.Currency); err != nil {
+		return nil, err
+	}
+	if err := s.resolveAttributeFilters(&query.Filter); err != nil {
+		return nil, err
+	}
+
+	page, err := s.repo.List(query)
 	if err != nil {
 		s.logger.Errorw("Failed to get all products from repository", "error", err)
 		return nil, err
 	}
 
-	s.logger.Infow("Retrieved all products", "count", len(products))
-	return products, nil
+	if query.Facets != nil {
+		if page.Facets, err = s.repo.Facets(&query.Filter, "", query.Facets, s.config.PriceRanges); err != nil {
+			s.logger.Errorw("Failed to count facets", "error", err)
+			return nil, err
+		}
+	}
+
+	for _, product := range page.Products {
+		s.setDisplayPrice(product, query.Currency)
+	}
+
+	s.logger.Infow("Retrieved all products", "count", len(page.Products), "total", page.Total)
+	return page, nil
+}
+
+func (s *productService) SearchProducts(query *model.ProductSearchQuery) (*model.SearchPage, error) {
+	if err := query.Normalize(); err != nil {
+		s.logger.Warnw("Invalid product search query", "error", err)
+		return nil, err
+	}
+	if err := s.checkDisplayCurrency(query.Currency); err != nil {
+		return nil, err
+	}
+	if err := s.resolveAttributeFilters(&query.Filter); err != nil {
+		return nil, err
+	}
+
+	page, err := s.repo.Search(query)
+	if err != nil {
+		s.logger.Errorw("Failed to search products in repository", "error", err, "query", query.Query)
+		return nil, err
+	}
+
+	if query.Facets != nil {
+		if page.Facets, err = s.repo.Facets(&query.Filter, query.Query, query.Facets, s.config.PriceRanges); err != nil {
+			s.logger.Errorw("Failed to count facets", "error", err, "query", query.Query)
+			return nil, err
+		}
+	}
+
+	for _, result := range page.Results {
+		s.setDisplayPrice(result.Product, query.Currency)
+	}
+
+	return page, nil
+}
+
+func (s *productService) ExportProducts(query *model.ExportQuery, fn func(*model.Product) error) error {
+	if err := query.Normalize(); err != nil {
+		s.logger.Warnw("Invalid product export query", "error", err)
+		return err
+	}
+	if err := s.checkDisplayCurrency(query.Currency); err != nil {
+		return err
+	}
+	if err := s.resolveAttributeFilters(&query.Filter); err != nil {
+		return err
+	}
+
+	count := 0
+	err := s.repo.Export(&query.Filter, func(product *model.Product) error {
+		s.setDisplayPrice(product, query.Currency)
+		count++
+		return fn(product)
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to export products", "error", err, "format", query.Format, "exported", count)
+		return err
+	}
+
+	s.logger.Infow("Exported products", "format", query.Format, "count", count)
+	return nil
 }
 
-func (s *productService) UpdateProduct(id string, req *model.UpdateProductRequest) (*model.Product, error) {
+func (s *productService) UpdateProduct(id string, req *model.UpdateProductRequest, precondition *model.Precondition) (*model.Product, error) {
 	if id == "" {
 		return nil, model.ErrInvalidID
 	}
+	if err := s.checkPrecondition(precondition); err != nil {
+		return nil, err
+	}
 
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.replace(current, req, precondition)
+}
+
+// maxPatchAttempts bounds how often an unconditional patch is reapplied after
+// the product changed between reading and writing it
+const maxPatchAttempts = 3
+
+func (s *productService) PatchProduct(id string, patch *model.Patch, precondition *model.Precondition) (*model.Product, error) {
+	if id == "" {
+		return nil, model.ErrInvalidID
+	}
+	if err := s.checkPrecondition(precondition); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, err := s.repo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if !precondition.Matches(current.Version) {
+			return nil, model.ErrVersionMismatch
+		}
+
+		document, err := json.Marshal(current.UpdateRequest())
+		if err != nil {
+			return nil, err
+		}
+
+		patched, err := applyPatch(document, patch)
+		if err != nil {
+			s.logger.Warnw("Failed to apply product patch", "error", err, "product_id", id)
+			return nil, err
+		}
+
+		// Only editable fields may be patched in; a patch adding id or created_at is rejected
+		var req model.UpdateProductRequest
+		decoder := json.NewDecoder(bytes.NewReader(patched))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidProduct, err)
+		}
+		req.UpdatedBy = patch.UpdatedBy
+		// The document carried the stock as read above, which may since have been
+		// adjusted; only a patch that changes it is a change of stock
+		if req.Stock != nil && *req.Stock == current.Stock {
+			req.Stock = nil
+		}
+
+		// The patch was applied to this version, so it may only be written over it
+		product, err := s.replace(current, &req, &model.Precondition{Versions: []int64{current.Version}})
+		if errors.Is(err, model.ErrVersionMismatch) && (precondition == nil || precondition.Any) && attempt < maxPatchAttempts {
+			continue
+		}
+		return product, err
+	}
+}
+
+// checkPrecondition enforces conditional writes when they are required
+func (s *productService) checkPrecondition(precondition *model.Precondition) error {
+	if precondition == nil && s.config.RequireIfMatch {
+		return model.ErrPreconditionRequired
+	}
+	return nil
+}
+
+// applyPatch applies a merge patch or JSON patch to document
+func applyPatch(document []byte, patch *model.Patch) ([]byte, error) {
+	switch patch.ContentType {
+	case model.MergePatchContentType:
+		patched, err := jsonpatch.MergePatch(document, patch.Document)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidPatch, err)
+		}
+		return patched, nil
+	case model.JSONPatchContentType:
+		operations, err := jsonpatch.DecodePatch(patch.Document)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidPatch, err)
+		}
+		patched, err := operations.Apply(document)
+		if err != nil {
+			if errors.Is(err, jsonpatch.ErrTestFailed) || errors.Is(err, jsonpatch.ErrMissing) || errors.Is(err, jsonpatch.ErrInvalidIndex) {
+				return nil, fmt.Errorf("%w: %v", model.ErrPatchConflict, err)
+			}
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidPatch, err)
+		}
+		return patched, nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported content type %q", model.ErrInvalidPatch, patch.ContentType)
+}
+
+// replace validates the complete new state of current and stores it
+func (s *productService) replace(current *model.Product, req *model.UpdateProductRequest, precondition *model.Precondition) (*model.Product, error) {
 	if err := req.Validate(); err != nil {
-		s.logger.Warnw("Validation failed for update product request", "error", err, "product_id", id)
+		s.logger.Warnw("Validation failed for update product request", "error", err, "product_id", current.ID)
 		return nil, err
 	}
+	if req.Slug != "" && !slug.Valid(req.Slug) {
+		return nil, model.ErrInvalidSlug
+	}
+	categoryID := ""
+	if req.CategoryID != nil {
+		categoryID = *req.CategoryID
+	}
+	if err := s.checkAttributes(categoryID, req.Attributes); err != nil {
+		s.logger.Warnw("Invalid attributes in update product request", "error", err, "product_id", current.ID)
+		return nil, err
+	}
+
+	// Variant price overrides are stored in the product currency
+	if req.Price.Currency != current.Price.Currency && current.Variants != nil {
+		return nil, fmt.Errorf("%w: the currency of a product with variants can not change", model.ErrInvalidPrice)
+	}
 
-	product, err := s.repo.Update(id, req)
+	product, err := s.repo.Update(current.ID, req, precondition)
 	if err != nil {
-		s.logger.Errorw("Failed to update product in repository", "error", err, "product_id", id)
+		if errors.Is(err, model.ErrVersionMismatch) {
+			return nil, err
+		}
+		s.logger.Errorw("Failed to update product in repository", "error", err, "product_id", current.ID)
 		return nil, err
 	}
 
-	s.logger.Infow("Product updated successfully", "product_id", id)
+	s.logger.Infow("Product updated successfully", "product_id", current.ID)
 	return product, nil
 }
 
-func (s *productService) DeleteProduct(id string) error {
+// checkAttributes checks every attribute value against its definition and that
+// the product has the attributes its category requires
+func (s *productService) checkAttributes(categoryID string, attributes model.Attributes) error {
+	codes := make([]string, 0, len(attributes))
+	for code := range attributes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	definitions, err := s.attributeRepo.GetByCodes(codes)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]*model.AttributeDefinition, len(definitions))
+	for _, definition := range definitions {
+		known[definition.Code] = definition
+	}
+	for _, code := range codes {
+		definition, ok := known[code]
+		if !ok {
+			return fmt.Errorf("%w: unknown attribute %s", model.ErrInvalidAttribute, code)
+		}
+		if err := definition.CheckValue(attributes[code]); err != nil {
+			return err
+		}
+	}
+
+	if categoryID == "" {
+		return nil
+	}
+	assigned, err := s.attributeRepo.CategoryAttributes(categoryID)
+	if err != nil {
+		return err
+	}
+	for _, attribute := range assigned {
+		if _, ok := attributes[attribute.Attribute.Code]; attribute.Required && !ok {
+			return fmt.Errorf("%w: %s is required in this category", model.ErrInvalidAttribute, attribute.Attribute.Code)
+		}
+	}
+	return nil
+}
+
+// resolveAttributeFilters resolves the attribute filters of filter against
+// their definitions
+func (s *productService) resolveAttributeFilters(filter *model.ProductFilter) error {
+	if len(filter.Attributes) == 0 {
+		return nil
+	}
+
+	codes := make([]string, len(filter.Attributes))
+	for i, attribute := range filter.Attributes {
+		codes[i] = attribute.Code
+	}
+	definitions, err := s.attributeRepo.GetByCodes(codes)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]*model.AttributeDefinition, len(definitions))
+	for _, definition := range definitions {
+		known[definition.Code] = definition
+	}
+
+	for i := range filter.Attributes {
+		definition, ok := known[filter.Attributes[i].Code]
+		if !ok {
+			return fmt.Errorf("%w: unknown attribute %s", model.ErrInvalidQuery, filter.Attributes[i].Code)
+		}
+		if err := filter.Attributes[i].Resolve(definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *productService) DeleteProduct(id string, precondition *model.Precondition, deletedBy string) error {
 	if id == "" {
 		return model.ErrInvalidID
 	}
+	if err := s.checkPrecondition(precondition); err != nil {
+		return err
+	}
 
-	if err := s.repo.Delete(id); err != nil {
+	if err := s.repo.Delete(id, precondition, deletedBy); err != nil {
 		s.logger.Errorw("Failed to delete product from repository", "error", err, "product_id", id)
 		return err
 	}
@@ -100,3 +515,66 @@ func (s *productService) DeleteProduct(id string) error {
 	s.logger.Infow("Product deleted successfully", "product_id", id)
 	return nil
 }
+
+func (s *productService) RestoreProduct(id string) (*model.Product, error) {
+	if id == "" {
+		return nil, model.ErrInvalidID
+	}
+
+	product, err := s.repo.Restore(id)
+	if err != nil {
+		s.logger.Errorw("Failed to restore product in repository", "error", err, "product_id", id)
+		return nil, err
+	}
+
+	s.logger.Infow("Product restored successfully", "product_id", id)
+	return product, nil
+}
+
+func (s *productService) GetTrash(query *model.ProductListQuery) (*model.ProductPage, error) {
+	query.Filter.Deleted = true
+	query.Facets = nil
+	return s.GetAllProducts(query)
+}
+
+func (s *productService) GetDrafts(query *model.ProductListQuery) (*model.ProductPage, error) {
+	query.Filter.Statuses = []string{model.StatusDraft}
+	return s.GetAllProducts(query)
+}
+
+func (s *productService) GetScheduled(query *model.ProductListQuery) (*model.ProductPage, error) {
+	query.Filter.Statuses = []string{model.StatusScheduled}
+	return s.GetAllProducts(query)
+}
+
+func (s *productService) SetStatus(id string, req *model.StatusRequest, precondition *model.Precondition) (*model.Product, error) {
+	if id == "" {
+		return nil, model.ErrInvalidID
+	}
+	if err := s.checkPrecondition(precondition); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := req.Validate(now); err != nil {
+		s.logger.Warnw("Validation failed for product status request", "error", err, "product_id", id)
+		return nil, err
+	}
+
+	return s.repo.SetStatus(id, req, now, precondition)
+}
+
+func (s *productService) EraseUserData(userID string) error {
+	if userID == "" {
+		return model.ErrInvalidID
+	}
+
+	count, err := s.repo.EraseUserReferences(userID)
+	if err != nil {
+		s.logger.Errorw("Failed to erase user data", "error", err, "user_id", userID)
+		return err
+	}
+
+	s.logger.Infow("User data erased", "user_id", userID, "references", count)
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+)
+
+func TestApplyPatch(t *testing.T) {
+	document := `{"name":"Lamp","tags":["home","light"],"attributes":{"color":"red","size":"m"},"price":{"amount":1999,"currency":"USD"}}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        string
+		wantErr     error
+	}{
+		{
+			name:        "merge replaces a field",
+			contentType: model.MergePatchContentType,
+			patch:       `{"name":"Desk lamp"}`,
+			want:        `{"name":"Desk lamp","tags":["home","light"],"attributes":{"color":"red","size":"m"},"price":{"amount":1999,"currency":"USD"}}`,
+		},
+		{
+			name:        "merge removes null fields",
+			contentType: model.MergePatchContentType,
+			patch:       `{"attributes":{"size":null}}`,
+			want:        `{"name":"Lamp","tags":["home","light"],"attributes":{"color":"red"},"price":{"amount":1999,"currency":"USD"}}`,
+		},
+		{
+			name:        "merge replaces arrays whole",
+			contentType: model.MergePatchContentType,
+			patch:       `{"tags":["office"]}`,
+			want:        `{"name":"Lamp","tags":["office"],"attributes":{"color":"red","size":"m"},"price":{"amount":1999,"currency":"USD"}}`,
+		},
+		{
+			name:        "merge with invalid json",
+			contentType: model.MergePatchContentType,
+			patch:       `{"name":`,
+			wantErr:     model.ErrInvalidPatch,
+		},
+		{
+			name:        "json patch operations",
+			contentType: model.JSONPatchContentType,
+			patch:       `[{"op":"replace","path":"/price/amount","value":1499},{"op":"add","path":"/tags/-","value":"sale"},{"op":"remove","path":"/attributes/size"}]`,
+			want:        `{"name":"Lamp","tags":["home","light","sale"],"attributes":{"color":"red"},"price":{"amount":1499,"currency":"USD"}}`,
+		},
+		{
+			name:        "json patch passing test",
+			contentType: model.JSONPatchContentType,
+			patch:       `[{"op":"test","path":"/name","value":"Lamp"},{"op":"replace","path":"/name","value":"Desk lamp"}]`,
+			want:        `{"name":"Desk lamp","tags":["home","light"],"attributes":{"color":"red","size":"m"},"price":{"amount":1999,"currency":"USD"}}`,
+		},
+		{
+			name:        "json patch failing test",
+			contentType: model.JSONPatchContentType,
+			patch:       `[{"op":"test","path":"/name","value":"Chair"},{"op":"replace","path":"/name","value":"Desk lamp"}]`,
+			wantErr:     model.ErrPatchConflict,
+		},
+		{
+			name:        "json patch missing path",
+			contentType: model.JSONPatchContentType,
+			patch:       `[{"op":"remove","path":"/description"}]`,
+			wantErr:     model.ErrPatchConflict,
+		},
+		{
+			name:        "json patch index out of range",
+			contentType: model.JSONPatchContentType,
+			patch:       `[{"op":"replace","path":"/tags/5","value":"x"}]`,
+			wantErr:     model.ErrPatchConflict,
+		},
+		{
+			name:        "json patch unknown operation",
+			contentType: model.JSONPatchContentType,
+			patch:       `[{"op":"rename","path":"/name","value":"x"}]`,
+			wantErr:     model.ErrInvalidPatch,
+		},
+		{
+			name:        "json patch that is not an array",
+			contentType: model.JSONPatchContentType,
+			patch:       `{"op":"replace","path":"/name","value":"x"}`,
+			wantErr:     model.ErrInvalidPatch,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "application/json",
+			patch:       `{"name":"Desk lamp"}`,
+			wantErr:     model.ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyPatch([]byte(document), &model.Patch{ContentType: tt.contentType, Document: []byte(tt.patch)})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("applyPatch() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyPatch() error = %v", err)
+			}
+
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal(got, &gotValue); err != nil {
+				t.Fatalf("applyPatch() returned invalid json %s: %v", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+				t.Fatalf("invalid expected json: %v", err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("applyPatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type PromotionService interface {
+	CreatePromotion(req *model.PromotionRequest) (*model.Promotion, error)
+	GetPromotion(id string) (*model.Promotion, error)
+	GetPromotions() ([]*model.Promotion, error)
+	UpdatePromotion(id string, req *model.PromotionRequest) (*model.Promotion, error)
+	DeletePromotion(id string) error
+	RedeemCoupon(req *model.RedeemCouponRequest) (*model.Promotion, error)
+}
+
+type promotionService struct {
+	repo   repository.PromotionRepository
+	logger *zap.SugaredLogger
+}
+
+func NewPromotionService(repo repository.PromotionRepository, logger *zap.SugaredLogger) PromotionService {
+	return &promotionService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *promotionService) CreatePromotion(req *model.PromotionRequest) (*model.Promotion, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for promotion", "error", err)
+		return nil, err
+	}
+	return s.repo.Create(req)
+}
+
+func (s *promotionService) GetPromotion(id string) (*model.Promotion, error) {
+	if id == "" {
+		return nil, model.ErrPromotionNotFound
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *promotionService) GetPromotions() ([]*model.Promotion, error) {
+	return s.repo.List()
+}
+
+func (s *promotionService) UpdatePromotion(id string, req *model.PromotionRequest) (*model.Promotion, error) {
+	if id == "" {
+		return nil, model.ErrPromotionNotFound
+	}
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for promotion", "error", err, "promotion_id", id)
+		return nil, err
+	}
+	return s.repo.Update(id, req)
+}
+
+func (s *promotionService) DeletePromotion(id string) error {
+	if id == "" {
+		return model.ErrPromotionNotFound
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *promotionService) RedeemCoupon(req *model.RedeemCouponRequest) (*model.Promotion, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Redeem(req, time.Now())
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type PublishSchedulerConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// PublishScheduler makes scheduled products active at their publish_at and
+// archives active products at their unpublish_at
+type PublishScheduler struct {
+	repo   repository.ProductRepository
+	config *PublishSchedulerConfig
+	logger *zap.SugaredLogger
+}
+
+func NewPublishScheduler(repo repository.ProductRepository, config *PublishSchedulerConfig, logger *zap.SugaredLogger) *PublishScheduler {
+	return &PublishScheduler{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run publishes and unpublishes due products every interval until ctx is done
+func (s *PublishScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx)
+		}
+	}
+}
+
+// run publishes before unpublishing, so a product whose whole window passed
+// while the scheduler was down still ends up archived
+func (s *PublishScheduler) run(ctx context.Context) {
+	now := time.Now()
+
+	for ctx.Err() == nil {
+		published, err := s.repo.PublishDue(now, s.config.BatchSize)
+		if err != nil {
+			s.logger.Errorw("Failed to publish scheduled products", "error", err)
+			return
+		}
+		if published > 0 {
+			s.logger.Infow("Published scheduled products", "count", published)
+		}
+		if published < s.config.BatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		archived, err := s.repo.UnpublishDue(now, s.config.BatchSize)
+		if err != nil {
+			s.logger.Errorw("Failed to unpublish products", "error", err)
+			return
+		}
+		if archived > 0 {
+			s.logger.Infow("Unpublished products", "count", archived)
+		}
+		if archived < s.config.BatchSize {
+			break
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ReservationConfig bounds how long reservations hold stock
+type ReservationConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// ReservationService manages reservations on behalf of a user. Reservations of
+// other users are reported as not found unless the user is an admin.
+type ReservationService interface {
+	CreateReservation(req *model.CreateReservationRequest) (*model.Reservation, error)
+	GetReservation(id, userID string, admin bool) (*model.Reservation, error)
+	CommitReservation(id, userID string, admin bool) (*model.Reservation, error)
+	CancelReservation(id, userID string, admin bool) (*model.Reservation, error)
+}
+
+type reservationService struct {
+	repo   repository.ReservationRepository
+	config *ReservationConfig
+	logger *zap.SugaredLogger
+}
+
+func NewReservationService(repo repository.ReservationRepository, config *ReservationConfig, logger *zap.SugaredLogger) ReservationService {
+	return &reservationService{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+func (s *reservationService) CreateReservation(req *model.CreateReservationRequest) (*model.Reservation, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for reservation", "error", err)
+		return nil, err
+	}
+
+	ttl := s.config.DefaultTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > s.config.MaxTTL {
+		return nil, fmt.Errorf("%w: ttl_seconds must be at most %d", model.ErrInvalidReservation, int(s.config.MaxTTL.Seconds()))
+	}
+
+	return s.repo.Create(req, time.Now().Add(ttl))
+}
+
+func (s *reservationService) GetReservation(id, userID string, admin bool) (*model.Reservation, error) {
+	if id == "" {
+		return nil, model.ErrInvalidID
+	}
+
+	reservation, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && (reservation.CreatedBy == nil || *reservation.CreatedBy != userID) {
+		return nil, model.ErrReservationNotFound
+	}
+	return reservation, nil
+}
+
+func (s *reservationService) CommitReservation(id, userID string, admin bool) (*model.Reservation, error) {
+	if _, err := s.GetReservation(id, userID, admin); err != nil {
+		return nil, err
+	}
+	return s.repo.Commit(id, userID)
+}
+
+func (s *reservationService) CancelReservation(id, userID string, admin bool) (*model.Reservation, error) {
+	if _, err := s.GetReservation(id, userID, admin); err != nil {
+		return nil, err
+	}
+	return s.repo.Cancel(id)
+}
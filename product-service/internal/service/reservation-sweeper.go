@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type SweepConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// ReservationSweeper releases the stock held by reservations that expired
+// without being committed or cancelled
+type ReservationSweeper struct {
+	repo   repository.ReservationRepository
+	config *SweepConfig
+	logger *zap.SugaredLogger
+}
+
+func NewReservationSweeper(repo repository.ReservationRepository, config *SweepConfig, logger *zap.SugaredLogger) *ReservationSweeper {
+	return &ReservationSweeper{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run expires reservations every interval until ctx is done
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *ReservationSweeper) sweep(ctx context.Context) {
+	now := time.Now()
+
+	total := 0
+	for ctx.Err() == nil {
+		expired, err := s.repo.Expire(now, s.config.BatchSize)
+		total += expired
+		if err != nil {
+			s.logger.Errorw("Failed to expire reservations", "error", err)
+			break
+		}
+		if expired < s.config.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Infow("Expired reservations", "count", total)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type StockService interface {
+	// AdjustStock applies a stock change and records it in the ledger
+	AdjustStock(productID string, req *model.StockAdjustmentRequest) (*model.StockMovement, error)
+	// GetStockHistory returns a page of the stock ledger of a product, newest first
+	GetStockHistory(productID string, query *model.StockMovementQuery) (*model.StockHistory, error)
+}
+
+type stockService struct {
+	repo   repository.StockRepository
+	logger *zap.SugaredLogger
+}
+
+func NewStockService(repo repository.StockRepository, logger *zap.SugaredLogger) StockService {
+	return &stockService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *stockService) AdjustStock(productID string, req *model.StockAdjustmentRequest) (*model.StockMovement, error) {
+	if productID == "" {
+		return nil, model.ErrInvalidID
+	}
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for stock adjustment", "error", err, "product_id", productID)
+		return nil, err
+	}
+
+	return s.repo.Adjust(productID, req)
+}
+
+func (s *stockService) GetStockHistory(productID string, query *model.StockMovementQuery) (*model.StockHistory, error) {
+	if productID == "" {
+		return nil, model.ErrInvalidID
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.History(productID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if history.Stock != history.LedgerStock {
+		s.logger.Warnw("Product stock does not match its ledger", "product_id", productID,
+			"stock", history.Stock, "ledger_stock", history.LedgerStock)
+	}
+	return history, nil
+}
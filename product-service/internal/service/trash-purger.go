@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type PurgeConfig struct {
+	// Retention is how long deleted products stay in the trash
+	Retention time.Duration
+	Interval  time.Duration
+	BatchSize int
+}
+
+// TrashPurger permanently deletes products that have been in the trash longer
+// than the retention period
+type TrashPurger struct {
+	repo   repository.ProductRepository
+	config *PurgeConfig
+	logger *zap.SugaredLogger
+}
+
+func NewTrashPurger(repo repository.ProductRepository, config *PurgeConfig, logger *zap.SugaredLogger) *TrashPurger {
+	return &TrashPurger{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run purges expired products every interval until ctx is done
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.purge(ctx)
+		}
+	}
+}
+
+// purge deletes expired products in batches so a large backlog does not hold
+// locks for long
+func (p *TrashPurger) purge(ctx context.Context) {
+	before := time.Now().Add(-p.config.Retention)
+
+	var total int64
+	for ctx.Err() == nil {
+		purged, err := p.repo.Purge(before, p.config.BatchSize)
+		if err != nil {
+			p.logger.Errorw("Failed to purge trash", "error", err)
+			return
+		}
+		total += purged
+		if purged < int64(p.config.BatchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		p.logger.Infow("Purged deleted products", "count", total, "deleted_before", before)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/leandrowiemesfilho/product-service/internal/model"
+	"github.com/leandrowiemesfilho/product-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type VariantService interface {
+	// SetVariantOptions replaces the option definitions of a product. Options or
+	// values still used by a variant can not be removed.
+	SetVariantOptions(productID string, req *model.SetVariantOptionsRequest) (*model.Product, error)
+	GetVariants(productID string) ([]*model.ProductVariant, error)
+	GetVariant(productID, id string) (*model.ProductVariant, error)
+	CreateVariant(productID string, req *model.VariantRequest) (*model.ProductVariant, error)
+	UpdateVariant(productID, id string, req *model.VariantRequest) (*model.ProductVariant, error)
+	// DeleteVariant removes a variant; actorID is recorded on the stock movement
+	DeleteVariant(productID, id, actorID string) error
+}
+
+type variantService struct {
+	productRepo repository.ProductRepository
+	variantRepo repository.VariantRepository
+	logger      *zap.SugaredLogger
+}
+
+func NewVariantService(productRepo repository.ProductRepository, variantRepo repository.VariantRepository, logger *zap.SugaredLogger) VariantService {
+	return &variantService{
+		productRepo: productRepo,
+		variantRepo: variantRepo,
+		logger:      logger,
+	}
+}
+
+func (s *variantService) SetVariantOptions(productID string, req *model.SetVariantOptionsRequest) (*model.Product, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for variant options", "error", err, "product_id", productID)
+		return nil, err
+	}
+
+	variants, err := s.GetVariants(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, variant := range variants {
+		if err := model.MatchVariantOptions(req.Options, variant.Options); err != nil {
+			return nil, fmt.Errorf("%w: variant %s: %v", model.ErrVariantOptionsInUse, variant.SKU, err)
+		}
+	}
+
+	if err := s.variantRepo.SetOptions(productID, req.Options); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Variant options updated", "product_id", productID, "options", len(req.Options))
+	return s.productRepo.GetByID(productID)
+}
+
+func (s *variantService) GetVariants(productID string) ([]*model.ProductVariant, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.variantRepo.ListByProduct(productID)
+}
+
+func (s *variantService) GetVariant(productID, id string) (*model.ProductVariant, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.variantRepo.GetByID(productID, id)
+}
+
+func (s *variantService) CreateVariant(productID string, req *model.VariantRequest) (*model.ProductVariant, error) {
+	if err := s.validateVariant(productID, req); err != nil {
+		return nil, err
+	}
+
+	variant, err := s.variantRepo.Create(productID, req)
+	if err != nil {
+		s.logger.Errorw("Failed to create variant in repository", "error", err, "product_id", productID)
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+func (s *variantService) UpdateVariant(productID, id string, req *model.VariantRequest) (*model.ProductVariant, error) {
+	if err := s.validateVariant(productID, req); err != nil {
+		return nil, err
+	}
+
+	variant, err := s.variantRepo.Update(productID, id, req)
+	if err != nil {
+		s.logger.Errorw("Failed to update variant in repository", "error", err, "variant_id", id)
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+func (s *variantService) DeleteVariant(productID, id, actorID string) error {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return err
+	}
+
+	if err := s.variantRepo.Delete(productID, id, actorID); err != nil {
+		s.logger.Errorw("Failed to delete variant in repository", "error", err, "variant_id", id)
+		return err
+	}
+
+	return nil
+}
+
+// validateVariant checks the request against the product: its options must match the
+// product's definitions and its price be in the product currency
+func (s *variantService) validateVariant(productID string, req *model.VariantRequest) error {
+	if err := req.Validate(); err != nil {
+		s.logger.Warnw("Validation failed for variant request", "error", err, "product_id", productID)
+		return err
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return err
+	}
+
+	if req.Price != nil && req.Price.Currency != product.Price.Currency {
+		return fmt.Errorf("%w: variant prices must be in the product currency %s", model.ErrInvalidPrice, product.Price.Currency)
+	}
+
+	return model.MatchVariantOptions(product.VariantOptions, req.Options)
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute or climb out of the store
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// ImmutableCacheControl lets CDNs and browsers cache a blob forever. Blob keys
+// are never reused, so a changed file always has a new URL.
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// BlobStore stores files under slash separated keys and serves them from public URLs
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes a blob; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public address of a blob
+	URL(key string) string
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localStore keeps blobs in a directory, for development and single instance
+// deployments. The directory is expected to be served at baseURL.
+type localStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir, baseURL string) (BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &localStore{
+		dir:     filepath.Clean(dir),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (s *localStore) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the blob next to its destination first so readers never see a
+// partial file
+func (s *localStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	return nil
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+
+	// Directories left empty are removed; removing one that is not fails
+	for dir := filepath.Dir(path); dir != s.dir && strings.HasPrefix(dir, s.dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *localStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config addresses a bucket of Amazon S3 or of an S3 compatible store such as
+// MinIO. PathStyle puts the bucket in the path rather than the host name, which
+// most compatible stores expect.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	Timeout   time.Duration
+}
+
+// s3Store talks to the S3 REST API directly, signing requests with AWS
+// Signature Version 4
+type s3Store struct {
+	config  *S3Config
+	bucket  *url.URL
+	baseURL string
+	client  *http.Client
+	now     func() time.Time
+}
+
+// NewS3Store returns a store of config.Bucket. Blobs are served from baseURL,
+// typically a CDN in front of the bucket, or from the bucket itself when empty.
+func NewS3Store(config *S3Config, baseURL string) (BlobStore, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+	if config.Bucket == "" || config.Region == "" {
+		return nil, fmt.Errorf("s3 store requires a bucket and a region")
+	}
+
+	bucket := &url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host, Path: strings.TrimSuffix(endpoint.Path, "/")}
+	if config.PathStyle {
+		bucket.Path += "/" + config.Bucket
+	} else {
+		bucket.Host = config.Bucket + "." + bucket.Host
+	}
+	if baseURL == "" {
+		baseURL = bucket.String()
+	}
+
+	return &s3Store{
+		config:  config,
+		bucket:  bucket,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: config.Timeout},
+		now:     time.Now,
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	header.Set("Cache-Control", ImmutableCacheControl)
+	return s.do(ctx, http.MethodPut, key, data, header, http.StatusOK)
+}
+
+// Delete succeeds for missing objects, which S3 reports as deleted too
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	return s.do(ctx, http.MethodDelete, key, nil, http.Header{}, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+func (s *s3Store) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *s3Store) do(ctx context.Context, method, key string, body []byte, header http.Header, expected ...int) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	target := *s.bucket
+	target.Path += "/" + key
+	target.RawPath = escapePath(target.Path)
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	req.ContentLength = int64(len(body))
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 %s %s: %w", method, key, err)
+	}
+	defer resp.Body.Close()
+
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			io.Copy(io.Discard, resp.Body)
+			return nil
+		}
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %s responded with status %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(message)))
+}
+
+// sign adds the AWS Signature Version 4 authorization of req
+func (s *s3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// escapePath percent-encodes every byte of path except unreserved characters
+// and slashes, as Signature Version 4 requires
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}